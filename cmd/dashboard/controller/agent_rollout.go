@@ -0,0 +1,332 @@
+package controller
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+const (
+	agentRolloutDefaultCanaryPercent = 10
+	agentRolloutDefaultBatchSize     = 10
+	agentRolloutDefaultBatchDelay    = 300
+	agentRolloutDefaultTimeout       = 300
+	agentRolloutMaxTimeout           = 3600
+)
+
+// List agent version distribution
+// @Summary List agent version distribution
+// @Security BearerAuth
+// @Schemes
+// @Description Count servers by reported agent version, most common first
+// @Tags auth required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.AgentVersionCount]
+// @Router /agent-version [get]
+func listAgentVersion(c *gin.Context) ([]*model.AgentVersionCount, error) {
+	counts := make(map[string]*model.AgentVersionCount)
+	for _, s := range singleton.ServerShared.GetSortedList() {
+		if !s.HasPermission(c) {
+			continue
+		}
+		var version string
+		if s.Host != nil {
+			version = s.Host.Version
+		}
+		vc, ok := counts[version]
+		if !ok {
+			vc = &model.AgentVersionCount{Version: version}
+			counts[version] = vc
+		}
+		vc.Count++
+		vc.Servers = append(vc.Servers, s.ID)
+	}
+
+	list := utils.MapValuesToSlice(counts)
+	slices.SortFunc(list, func(a, b *model.AgentVersionCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Version, b.Version))
+	})
+	return list, nil
+}
+
+// Create agent rollout
+// @Summary Create agent rollout
+// @Security BearerAuth
+// @Schemes
+// @Description Upgrade agents in waves: a canary wave first, then batches with a delay in between. The rollout pauses itself when too many upgraded servers fail to reconnect with the new version
+// @Tags auth required
+// @Accept json
+// @param request body model.AgentRolloutForm true "AgentRolloutForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /agent-rollout [post]
+func createAgentRollout(c *gin.Context) (uint64, error) {
+	var rf model.AgentRolloutForm
+	if err := c.ShouldBindJSON(&rf); err != nil {
+		return 0, err
+	}
+
+	if rf.LatestVersion == "" {
+		return 0, singleton.Localizer.ErrorT("%s is required", "latest_version")
+	}
+	if rf.CanaryPercent == 0 {
+		rf.CanaryPercent = agentRolloutDefaultCanaryPercent
+	}
+	if rf.CanaryPercent > 100 {
+		return 0, singleton.Localizer.ErrorT("canary percent must be between 1 and 100")
+	}
+	if rf.BatchSize == 0 {
+		rf.BatchSize = agentRolloutDefaultBatchSize
+	}
+	if rf.BatchDelay == 0 {
+		rf.BatchDelay = agentRolloutDefaultBatchDelay
+	}
+	if rf.ReconnectTimeout == 0 {
+		rf.ReconnectTimeout = agentRolloutDefaultTimeout
+	}
+	if rf.ReconnectTimeout > agentRolloutMaxTimeout {
+		return 0, singleton.Localizer.ErrorT("timeout cannot exceed %d seconds", agentRolloutMaxTimeout)
+	}
+	if err := validateSelector(rf.ServerSelector); err != nil {
+		return 0, err
+	}
+
+	serverIDs, err := resolveServerGroups(c, rf.Servers, rf.ServerGroups)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range singleton.ServerShared.Select(rf.ServerSelector) {
+		if s, ok := singleton.ServerShared.Get(id); ok && s.HasPermission(c) && !slices.Contains(serverIDs, id) {
+			serverIDs = append(serverIDs, id)
+		}
+	}
+	if len(serverIDs) == 0 {
+		return 0, singleton.Localizer.ErrorT("no servers selected")
+	}
+	if !singleton.ServerShared.CheckPermission(c, slices.Values(serverIDs)) {
+		return 0, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	slices.Sort(serverIDs)
+	servers := make([]*model.Server, 0, len(serverIDs))
+	for _, id := range serverIDs {
+		s, _ := singleton.ServerShared.Get(id)
+		if s == nil {
+			return 0, singleton.Localizer.ErrorT("server id %d does not exist", id)
+		}
+		servers = append(servers, s)
+	}
+
+	var r model.AgentRollout
+	r.UserID = getUid(c)
+	r.Name = rf.Name
+	r.LatestVersion = rf.LatestVersion
+	r.CanaryPercent = rf.CanaryPercent
+	r.BatchSize = rf.BatchSize
+	r.BatchDelay = rf.BatchDelay
+	r.ReconnectTimeout = rf.ReconnectTimeout
+	r.MaxFailures = rf.MaxFailures
+	r.Servers = serverIDs
+
+	if err := singleton.AgentRolloutShared.Start(&r, servers); err != nil {
+		return 0, err
+	}
+	return r.ID, nil
+}
+
+// List agent rollouts
+// @Summary List agent rollouts
+// @Security BearerAuth
+// @Schemes
+// @Description List agent rollouts, members can only see their own
+// @Tags auth required
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.AgentRollout, model.AgentRollout]
+// @Router /agent-rollout [get]
+func listAgentRollout(c *gin.Context) (*model.Value[[]*model.AgentRollout], error) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx := singleton.DB.Model(&model.AgentRollout{})
+	if !isAdmin(c) {
+		tx = tx.Where("user_id = ?", getUid(c))
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var rollouts []*model.AgentRollout
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&rollouts).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.AgentRollout]{
+		Value: rollouts,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// Get agent rollout progress
+// @Summary Get agent rollout progress
+// @Security BearerAuth
+// @Schemes
+// @Description Get an agent rollout with the upgrade state of every server
+// @Tags auth required
+// @param id path uint true "Rollout ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.AgentRolloutProgress]
+// @Router /agent-rollout/{id} [get]
+func getAgentRollout(c *gin.Context) (*model.AgentRolloutProgress, error) {
+	r, err := getAgentRolloutWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*model.AgentRolloutTarget
+	if err := singleton.DB.Where("rollout_id = ?", r.ID).Order("wave, id").Find(&targets).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	p := &model.AgentRolloutProgress{AgentRollout: r, Total: len(targets), Targets: targets}
+	for _, t := range targets {
+		p.Waves = max(p.Waves, t.Wave+1)
+		switch t.Status {
+		case model.AgentUpgradePending:
+			p.Pending++
+		case model.AgentUpgradeSent:
+			p.Sent++
+		case model.AgentUpgradeSucceeded:
+			p.Succeeded++
+		case model.AgentUpgradeFailed:
+			p.Failed++
+		case model.AgentUpgradeSkipped:
+			p.Skipped++
+		}
+	}
+	return p, nil
+}
+
+// Pause agent rollout
+// @Summary Pause agent rollout
+// @Security BearerAuth
+// @Schemes
+// @Description Stop dispatching further waves, servers already upgrading are still tracked
+// @Tags auth required
+// @param id path uint true "Rollout ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /agent-rollout/{id}/pause [post]
+func pauseAgentRollout(c *gin.Context) (any, error) {
+	r, err := getAgentRolloutWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+	return nil, singleton.AgentRolloutShared.Pause(r, "paused manually")
+}
+
+// Resume agent rollout
+// @Summary Resume agent rollout
+// @Security BearerAuth
+// @Schemes
+// @Description Resume a paused rollout, the failure count starts over
+// @Tags auth required
+// @param id path uint true "Rollout ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /agent-rollout/{id}/resume [post]
+func resumeAgentRollout(c *gin.Context) (any, error) {
+	r, err := getAgentRolloutWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+	return nil, singleton.AgentRolloutShared.Resume(r)
+}
+
+// Cancel agent rollout
+// @Summary Cancel agent rollout
+// @Security BearerAuth
+// @Schemes
+// @Description Cancel a rollout, servers not yet upgraded are skipped
+// @Tags auth required
+// @param id path uint true "Rollout ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /agent-rollout/{id}/cancel [post]
+func cancelAgentRollout(c *gin.Context) (any, error) {
+	r, err := getAgentRolloutWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+	return nil, singleton.AgentRolloutShared.Cancel(r)
+}
+
+// Batch delete agent rollouts
+// @Summary Batch delete agent rollouts
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete agent rollouts
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/agent-rollout [post]
+func batchDeleteAgentRollout(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	var rollouts []model.AgentRollout
+	if err := singleton.DB.Where("id in (?)", ids).Find(&rollouts).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	for _, r := range rollouts {
+		if !r.HasPermission(c) {
+			return nil, singleton.Localizer.ErrorT("permission denied")
+		}
+	}
+
+	if err := singleton.AgentRolloutShared.Delete(ids); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
+func getAgentRolloutWithPermission(c *gin.Context) (*model.AgentRollout, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var r model.AgentRollout
+	if err := singleton.DB.First(&r, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("rollout id %d does not exist", id)
+	}
+
+	if !r.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return &r, nil
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"maps"
+	"slices"
 	"strconv"
 	"time"
 
@@ -59,6 +60,8 @@ func createAlertRule(c *gin.Context) (uint64, error) {
 	r.Rules = arf.Rules
 	r.FailTriggerTasks = arf.FailTriggerTasks
 	r.RecoverTriggerTasks = arf.RecoverTriggerTasks
+	r.FailTriggerWorkflows = arf.FailTriggerWorkflows
+	r.RecoverTriggerWorkflows = arf.RecoverTriggerWorkflows
 	r.NotificationGroupID = arf.NotificationGroupID
 	enable := arf.Enable
 	r.TriggerMode = arf.TriggerMode
@@ -113,6 +116,8 @@ func updateAlertRule(c *gin.Context) (any, error) {
 	r.Rules = arf.Rules
 	r.FailTriggerTasks = arf.FailTriggerTasks
 	r.RecoverTriggerTasks = arf.RecoverTriggerTasks
+	r.FailTriggerWorkflows = arf.FailTriggerWorkflows
+	r.RecoverTriggerWorkflows = arf.RecoverTriggerWorkflows
 	r.NotificationGroupID = arf.NotificationGroupID
 	enable := arf.Enable
 	r.TriggerMode = arf.TriggerMode
@@ -172,6 +177,12 @@ func validateRule(c *gin.Context, r *model.AlertRule) error {
 			if !singleton.ServerShared.CheckPermission(c, maps.Keys(rule.Ignore)) {
 				return singleton.Localizer.ErrorT("permission denied")
 			}
+			if err := checkServerGroups(c, rule.IgnoreGroups); err != nil {
+				return err
+			}
+			if err := validateSelector(rule.IgnoreSelector); err != nil {
+				return err
+			}
 
 			if !rule.IsTransferDurationRule() {
 				if rule.Duration < 3 {
@@ -192,5 +203,9 @@ func validateRule(c *gin.Context, r *model.AlertRule) error {
 	} else {
 		return singleton.Localizer.ErrorT("need to configure at least a single rule")
 	}
+	if !singleton.WorkflowShared.CheckPermission(c, slices.Values(r.FailTriggerWorkflows)) ||
+		!singleton.WorkflowShared.CheckPermission(c, slices.Values(r.RecoverTriggerWorkflows)) {
+		return singleton.Localizer.ErrorT("permission denied")
+	}
 	return nil
 }
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+const (
+	commandJobDefaultTimeout = 300
+	commandJobMaxTimeout     = 3600
+)
+
+// Run command
+// @Summary Run command
+// @Security BearerAuth
+// @Schemes
+// @Description Run a command on the given servers and server groups, return the job ID
+// @Tags auth required
+// @Accept json
+// @param request body model.CommandJobForm true "CommandJobForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /command-job [post]
+func createCommandJob(c *gin.Context) (uint64, error) {
+	var cf model.CommandJobForm
+	if err := c.ShouldBindJSON(&cf); err != nil {
+		return 0, err
+	}
+
+	if cf.Command == "" {
+		return 0, singleton.Localizer.ErrorT("command cannot be empty")
+	}
+	if cf.Timeout == 0 {
+		cf.Timeout = commandJobDefaultTimeout
+	}
+	if cf.Timeout > commandJobMaxTimeout {
+		return 0, singleton.Localizer.ErrorT("timeout cannot exceed %d seconds", commandJobMaxTimeout)
+	}
+
+	serverIDs, err := resolveServerGroups(c, cf.Servers, cf.ServerGroups)
+	if err != nil {
+		return 0, err
+	}
+	if len(serverIDs) == 0 {
+		return 0, singleton.Localizer.ErrorT("no servers selected")
+	}
+	if !singleton.ServerShared.CheckPermission(c, slices.Values(serverIDs)) {
+		return 0, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	servers := make([]*model.Server, 0, len(serverIDs))
+	for _, id := range serverIDs {
+		s, _ := singleton.ServerShared.Get(id)
+		if s == nil {
+			return 0, singleton.Localizer.ErrorT("server id %d does not exist", id)
+		}
+		servers = append(servers, s)
+	}
+
+	var job model.CommandJob
+	job.UserID = getUid(c)
+	job.Command = cf.Command
+	job.Timeout = cf.Timeout
+	job.Servers = serverIDs
+
+	if err := singleton.CommandJobShared.Start(&job, servers); err != nil {
+		return 0, newGormError("%v", err)
+	}
+	return job.ID, nil
+}
+
+// List command jobs
+// @Summary List command jobs
+// @Security BearerAuth
+// @Schemes
+// @Description List command jobs, members can only see their own
+// @Tags auth required
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.CommandJob, model.CommandJob]
+// @Router /command-job [get]
+func listCommandJob(c *gin.Context) (*model.Value[[]*model.CommandJob], error) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx := singleton.DB.Model(&model.CommandJob{})
+	if !isAdmin(c) {
+		tx = tx.Where("user_id = ?", getUid(c))
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var jobs []*model.CommandJob
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&jobs).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.CommandJob]{
+		Value: jobs,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// Get command job
+// @Summary Get command job
+// @Security BearerAuth
+// @Schemes
+// @Description Get a command job with the result of every server
+// @Tags auth required
+// @param id path uint true "Job ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.CommandJobDetail]
+// @Router /command-job/{id} [get]
+func getCommandJob(c *gin.Context) (*model.CommandJobDetail, error) {
+	job, err := getCommandJobWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &model.CommandJobDetail{CommandJob: job}
+	if err := singleton.DB.Where("job_id = ?", job.ID).Order("id").Find(&detail.Results).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return detail, nil
+}
+
+// Command job stream
+// @Summary Command job stream
+// @Description Stream the result of every server as it arrives, the connection is closed when the job finishes
+// @Tags auth required
+// @param id path uint true "Job ID"
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /ws/command-job/{id} [get]
+func commandJobStream(c *gin.Context) (any, error) {
+	job, err := getCommandJobWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return nil, newWsError("%v", err)
+	}
+	defer conn.Close()
+
+	send := func(result *model.CommandJobResult) error {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}
+
+	results, ch, cancel, running := singleton.CommandJobShared.Subscribe(job.ID)
+	if !running {
+		// 已结束的批量命令直接发送全部结果
+		var finished []*model.CommandJobResult
+		singleton.DB.Where("job_id = ?", job.ID).Order("id").Find(&finished)
+		for _, r := range finished {
+			if err := send(r); err != nil {
+				break
+			}
+		}
+		return nil, newWsError("")
+	}
+	defer cancel()
+
+	for i := range results {
+		if err := send(&results[i]); err != nil {
+			return nil, newWsError("")
+		}
+	}
+
+	// 客户端断开连接时结束推送
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case result, ok := <-ch:
+			if !ok {
+				return nil, newWsError("")
+			}
+			if err := send(&result); err != nil {
+				return nil, newWsError("")
+			}
+		case <-closed:
+			return nil, newWsError("")
+		case <-time.After(time.Second * 10):
+			if err := conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+				return nil, newWsError("")
+			}
+		}
+	}
+}
+
+func getCommandJobWithPermission(c *gin.Context) (*model.CommandJob, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var job model.CommandJob
+	if err := singleton.DB.First(&job, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("command job id %d does not exist", id)
+	}
+
+	if !job.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return &job, nil
+}
+
+// resolveServerGroups 展开服务器分组并与服务器列表合并去重
+func resolveServerGroups(c *gin.Context, servers, groups []uint64) ([]uint64, error) {
+	if err := checkServerGroups(c, groups); err != nil {
+		return nil, err
+	}
+	return utils.Unique(append(slices.Clone(servers), singleton.ServerGroupShared.Members(groups...)...)), nil
+}
@@ -56,12 +56,16 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	if err := authMiddleware.MiddlewareInit(); err != nil {
 		log.Fatal("authMiddleware.MiddlewareInit Error:" + err.Error())
 	}
+	natAuthMiddleware = authMiddleware
 	api := r.Group("api/v1")
 	api.StaticFS(singleton.Conf.LocalPath, http.Dir(singleton.Conf.LocalPath))
 	api.POST("/login", authMiddleware.LoginHandler)
 	api.GET("/oauth2/:provider", commonHandler(oauth2redirect))
 
 	fallbackAuthMw := fallbackAuthMiddleware(authMiddleware)
+	api.GET("/status/:slug", commonHandler(showStatusPage))
+	r.GET("/status/:slug", renderStatusPage)
+
 	fallbackAuth := api.Group("", fallbackAuthMw)
 	fallbackAuth.GET("/setting", commonHandler(listConfig))
 	fallbackAuth.GET("/oauth2/callback", commonHandler(oauth2callback(authMiddleware)))
@@ -99,9 +103,18 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 
 	auth.POST("/terminal", commonHandler(createTerminal))
 	auth.GET("/ws/terminal/:id", commonHandler(terminalStream))
+	auth.POST("/terminal/:id/share", commonHandler(shareTerminal))
+	auth.POST("/terminal/:id/unshare", commonHandler(unshareTerminal))
+	auth.GET("/terminal/:id/viewer", commonHandler(listTerminalViewer))
+	auth.POST("/terminal/:id/viewer/batch-revoke", commonHandler(batchRevokeTerminalViewer))
+	auth.GET("/ws/terminal-view/:token", commonHandler(terminalViewStream))
+	auth.GET("/terminal-recording", pCommonHandler(listTerminalRecording))
+	auth.GET("/terminal-recording/:id/download", adminHandler(downloadTerminalRecording))
+	auth.POST("/batch-delete/terminal-recording", adminHandler(batchDeleteTerminalRecording))
 
 	auth.GET("/file", commonHandler(createFM))
 	auth.GET("/ws/file/:id", commonHandler(fmStream))
+	auth.GET("/fm-audit-log", pCommonHandler(listFMAuditLog))
 
 	auth.GET("/profile", commonHandler(getProfile))
 	auth.POST("/profile", commonHandler(updateProfile))
@@ -109,9 +122,11 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 
 	auth.GET("/user", adminHandler(listUser))
 	auth.POST("/user", adminHandler(createUser))
+	auth.PATCH("/user/:id/fm-limit", adminHandler(updateUserFMLimit))
 	auth.POST("/batch-delete/user", adminHandler(batchDeleteUser))
 
 	auth.GET("/service/list", listHandler(listService))
+	auth.GET("/service/sla", commonHandler(getServiceSLAReport))
 	auth.POST("/service", commonHandler(createService))
 	auth.PATCH("/service/:id", commonHandler(updateService))
 	auth.POST("/batch-delete/service", commonHandler(batchDeleteService))
@@ -131,6 +146,10 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.POST("/server/config", commonHandler(setServerConfig))
 	auth.POST("/batch-delete/server", commonHandler(batchDeleteServer))
 	auth.POST("/force-update/server", commonHandler(forceUpdateServer))
+	auth.GET("/server/:id/cron-execution", pCommonHandler(listServerCronExecution))
+	auth.GET("/server/:id/ddns-history", pCommonHandler(listServerDDNSHistory))
+	auth.GET("/server/:id/ddns-drift", commonHandler(listServerDDNSDrift))
+	auth.GET("/server/:id/host-history", pCommonHandler(listServerHostHistory))
 
 	auth.GET("/notification", listHandler(listNotification))
 	auth.POST("/notification", commonHandler(createNotification))
@@ -146,18 +165,74 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.POST("/cron", commonHandler(createCron))
 	auth.PATCH("/cron/:id", commonHandler(updateCron))
 	auth.GET("/cron/:id/manual", commonHandler(manualTriggerCron))
+	auth.POST("/cron/:id/manual", commonHandler(manualTriggerCron))
+	auth.GET("/cron/:id/execution", pCommonHandler(listCronExecution))
 	auth.POST("/batch-delete/cron", commonHandler(batchDeleteCron))
+	auth.GET("/cron-schedule", commonHandler(previewCronSchedule))
+	auth.GET("/cron-calendar", commonHandler(getCronCalendar))
+
+	auth.GET("/workflow", listHandler(listWorkflow))
+	auth.POST("/workflow", commonHandler(createWorkflow))
+	auth.PATCH("/workflow/:id", commonHandler(updateWorkflow))
+	auth.GET("/workflow/:id/manual", commonHandler(manualTriggerWorkflow))
+	auth.GET("/workflow/:id/run", pCommonHandler(listWorkflowRun))
+	auth.GET("/workflow/:id/run/:run/execution", pCommonHandler(listWorkflowRunExecution))
+	auth.POST("/batch-delete/workflow", commonHandler(batchDeleteWorkflow))
+
+	auth.GET("/command-job", pCommonHandler(listCommandJob))
+	auth.POST("/command-job", commonHandler(createCommandJob))
+	auth.GET("/command-job/:id", commonHandler(getCommandJob))
+	auth.GET("/ws/command-job/:id", commonHandler(commandJobStream))
+
+	auth.GET("/agent-version", commonHandler(listAgentVersion))
+	auth.GET("/agent-rollout", pCommonHandler(listAgentRollout))
+	auth.POST("/agent-rollout", commonHandler(createAgentRollout))
+	auth.GET("/agent-rollout/:id", commonHandler(getAgentRollout))
+	auth.POST("/agent-rollout/:id/pause", commonHandler(pauseAgentRollout))
+	auth.POST("/agent-rollout/:id/resume", commonHandler(resumeAgentRollout))
+	auth.POST("/agent-rollout/:id/cancel", commonHandler(cancelAgentRollout))
+	auth.POST("/batch-delete/agent-rollout", commonHandler(batchDeleteAgentRollout))
+
+	auth.GET("/enrollment-token", adminHandler(listEnrollmentToken))
+	auth.POST("/enrollment-token", adminHandler(createEnrollmentToken))
+	auth.PATCH("/enrollment-token/:id", adminHandler(updateEnrollmentToken))
+	auth.POST("/enrollment-token/:id/revoke", adminHandler(revokeEnrollmentToken))
+	auth.POST("/batch-delete/enrollment-token", adminHandler(batchDeleteEnrollmentToken))
+	auth.GET("/agent-enrollment", adminHandler(listAgentEnrollment))
+
+	auth.GET("/pending-agent", adminHandler(listPendingAgent))
+	auth.POST("/pending-agent/:id/approve", adminHandler(approvePendingAgent))
+	auth.POST("/pending-agent/:id/reject", adminHandler(rejectPendingAgent))
+	auth.POST("/batch-delete/pending-agent", adminHandler(batchDeletePendingAgent))
+
+	auth.GET("/secret", listHandler(listSecret))
+	auth.POST("/secret", commonHandler(createSecret))
+	auth.PATCH("/secret/:id", commonHandler(updateSecret))
+	auth.POST("/batch-delete/secret", commonHandler(batchDeleteSecret))
 
 	auth.GET("/ddns", listHandler(listDDNS))
 	auth.GET("/ddns/providers", commonHandler(listProviders))
 	auth.POST("/ddns", commonHandler(createDDNS))
 	auth.PATCH("/ddns/:id", commonHandler(updateDDNS))
+	auth.GET("/ddns/:id/history", pCommonHandler(listDDNSHistory))
+	auth.GET("/ddns/:id/drift", commonHandler(listDDNSDrift))
 	auth.POST("/batch-delete/ddns", commonHandler(batchDeleteDDNS))
 
 	auth.GET("/nat", listHandler(listNAT))
 	auth.POST("/nat", commonHandler(createNAT))
 	auth.PATCH("/nat/:id", commonHandler(updateNAT))
 	auth.POST("/batch-delete/nat", commonHandler(batchDeleteNAT))
+	auth.GET("/nat/:id/transfer", commonHandler(listNATTransfer))
+	auth.POST("/nat/:id/ticket", commonHandler(createNATLoginTicket))
+
+	auth.GET("/status-page", listHandler(listStatusPage))
+	auth.POST("/status-page", commonHandler(createStatusPage))
+	auth.PATCH("/status-page/:id", commonHandler(updateStatusPage))
+	auth.POST("/batch-delete/status-page", commonHandler(batchDeleteStatusPage))
+	auth.GET("/status-page/:id/incident", commonHandler(listStatusPageIncident))
+	auth.POST("/status-page-incident", commonHandler(createStatusPageIncident))
+	auth.PATCH("/status-page-incident/:id", commonHandler(updateStatusPageIncident))
+	auth.POST("/batch-delete/status-page-incident", commonHandler(batchDeleteStatusPageIncident))
 
 	auth.GET("/waf", pCommonHandler(listBlockedAddress))
 	auth.POST("/batch-delete/waf", adminHandler(batchDeleteBlockedAddress))
@@ -166,6 +241,8 @@ func routers(r *gin.Engine, frontendDist fs.FS) {
 	auth.POST("/online-user/batch-block", adminHandler(batchBlockOnlineUser))
 
 	auth.PATCH("/setting", adminHandler(updateConfig))
+	auth.GET("/setting/retention", adminHandler(getRetention))
+	auth.PATCH("/setting/retention", adminHandler(updateRetention))
 
 	auth.PATCH("/tool/:id", commonHandler(updateTool))
 	auth.POST("/tool", commonHandler(createTool))
@@ -342,6 +419,11 @@ func getUid(c *gin.Context) uint64 {
 	return user.ID
 }
 
+func isAdmin(c *gin.Context) bool {
+	user, ok := c.Get(model.CtxKeyAuthorizedUser)
+	return ok && user.(*model.User).Role == model.RoleAdmin
+}
+
 func fallbackToFrontend(frontendDist fs.FS) func(*gin.Context) {
 	checkLocalFileOrFs := func(c *gin.Context, fs fs.FS, path string, customStatusCode int) bool {
 		if _, err := os.Stat(path); err == nil {
@@ -373,10 +455,14 @@ func fallbackToFrontend(frontendDist fs.FS) func(*gin.Context) {
 		regexp.MustCompile(`^/dashboard/login$`),
 		regexp.MustCompile(`^/dashboard/service$`),
 		regexp.MustCompile(`^/dashboard/cron$`),
+		regexp.MustCompile(`^/dashboard/workflow$`),
+		regexp.MustCompile(`^/dashboard/command-job$`),
+		regexp.MustCompile(`^/dashboard/secret$`),
 		regexp.MustCompile(`^/dashboard/notification$`),
 		regexp.MustCompile(`^/dashboard/alert-rule$`),
 		regexp.MustCompile(`^/dashboard/ddns$`),
 		regexp.MustCompile(`^/dashboard/nat$`),
+		regexp.MustCompile(`^/dashboard/status-page$`),
 		regexp.MustCompile(`^/dashboard/server-group$`),
 		regexp.MustCompile(`^/dashboard/notification-group$`),
 		regexp.MustCompile(`^/dashboard/profile$`),
@@ -384,6 +470,7 @@ func fallbackToFrontend(frontendDist fs.FS) func(*gin.Context) {
 		regexp.MustCompile(`^/dashboard/settings/user$`),
 		regexp.MustCompile(`^/dashboard/settings/online-user$`),
 		regexp.MustCompile(`^/dashboard/settings/waf$`),
+		regexp.MustCompile(`^/dashboard/terminal-recording$`),
 	}
 
 	getFallbackStatusCode := func(path string) int {
@@ -1,14 +1,27 @@
 package controller
 
 import (
+	"errors"
+	"io"
+	"regexp"
 	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 
 	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/schedule"
 	"github.com/telexy324/billabong/service/singleton"
+	"gorm.io/gorm"
+)
+
+var cronParameterNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+const (
+	cronCalendarMaxWindow = 31 * 24 * time.Hour
+	cronCalendarMaxItems  = 5000
 )
 
 // List schedule tasks
@@ -53,6 +66,12 @@ func createCron(c *gin.Context) (uint64, error) {
 	if !singleton.ServerShared.CheckPermission(c, slices.Values(cf.Servers)) {
 		return 0, singleton.Localizer.ErrorT("permission denied")
 	}
+	if err := checkServerGroups(c, cf.ServerGroups); err != nil {
+		return 0, err
+	}
+	if err := validateSelector(cf.ServerSelector); err != nil {
+		return 0, err
+	}
 
 	cr.UserID = getUid(c)
 	cr.TaskType = cf.TaskType
@@ -60,18 +79,38 @@ func createCron(c *gin.Context) (uint64, error) {
 	cr.Scheduler = cf.Scheduler
 	cr.Command = cf.Command
 	cr.Servers = cf.Servers
+	cr.ServerGroups = cf.ServerGroups
+	cr.ServerSelector = cf.ServerSelector
 	cr.PushSuccessful = cf.PushSuccessful
 	cr.NotificationGroupID = cf.NotificationGroupID
 	cr.Cover = cf.Cover
+	cr.Timeout = cf.Timeout
+	cr.ConcurrencyPolicy = cf.ConcurrencyPolicy
+	cr.Jitter = cf.Jitter
+	cr.Parameters = cf.Parameters
 
 	if cr.TaskType == model.CronTypeCronTask && cr.Cover == model.CronCoverAlertTrigger {
 		return 0, singleton.Localizer.ErrorT("scheduled tasks cannot be triggered by alarms")
 	}
 
+	if err := validateCronCommand(&cr); err != nil {
+		return 0, err
+	}
+
+	if cr.ConcurrencyPolicy > model.CronConcurrencyQueue {
+		return 0, singleton.Localizer.ErrorT("invalid concurrency policy: %d", cr.ConcurrencyPolicy)
+	}
+
+	if cr.TaskType == model.CronTypeCronTask {
+		if err := validateCronSchedule(cr.Scheduler); err != nil {
+			return 0, err
+		}
+	}
+
 	// 对于计划任务类型，需要更新CronJob
 	var err error
 	if cf.TaskType == model.CronTypeCronTask {
-		if cr.CronJobID, err = singleton.CronShared.AddFunc(cr.Scheduler, singleton.CronTrigger(&cr)); err != nil {
+		if cr.CronJobID, err = singleton.CronShared.AddFunc(cr.Scheduler, singleton.CronTrigger(&cr, model.CronTriggerSchedule)); err != nil {
 			return 0, err
 		}
 	}
@@ -111,6 +150,12 @@ func updateCron(c *gin.Context) (any, error) {
 	if !singleton.ServerShared.CheckPermission(c, slices.Values(cf.Servers)) {
 		return 0, singleton.Localizer.ErrorT("permission denied")
 	}
+	if err := checkServerGroups(c, cf.ServerGroups); err != nil {
+		return 0, err
+	}
+	if err := validateSelector(cf.ServerSelector); err != nil {
+		return 0, err
+	}
 
 	var cr model.Cron
 	if err := singleton.DB.First(&cr, id).Error; err != nil {
@@ -126,17 +171,37 @@ func updateCron(c *gin.Context) (any, error) {
 	cr.Scheduler = cf.Scheduler
 	cr.Command = cf.Command
 	cr.Servers = cf.Servers
+	cr.ServerGroups = cf.ServerGroups
+	cr.ServerSelector = cf.ServerSelector
 	cr.PushSuccessful = cf.PushSuccessful
 	cr.NotificationGroupID = cf.NotificationGroupID
 	cr.Cover = cf.Cover
+	cr.Timeout = cf.Timeout
+	cr.ConcurrencyPolicy = cf.ConcurrencyPolicy
+	cr.Jitter = cf.Jitter
+	cr.Parameters = cf.Parameters
 
 	if cr.TaskType == model.CronTypeCronTask && cr.Cover == model.CronCoverAlertTrigger {
 		return nil, singleton.Localizer.ErrorT("scheduled tasks cannot be triggered by alarms")
 	}
 
+	if err := validateCronCommand(&cr); err != nil {
+		return nil, err
+	}
+
+	if cr.ConcurrencyPolicy > model.CronConcurrencyQueue {
+		return nil, singleton.Localizer.ErrorT("invalid concurrency policy: %d", cr.ConcurrencyPolicy)
+	}
+
+	if cr.TaskType == model.CronTypeCronTask {
+		if err := validateCronSchedule(cr.Scheduler); err != nil {
+			return nil, err
+		}
+	}
+
 	// 对于计划任务类型，需要更新CronJob
 	if cf.TaskType == model.CronTypeCronTask {
-		if cr.CronJobID, err = singleton.CronShared.AddFunc(cr.Scheduler, singleton.CronTrigger(&cr)); err != nil {
+		if cr.CronJobID, err = singleton.CronShared.AddFunc(cr.Scheduler, singleton.CronTrigger(&cr, model.CronTriggerSchedule)); err != nil {
 			return nil, err
 		}
 	}
@@ -153,13 +218,15 @@ func updateCron(c *gin.Context) (any, error) {
 // @Summary Trigger schedule task
 // @Security BearerAuth
 // @Schemes
-// @Description Trigger schedule task
+// @Description Trigger schedule task, parameters in the optional request body override their defaults
 // @Tags auth required
 // @Accept json
 // @param id path uint true "Task ID"
+// @param request body model.CronTriggerForm false "CronTriggerForm"
 // @Produce json
 // @Success 200 {object} model.CommonResponse[any]
 // @Router /cron/{id}/manual [get]
+// @Router /cron/{id}/manual [post]
 func manualTriggerCron(c *gin.Context) (any, error) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
@@ -167,6 +234,11 @@ func manualTriggerCron(c *gin.Context) (any, error) {
 		return nil, err
 	}
 
+	var tf model.CronTriggerForm
+	if err := c.ShouldBindJSON(&tf); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
 	cr, ok := singleton.CronShared.Get(id)
 	if !ok {
 		return nil, singleton.Localizer.ErrorT("task id %d does not exist", id)
@@ -176,7 +248,11 @@ func manualTriggerCron(c *gin.Context) (any, error) {
 		return nil, singleton.Localizer.ErrorT("permission denied")
 	}
 
-	singleton.ManualTrigger(cr)
+	if err := validateCronParameters(cr, tf.Parameters); err != nil {
+		return nil, err
+	}
+
+	singleton.ManualTrigger(cr, tf.Parameters)
 	return nil, nil
 }
 
@@ -208,3 +284,231 @@ func batchDeleteCron(c *gin.Context) (any, error) {
 	singleton.CronShared.Delete(cr)
 	return nil, nil
 }
+
+// List schedule task executions
+// @Summary List schedule task executions
+// @Security BearerAuth
+// @Schemes
+// @Description List execution history of a schedule task
+// @Tags auth required
+// @param id path uint true "Task ID"
+// @Param server_id query uint false "Server ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.CronExecution, model.CronExecution]
+// @Router /cron/{id}/execution [get]
+func listCronExecution(c *gin.Context) (*model.Value[[]*model.CronExecution], error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	cr, ok := singleton.CronShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("task id %d does not exist", id)
+	}
+
+	if !cr.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	tx := singleton.DB.Where("cron_id = ?", id)
+	if serverID, err := strconv.ParseUint(c.Query("server_id"), 10, 64); err == nil {
+		tx = tx.Where("server_id = ?", serverID)
+	}
+	return paginateCronExecution(c, tx)
+}
+
+// List schedule task executions of server
+// @Summary List schedule task executions of server
+// @Security BearerAuth
+// @Schemes
+// @Description List schedule task execution history of a server
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.CronExecution, model.CronExecution]
+// @Router /server/{id}/cron-execution [get]
+func listServerCronExecution(c *gin.Context) (*model.Value[[]*model.CronExecution], error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	server, ok := singleton.ServerShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("server not found")
+	}
+
+	if !server.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	return paginateCronExecution(c, singleton.DB.Where("server_id = ?", id))
+}
+
+func paginateCronExecution(c *gin.Context, tx *gorm.DB) (*model.Value[[]*model.CronExecution], error) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx = tx.Session(&gorm.Session{})
+
+	var total int64
+	if err := tx.Model(&model.CronExecution{}).Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var executions []*model.CronExecution
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&executions).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.CronExecution]{
+		Value: executions,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// Preview schedule expression
+// @Summary Preview schedule expression
+// @Security BearerAuth
+// @Schemes
+// @Description Validate a schedule expression and return its description and next fire times
+// @Tags auth required
+// @Param scheduler query string true "Schedule expression"
+// @Param count query uint false "Number of fire times, default 10, at most 100"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.CronSchedulePreview]
+// @Router /cron-schedule [get]
+func previewCronSchedule(c *gin.Context) (*model.CronSchedulePreview, error) {
+	spec := c.Query("scheduler")
+	if err := validateCronSchedule(spec); err != nil {
+		return nil, err
+	}
+	sched, _ := singleton.ParseCronSchedule(spec)
+
+	count, err := strconv.Atoi(c.Query("count"))
+	if err != nil || count < 1 {
+		count = 10
+	}
+	count = min(count, 100)
+
+	return &model.CronSchedulePreview{
+		Description: schedule.Describe(spec),
+		Next:        singleton.CronNextTimes(sched, time.Now(), time.Time{}, count),
+	}, nil
+}
+
+// Schedule calendar
+// @Summary Schedule calendar
+// @Security BearerAuth
+// @Schemes
+// @Description List fire times of all scheduled tasks and workflows in a time window of at most 31 days
+// @Tags auth required
+// @Param from query int false "Window start, unix timestamp in seconds, default now"
+// @Param to query int false "Window end, unix timestamp in seconds, default 24 hours after start"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.CronCalendarResponse]
+// @Router /cron-calendar [get]
+func getCronCalendar(c *gin.Context) (*model.CronCalendarResponse, error) {
+	from := time.Now()
+	if ts, err := strconv.ParseInt(c.Query("from"), 10, 64); err == nil {
+		from = time.Unix(ts, 0)
+	}
+	to := from.Add(24 * time.Hour)
+	if ts, err := strconv.ParseInt(c.Query("to"), 10, 64); err == nil {
+		to = time.Unix(ts, 0)
+	}
+	if !to.After(from) {
+		return nil, singleton.Localizer.ErrorT("the end time must be later than the start time")
+	}
+	if to.Sub(from) > cronCalendarMaxWindow {
+		return nil, singleton.Localizer.ErrorT("the time window cannot exceed %d days", int(cronCalendarMaxWindow.Hours()/24))
+	}
+
+	resp := &model.CronCalendarResponse{Items: make([]model.CronCalendarItem, 0)}
+	add := func(spec string, item model.CronCalendarItem) {
+		sched, err := singleton.ParseCronSchedule(spec)
+		if err != nil {
+			return
+		}
+		// 多取一次用于判断是否超出数量限制
+		times := singleton.CronNextTimes(sched, from, to, cronCalendarMaxItems+1)
+		for _, t := range times {
+			item.Time = t
+			resp.Items = append(resp.Items, item)
+		}
+	}
+
+	for _, cr := range singleton.CronShared.GetSortedList() {
+		if cr.TaskType != model.CronTypeCronTask || !cr.HasPermission(c) {
+			continue
+		}
+		add(cr.Scheduler, model.CronCalendarItem{CronID: cr.ID, Name: cr.Name})
+	}
+	for _, wf := range singleton.WorkflowShared.GetSortedList() {
+		if wf.Scheduler == "" || !wf.HasPermission(c) {
+			continue
+		}
+		add(wf.Scheduler, model.CronCalendarItem{WorkflowID: wf.ID, Name: wf.Name})
+	}
+
+	slices.SortStableFunc(resp.Items, func(a, b model.CronCalendarItem) int {
+		return a.Time.Compare(b.Time)
+	})
+	if len(resp.Items) > cronCalendarMaxItems {
+		resp.Items = resp.Items[:cronCalendarMaxItems]
+		resp.Truncated = true
+	}
+	return resp, nil
+}
+
+func validateCronSchedule(spec string) error {
+	if spec == "" {
+		return singleton.Localizer.ErrorT("schedule expression cannot be empty")
+	}
+	if _, err := singleton.ParseCronSchedule(spec); err != nil {
+		return singleton.Localizer.ErrorT("invalid schedule expression %s: %v", spec, err)
+	}
+	return nil
+}
+
+// validateCronCommand 检查参数定义，命令中引用的密钥在下发时检查归属
+func validateCronCommand(cr *model.Cron) error {
+	names := make(map[string]bool, len(cr.Parameters))
+	for _, p := range cr.Parameters {
+		if !cronParameterNameRe.MatchString(p.Name) {
+			return singleton.Localizer.ErrorT("invalid parameter name: %s", p.Name)
+		}
+		if names[p.Name] {
+			return singleton.Localizer.ErrorT("duplicate parameter: %s", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// validateCronParameters 检查要覆盖的参数均已在任务中定义
+func validateCronParameters(cr *model.Cron, params map[string]string) error {
+	for name := range params {
+		if _, ok := cr.Parameter(name, nil); !ok {
+			return singleton.Localizer.ErrorT("parameter %s is not defined", name)
+		}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"golang.org/x/net/idna"
 
 	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/ddns"
 	"github.com/telexy324/billabong/service/singleton"
 )
 
@@ -63,6 +64,10 @@ func createDDNS(c *gin.Context) (uint64, error) {
 	p.EnableIPv4 = &enableIPv4
 	p.EnableIPv6 = &enableIPv6
 	p.MaxRetries = df.MaxRetries
+	p.RepushOnDrift = df.RepushOnDrift
+	p.TTL = df.TTL
+	p.ServerGroupID = df.ServerGroupID
+	p.ServerGroups = df.ServerGroups
 	p.Provider = df.Provider
 	p.Domains = df.Domains
 	p.AccessID = df.AccessID
@@ -72,6 +77,11 @@ func createDDNS(c *gin.Context) (uint64, error) {
 	p.WebhookRequestType = df.WebhookRequestType
 	p.WebhookRequestBody = df.WebhookRequestBody
 	p.WebhookHeaders = df.WebhookHeaders
+	p.Config = df.Config
+
+	if err := validateDDNSProvider(c, &p); err != nil {
+		return 0, err
+	}
 
 	for n, domain := range p.Domains {
 		// IDN to ASCII
@@ -82,11 +92,18 @@ func createDDNS(c *gin.Context) (uint64, error) {
 		p.Domains[n] = domainValid
 	}
 
+	if err := p.EncryptConfig(singleton.Conf.SecretEncryptionKey); err != nil {
+		return 0, err
+	}
+
 	if err := singleton.DB.Create(&p).Error; err != nil {
 		return 0, newGormError("%v", err)
 	}
 
 	singleton.DDNSShared.Update(&p)
+	if p.ServerGroupID != 0 {
+		go singleton.DDNSShared.RefreshGroupRecords()
+	}
 	return p.ID, nil
 }
 
@@ -134,6 +151,10 @@ func updateDDNS(c *gin.Context) (any, error) {
 	p.EnableIPv4 = &enableIPv4
 	p.EnableIPv6 = &enableIPv6
 	p.MaxRetries = df.MaxRetries
+	p.RepushOnDrift = df.RepushOnDrift
+	p.TTL = df.TTL
+	p.ServerGroupID = df.ServerGroupID
+	p.ServerGroups = df.ServerGroups
 	p.Provider = df.Provider
 	p.Domains = df.Domains
 	p.AccessID = df.AccessID
@@ -143,6 +164,11 @@ func updateDDNS(c *gin.Context) (any, error) {
 	p.WebhookRequestType = df.WebhookRequestType
 	p.WebhookRequestBody = df.WebhookRequestBody
 	p.WebhookHeaders = df.WebhookHeaders
+	p.Config = df.Config
+
+	if err := validateDDNSProvider(c, &p); err != nil {
+		return nil, err
+	}
 
 	for n, domain := range p.Domains {
 		// IDN to ASCII
@@ -153,11 +179,18 @@ func updateDDNS(c *gin.Context) (any, error) {
 		p.Domains[n] = domainValid
 	}
 
+	if err := p.EncryptConfig(singleton.Conf.SecretEncryptionKey); err != nil {
+		return nil, err
+	}
+
 	if err = singleton.DB.Save(&p).Error; err != nil {
 		return nil, newGormError("%v", err)
 	}
 
 	singleton.DDNSShared.Update(&p)
+	if p.ServerGroupID != 0 {
+		go singleton.DDNSShared.RefreshGroupRecords()
+	}
 
 	return nil, nil
 }
@@ -199,8 +232,41 @@ func batchDeleteDDNS(c *gin.Context) (any, error) {
 // @Security BearerAuth
 // @Tags auth required
 // @Produce json
-// @Success 200 {object} model.CommonResponse[[]string]
+// @Success 200 {object} model.CommonResponse[[]ddns.ProviderInfo]
 // @Router /ddns/providers [get]
-func listProviders(c *gin.Context) ([]string, error) {
-	return model.ProviderList, nil
+func listProviders(c *gin.Context) ([]ddns.ProviderInfo, error) {
+	return ddns.Providers(), nil
+}
+
+func validateDDNSProvider(c *gin.Context, p *model.DDNSProfile) error {
+	if !ddns.Registered(p.Provider) {
+		return singleton.Localizer.ErrorT("provider %s does not exist", p.Provider)
+	}
+	if f := ddns.MissingField(p); f != "" {
+		return singleton.Localizer.ErrorT("%s is required", f)
+	}
+	if p.TTL > 86400 {
+		return singleton.Localizer.ErrorT("the TTL must be at most 86400 seconds")
+	}
+
+	if err := checkServerGroups(c, p.ServerGroups); err != nil {
+		return err
+	}
+
+	if p.ServerGroupID != 0 {
+		if len(p.ServerGroups) > 0 {
+			return singleton.Localizer.ErrorT("round-robin profiles cannot be assigned to server groups")
+		}
+		if !ddns.SupportsMultiValue(p.Provider) {
+			return singleton.Localizer.ErrorT("provider %s does not support round-robin records", p.Provider)
+		}
+		var sg model.ServerGroup
+		if err := singleton.DB.First(&sg, p.ServerGroupID).Error; err != nil {
+			return singleton.Localizer.ErrorT("server group does not exist")
+		}
+		if !sg.HasPermission(c) {
+			return singleton.Localizer.ErrorT("permission denied")
+		}
+	}
+	return nil
 }
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// List update history of DDNS profile
+// @Summary List update history of DDNS profile
+// @Security BearerAuth
+// @Schemes
+// @Description List DDNS record update attempts of a profile
+// @Tags auth required
+// @param id path uint true "Profile ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.DDNSUpdateLog, model.DDNSUpdateLog]
+// @Router /ddns/{id}/history [get]
+func listDDNSHistory(c *gin.Context) (*model.Value[[]*model.DDNSUpdateLog], error) {
+	profile, err := getDDNSProfileWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateDDNSHistory(c, singleton.DB.Where("profile_id = ?", profile.ID))
+}
+
+// List DDNS update history of server
+// @Summary List DDNS update history of server
+// @Security BearerAuth
+// @Schemes
+// @Description List DDNS record update attempts of a server
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.DDNSUpdateLog, model.DDNSUpdateLog]
+// @Router /server/{id}/ddns-history [get]
+func listServerDDNSHistory(c *gin.Context) (*model.Value[[]*model.DDNSUpdateLog], error) {
+	server, err := getServerWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateDDNSHistory(c, singleton.DB.Where("server_id = ?", server.ID))
+}
+
+// List drift check results of DDNS profile
+// @Summary List drift check results of DDNS profile
+// @Security BearerAuth
+// @Schemes
+// @Description List the latest check of published records against server IP
+// @Tags auth required
+// @param id path uint true "Profile ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.DDNSDriftCheck]
+// @Router /ddns/{id}/drift [get]
+func listDDNSDrift(c *gin.Context) ([]*model.DDNSDriftCheck, error) {
+	profile, err := getDDNSProfileWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return singleton.DDNSShared.DriftChecks(func(r *model.DDNSDriftCheck) bool {
+		return r.ProfileID == profile.ID
+	}), nil
+}
+
+// List DDNS drift check results of server
+// @Summary List DDNS drift check results of server
+// @Security BearerAuth
+// @Schemes
+// @Description List the latest check of published records against server IP
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.DDNSDriftCheck]
+// @Router /server/{id}/ddns-drift [get]
+func listServerDDNSDrift(c *gin.Context) ([]*model.DDNSDriftCheck, error) {
+	server, err := getServerWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return singleton.DDNSShared.DriftChecks(func(r *model.DDNSDriftCheck) bool {
+		return r.ServerID == server.ID
+	}), nil
+}
+
+func getDDNSProfileWithPermission(c *gin.Context) (*model.DDNSProfile, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, ok := singleton.DDNSShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("profile id %d does not exist", id)
+	}
+
+	if !profile.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	return profile, nil
+}
+
+func paginateDDNSHistory(c *gin.Context, tx *gorm.DB) (*model.Value[[]*model.DDNSUpdateLog], error) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx = tx.Session(&gorm.Session{})
+
+	var total int64
+	if err := tx.Model(&model.DDNSUpdateLog{}).Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var logs []*model.DDNSUpdateLog
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.DDNSUpdateLog]{
+		Value: logs,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
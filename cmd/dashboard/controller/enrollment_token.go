@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/labels"
+	"github.com/telexy324/billabong/pkg/utils"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+const enrollmentTokenLength = 32
+
+// List enrollment tokens
+// @Summary List enrollment tokens
+// @Security BearerAuth
+// @Schemes
+// @Description List agent enrollment tokens, the tokens themselves are never returned
+// @Tags admin required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.EnrollmentToken]
+// @Router /enrollment-token [get]
+func listEnrollmentToken(c *gin.Context) ([]*model.EnrollmentToken, error) {
+	return singleton.EnrollmentTokenShared.GetSortedList(), nil
+}
+
+// Create enrollment token
+// @Summary Create enrollment token
+// @Security BearerAuth
+// @Schemes
+// @Description Create an agent enrollment token, agents use it as client_secret. The token is only returned once
+// @Tags admin required
+// @Accept json
+// @param request body model.EnrollmentTokenForm true "EnrollmentTokenForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.EnrollmentTokenResponse]
+// @Router /enrollment-token [post]
+func createEnrollmentToken(c *gin.Context) (*model.EnrollmentTokenResponse, error) {
+	var tf model.EnrollmentTokenForm
+	if err := c.ShouldBindJSON(&tf); err != nil {
+		return nil, err
+	}
+
+	var t model.EnrollmentToken
+	if err := applyEnrollmentTokenForm(c, &t, &tf); err != nil {
+		return nil, err
+	}
+
+	random, err := utils.GenerateRandomString(enrollmentTokenLength)
+	if err != nil {
+		return nil, err
+	}
+	token := model.EnrollmentTokenPrefix + random
+
+	t.UserID = getUid(c)
+	t.TokenHash = model.HashEnrollmentToken(token)
+	t.TokenPrefix = token[:len(model.EnrollmentTokenPrefix)+6]
+
+	if err := singleton.DB.Create(&t).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.EnrollmentTokenShared.Update(&t)
+	return &model.EnrollmentTokenResponse{ID: t.ID, Token: token}, nil
+}
+
+// Update enrollment token
+// @Summary Update enrollment token
+// @Security BearerAuth
+// @Schemes
+// @Description Update the limits and presets of an enrollment token, the token itself does not change
+// @Tags admin required
+// @Accept json
+// @param id path uint true "Token ID"
+// @param request body model.EnrollmentTokenForm true "EnrollmentTokenForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /enrollment-token/{id} [patch]
+func updateEnrollmentToken(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var tf model.EnrollmentTokenForm
+	if err := c.ShouldBindJSON(&tf); err != nil {
+		return nil, err
+	}
+
+	var t model.EnrollmentToken
+	if err := singleton.DB.First(&t, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("enrollment token id %d does not exist", id)
+	}
+
+	if err := applyEnrollmentTokenForm(c, &t, &tf); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Save(&t).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.EnrollmentTokenShared.Update(&t)
+	return nil, nil
+}
+
+// Revoke enrollment token
+// @Summary Revoke enrollment token
+// @Security BearerAuth
+// @Schemes
+// @Description Revoke an enrollment token so it can no longer enroll new servers. Servers already enrolled with it keep authenticating with the token, delete the token to disconnect them
+// @Tags admin required
+// @param id path uint true "Token ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /enrollment-token/{id}/revoke [post]
+func revokeEnrollmentToken(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var t model.EnrollmentToken
+	if err := singleton.DB.First(&t, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("enrollment token id %d does not exist", id)
+	}
+	if t.RevokedAt != nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	t.RevokedAt = &now
+	if err := singleton.DB.Save(&t).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.EnrollmentTokenShared.Update(&t)
+	return nil, nil
+}
+
+// Batch delete enrollment tokens
+// @Summary Batch delete enrollment tokens
+// @Security BearerAuth
+// @Schemes
+// @Description Delete enrollment tokens, servers enrolled with them can no longer connect
+// @Tags admin required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/enrollment-token [post]
+func batchDeleteEnrollmentToken(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.EnrollmentToken{}, "id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.EnrollmentTokenShared.Delete(ids)
+	return nil, nil
+}
+
+// List agent enrollments
+// @Summary List agent enrollments
+// @Security BearerAuth
+// @Schemes
+// @Description List which enrollment token enrolled which server UUID
+// @Tags admin required
+// @Param token_id query uint false "Token ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.AgentEnrollment, model.AgentEnrollment]
+// @Router /agent-enrollment [get]
+func listAgentEnrollment(c *gin.Context) (*model.Value[[]*model.AgentEnrollment], error) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx := singleton.DB.Model(&model.AgentEnrollment{})
+	if tokenID, err := strconv.ParseUint(c.Query("token_id"), 10, 64); err == nil {
+		tx = tx.Where("token_id = ?", tokenID)
+	}
+	tx = tx.Session(&gorm.Session{})
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var enrollments []*model.AgentEnrollment
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&enrollments).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.AgentEnrollment]{
+		Value: enrollments,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+func applyEnrollmentTokenForm(c *gin.Context, t *model.EnrollmentToken, tf *model.EnrollmentTokenForm) error {
+	if tf.ExpiresAt != nil && tf.ExpiresAt.Before(time.Now()) {
+		return singleton.Localizer.ErrorT("expiry time must be in the future")
+	}
+	if err := checkServerGroups(c, tf.ServerGroups); err != nil {
+		return err
+	}
+	if err := labels.Validate(tf.Labels); err != nil {
+		return singleton.Localizer.ErrorT("invalid labels: %v", err)
+	}
+
+	t.Name = tf.Name
+	t.MaxUses = tf.MaxUses
+	t.ExpiresAt = tf.ExpiresAt
+	t.NameTemplate = tf.NameTemplate
+	t.ServerGroups = tf.ServerGroups
+	t.Labels = tf.Labels
+	return nil
+}
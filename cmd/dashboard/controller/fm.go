@@ -48,6 +48,15 @@ func createFM(c *gin.Context) (*model.CreateFMResponse, error) {
 
 	rpc.NezhaHandlerSingleton.CreateStream(streamId)
 
+	user := c.MustGet(model.CtxKeyAuthorizedUser).(*model.User)
+	singleton.Cache.Set(model.CacheKeyFM+streamId, &terminalSession{
+		UserID:     user.ID,
+		Username:   user.Username,
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		ClientIP:   c.GetString(model.CtxKeyRealIPStr),
+	}, time.Minute)
+
 	fmData, _ := json.Marshal(&model.TaskFM{
 		StreamID: streamId,
 	})
@@ -77,6 +86,22 @@ func fmStream(c *gin.Context) (any, error) {
 	}
 	defer rpc.NezhaHandlerSingleton.CloseStream(streamId)
 
+	// 会话信息用于审计与权限检查，过期后不再允许连接
+	s, ok := singleton.Cache.Get(model.CacheKeyFM + streamId)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("session expired")
+	}
+	singleton.Cache.Delete(model.CacheKeyFM + streamId)
+	session := s.(*terminalSession)
+	if session.UserID != getUid(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	server, _ := singleton.ServerShared.Get(session.ServerID)
+	if server == nil {
+		return nil, singleton.Localizer.ErrorT("server not found or not connected")
+	}
+
 	wsConn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		return nil, newWsError("%v", err)
@@ -94,7 +119,8 @@ func fmStream(c *gin.Context) (any, error) {
 		}
 	}()
 
-	if err = rpc.NezhaHandlerSingleton.UserConnected(streamId, conn); err != nil {
+	auditor := newFMAuditor(conn, session, server, c.MustGet(model.CtxKeyAuthorizedUser).(*model.User))
+	if err = rpc.NezhaHandlerSingleton.UserConnected(streamId, auditor); err != nil {
 		return nil, newWsError("%v", err)
 	}
 
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// agent 文件管理协议中的响应标识
+var (
+	fmFileIdentifier  = []byte{0x4E, 0x5A, 0x54, 0x44} // NZTD 下载文件头，后跟 8 字节文件大小
+	fmErrorIdentifier = []byte{0x4E, 0x45, 0x52, 0x52} // NERR 错误信息
+)
+
+// fmAuditor 解析浏览器发送的文件管理操作，记录审计日志并执行路径与传输大小限制
+type fmAuditor struct {
+	io.ReadWriteCloser
+
+	session *terminalSession
+	server  *model.Server
+	limit   int64 // 单次传输的最大字节数，0 表示不限制
+
+	uploadRemaining int64 // 上传中的文件剩余字节
+	uploadDiscard   bool  // 上传被拒绝，丢弃剩余数据
+
+	mu              sync.Mutex
+	downloads       []*model.FMAuditLog // 等待 agent 返回文件大小的下载
+	downloadDiscard int64               // 超过大小限制的下载剩余字节
+}
+
+func newFMAuditor(conn io.ReadWriteCloser, session *terminalSession, server *model.Server, user *model.User) *fmAuditor {
+	limit := user.FMMaxTransferSize
+	if limit == 0 {
+		limit = singleton.Conf.FMMaxTransferSize
+	}
+	return &fmAuditor{
+		ReadWriteCloser: conn,
+		session:         session,
+		server:          server,
+		limit:           limit,
+	}
+}
+
+// Read 浏览器发送的操作，首字节 0 为列出目录，1 为下载，2 为上传（后跟 8 字节文件大小），其后为路径
+func (a *fmAuditor) Read(p []byte) (int, error) {
+	for {
+		n, err := a.ReadWriteCloser.Read(p)
+		if n == 0 {
+			return n, err
+		}
+
+		if a.uploadRemaining > 0 {
+			a.uploadRemaining -= int64(n)
+			if a.uploadDiscard {
+				if err != nil {
+					return 0, err
+				}
+				continue
+			}
+			return n, err
+		}
+
+		if a.check(p[:n]) || err != nil {
+			return n, err
+		}
+	}
+}
+
+// check 记录操作并判断是否转发给 agent
+func (a *fmAuditor) check(data []byte) bool {
+	entry := a.newLog()
+	switch data[0] {
+	case model.FMOperationList, model.FMOperationDownload:
+		entry.Operation = data[0]
+		entry.Path = string(data[1:])
+	case model.FMOperationUpload:
+		if len(data) < 9 {
+			return true
+		}
+		entry.Operation = model.FMOperationUpload
+		entry.Size = int64(binary.BigEndian.Uint64(data[1:9]))
+		entry.Path = string(data[9:])
+		a.uploadRemaining = entry.Size
+		a.uploadDiscard = false
+	default:
+		return true
+	}
+
+	switch {
+	case !a.server.FMPathAllowed(entry.Path):
+		entry.Denied = true
+		entry.Reason = singleton.Localizer.T("path is not allowed")
+	case entry.Operation == model.FMOperationUpload && a.limit > 0 && entry.Size > a.limit:
+		entry.Denied = true
+		entry.Reason = singleton.Localizer.Tf("file size exceeds the limit of %d bytes", a.limit)
+	}
+
+	// 先保存再等待下载结果，避免与 Write 同时修改记录
+	a.save(entry)
+	if entry.Denied {
+		a.uploadDiscard = true
+		a.deny(entry.Reason)
+	} else if entry.Operation == model.FMOperationDownload {
+		a.mu.Lock()
+		a.downloads = append(a.downloads, entry)
+		a.mu.Unlock()
+	}
+	return !entry.Denied
+}
+
+// Write agent 返回的数据，下载开始时记录文件大小，超过限制时中止传输，下载失败时记录错误信息
+func (a *fmAuditor) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	if a.downloadDiscard > 0 {
+		a.downloadDiscard -= int64(len(p))
+		a.mu.Unlock()
+		return len(p), nil
+	}
+
+	var entry *model.FMAuditLog
+	switch {
+	case len(a.downloads) == 0:
+	case len(p) >= 12 && bytes.Equal(p[:4], fmFileIdentifier):
+		entry = a.downloads[0]
+		a.downloads = a.downloads[1:]
+		entry.Size = int64(binary.BigEndian.Uint64(p[4:12]))
+		if a.limit > 0 && entry.Size > a.limit {
+			entry.Denied = true
+			entry.Reason = singleton.Localizer.Tf("file size exceeds the limit of %d bytes", a.limit)
+			a.downloadDiscard = entry.Size - int64(len(p)-12)
+		}
+	case len(p) >= 4 && bytes.Equal(p[:4], fmErrorIdentifier):
+		// 下载失败，记录 agent 返回的错误，之后的文件头属于下一个下载
+		entry = a.downloads[0]
+		a.downloads = a.downloads[1:]
+		entry.Reason = string(p[4:])
+	}
+	a.mu.Unlock()
+
+	if entry != nil {
+		a.save(entry)
+		if entry.Denied {
+			a.deny(entry.Reason)
+			return len(p), nil
+		}
+	}
+	return a.ReadWriteCloser.Write(p)
+}
+
+// deny 向浏览器返回错误信息
+func (a *fmAuditor) deny(reason string) {
+	a.ReadWriteCloser.Write(append(bytes.Clone(fmErrorIdentifier), reason...))
+}
+
+func (a *fmAuditor) newLog() *model.FMAuditLog {
+	return &model.FMAuditLog{
+		UserID:     a.session.UserID,
+		Username:   a.session.Username,
+		ServerID:   a.session.ServerID,
+		ServerName: a.session.ServerName,
+		ClientIP:   a.session.ClientIP,
+	}
+}
+
+func (a *fmAuditor) save(entry *model.FMAuditLog) {
+	if err := singleton.DB.Save(entry).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save file manager audit log: %v", err)
+	}
+}
+
+// List file manager audit logs
+// @Summary List file manager audit logs
+// @Security BearerAuth
+// @Schemes
+// @Description List file manager operations
+// @Tags admin required
+// @Param server_id query uint false "Server ID"
+// @Param user_id query uint false "User ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.FMAuditLog, model.FMAuditLog]
+// @Router /fm-audit-log [get]
+func listFMAuditLog(c *gin.Context) (*model.Value[[]*model.FMAuditLog], error) {
+	if !isAdmin(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx := singleton.DB.Model(&model.FMAuditLog{})
+	if serverID, err := strconv.ParseUint(c.Query("server_id"), 10, 64); err == nil {
+		tx = tx.Where("server_id = ?", serverID)
+	}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64); err == nil {
+		tx = tx.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var logs []*model.FMAuditLog
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.FMAuditLog]{
+		Value: logs,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
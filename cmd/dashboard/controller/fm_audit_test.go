@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+type fmTestConn struct {
+	bytes.Buffer
+}
+
+func (*fmTestConn) Close() error { return nil }
+
+func TestFMAuditorErrorThenDownload(t *testing.T) {
+	// 只生成 SQL，不连接数据库
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	singleton.DB = db
+	defer func() { singleton.DB = nil }()
+
+	conn := &fmTestConn{}
+	a := &fmAuditor{
+		ReadWriteCloser: conn,
+		session:         &terminalSession{},
+		server:          &model.Server{},
+		limit:           1024,
+	}
+
+	a.check(append([]byte{model.FMOperationDownload}, "/missing"...))
+	a.check(append([]byte{model.FMOperationDownload}, "/etc/hosts"...))
+	logs := a.downloads
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 pending downloads, got %d", len(logs))
+	}
+
+	a.Write(append(bytes.Clone(fmErrorIdentifier), "no such file"...))
+
+	header := append(bytes.Clone(fmFileIdentifier), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(header[4:], 100)
+	a.Write(header)
+
+	if logs[0].Path != "/missing" || logs[0].Reason != "no such file" || logs[0].Size != 0 {
+		t.Errorf("Unexpected failed download log: %+v", logs[0])
+	}
+	if logs[1].Path != "/etc/hosts" || logs[1].Size != 100 || logs[1].Denied {
+		t.Errorf("Unexpected download log: %+v", logs[1])
+	}
+	if len(a.downloads) != 0 {
+		t.Errorf("Expected no pending downloads, got %d", len(a.downloads))
+	}
+}
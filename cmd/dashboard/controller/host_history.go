@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// List host change history of server
+// @Summary List host change history of server
+// @Security BearerAuth
+// @Schemes
+// @Description List snapshots of reported host facts, a new one is recorded whenever they change
+// @Tags auth required
+// @param id path uint true "Server ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.HostSnapshot, model.HostSnapshot]
+// @Router /server/{id}/host-history [get]
+func listServerHostHistory(c *gin.Context) (*model.Value[[]*model.HostSnapshot], error) {
+	server, err := getServerWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx := singleton.DB.Where("server_id = ?", server.ID).Session(&gorm.Session{})
+
+	var total int64
+	if err := tx.Model(&model.HostSnapshot{}).Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var snapshots []*model.HostSnapshot
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&snapshots).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.HostSnapshot]{
+		Value: snapshots,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
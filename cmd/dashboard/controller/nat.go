@@ -3,6 +3,8 @@ package controller
 import (
 	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
@@ -31,6 +33,10 @@ func listNAT(c *gin.Context) ([]*model.NAT, error) {
 		return nil, err
 	}
 
+	for _, profile := range n {
+		profile.Stats = singleton.NATShared.Stats(profile.ID)
+	}
+
 	return n, nil
 }
 
@@ -68,6 +74,13 @@ func createNAT(c *gin.Context) (uint64, error) {
 	n.Host = nf.Host
 	n.ServerID = nf.ServerID
 
+	if err := applyNATAccess(&n, &nf); err != nil {
+		return 0, err
+	}
+	if err := applyNATLimits(&n, &nf); err != nil {
+		return 0, err
+	}
+
 	if err := singleton.DB.Create(&n).Error; err != nil {
 		return 0, newGormError("%v", err)
 	}
@@ -122,6 +135,13 @@ func updateNAT(c *gin.Context) (any, error) {
 	n.Host = nf.Host
 	n.ServerID = nf.ServerID
 
+	if err := applyNATAccess(&n, &nf); err != nil {
+		return nil, err
+	}
+	if err := applyNATLimits(&n, &nf); err != nil {
+		return nil, err
+	}
+
 	if err := singleton.DB.Save(&n).Error; err != nil {
 		return 0, newGormError("%v", err)
 	}
@@ -161,3 +181,130 @@ func batchDeleteNAT(c *gin.Context) (any, error) {
 	singleton.NATShared.Delete(n)
 	return nil, nil
 }
+
+// applyNATAccess 检查并设置访问策略，Basic 认证密码留空时保留原密码
+func applyNATAccess(n *model.NAT, nf *model.NATForm) error {
+	switch nf.AccessPolicy {
+	case model.NATAccessPublic, model.NATAccessLogin:
+	case model.NATAccessBasicAuth:
+		if nf.BasicAuthUsername == "" || strings.Contains(nf.BasicAuthUsername, ":") {
+			return singleton.Localizer.ErrorT("invalid basic auth username")
+		}
+		if nf.BasicAuthPassword == "" && (n.BasicAuthPassword == "" || n.AccessPolicy != model.NATAccessBasicAuth) {
+			return singleton.Localizer.ErrorT("basic auth password cannot be empty")
+		}
+	case model.NATAccessIPAllowlist:
+		if len(nf.AllowedIPs) == 0 {
+			return singleton.Localizer.ErrorT("allowed ip list cannot be empty")
+		}
+		if _, err := model.ParseIPPrefixes(nf.AllowedIPs); err != nil {
+			return singleton.Localizer.ErrorT("invalid ip or cidr: %v", err)
+		}
+	default:
+		return singleton.Localizer.ErrorT("invalid access policy")
+	}
+
+	n.AccessPolicy = nf.AccessPolicy
+	n.AllowedIPs = nf.AllowedIPs
+	if n.AccessPolicy != model.NATAccessBasicAuth {
+		n.BasicAuthUsername = ""
+		n.BasicAuthPassword = ""
+		return nil
+	}
+
+	n.BasicAuthUsername = nf.BasicAuthUsername
+	if nf.BasicAuthPassword != "" {
+		password, err := utils.Encrypt(singleton.Conf.SecretEncryptionKey, []byte(nf.BasicAuthPassword))
+		if err != nil {
+			return err
+		}
+		n.BasicAuthPassword = password
+	}
+	return nil
+}
+
+func applyNATLimits(n *model.NAT, nf *model.NATForm) error {
+	if nf.MaxStreams < 0 || nf.MaxBodySize < 0 {
+		return singleton.Localizer.ErrorT("limits cannot be negative")
+	}
+	n.MaxStreams = nf.MaxStreams
+	n.RateLimit = nf.RateLimit
+	n.MaxBodySize = nf.MaxBodySize
+	return nil
+}
+
+// List NAT traffic
+// @Summary List NAT traffic
+// @Security BearerAuth
+// @Schemes
+// @Description List hourly traffic records of a NAT profile
+// @Tags auth required
+// @Param id path uint true "Profile ID"
+// @Param hours query uint false "Hours to look back, default 24, at most 720"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.NATTransfer]
+// @Router /nat/{id}/transfer [get]
+func listNATTransfer(c *gin.Context) ([]*model.NATTransfer, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := singleton.NATShared.GetDomain(id)
+	n := singleton.NATShared.GetNATConfigByDomain(domain)
+	if n == nil {
+		return nil, singleton.Localizer.ErrorT("profile id %d does not exist", id)
+	}
+	if !n.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	hours, err := strconv.Atoi(c.Query("hours"))
+	if err != nil || hours < 1 {
+		hours = 24
+	}
+	hours = min(hours, 720)
+
+	var transfers []*model.NATTransfer
+	if err := singleton.DB.Where("nat_id = ? AND created_at >= ?", id, time.Now().Add(-time.Duration(hours)*time.Hour)).
+		Order("created_at").Find(&transfers).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return transfers, nil
+}
+
+// Create NAT login ticket
+// @Summary Create NAT login ticket
+// @Security BearerAuth
+// @Schemes
+// @Description Create a one-time ticket for a NAT profile that requires dashboard login, open the NAT domain with ?nz-nat-ticket=<ticket> within the given seconds to start a session there
+// @Tags auth required
+// @Param id path uint true "Profile ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.NATLoginTicketResponse]
+// @Router /nat/{id}/ticket [post]
+func createNATLoginTicket(c *gin.Context) (*model.NATLoginTicketResponse, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := singleton.NATShared.GetDomain(id)
+	n := singleton.NATShared.GetNATConfigByDomain(domain)
+	if n == nil {
+		return nil, singleton.Localizer.ErrorT("profile id %d does not exist", id)
+	}
+	if !n.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	ticket, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	singleton.Cache.Set(model.CacheKeyNATTicket+ticket, &natLogin{userID: getUid(c), natID: n.ID}, natTicketTimeout)
+	return &model.NATLoginTicketResponse{
+		Ticket:    ticket,
+		ExpiresIn: int(natTicketTimeout.Seconds()),
+	}, nil
+}
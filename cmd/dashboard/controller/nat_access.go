@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	gjwt "github.com/golang-jwt/jwt/v4"
+
+	"github.com/telexy324/billabong/cmd/dashboard/controller/waf"
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+const (
+	natJWTCookie        = "nz-jwt"
+	natSessionCookie    = "nz-nat-session"
+	natTicketQuery      = "nz-nat-ticket" // 面板跳转到内网穿透域名时携带的一次性票据
+	natTicketTimeout    = time.Minute
+	cacheKeyNATAuthFail = "cknaf::" // 最近 Basic 认证失败的 IP
+)
+
+// natLogin 登录票据与会话对应的用户与内网穿透配置
+type natLogin struct {
+	userID uint64
+	natID  uint64
+}
+
+var natAuthMiddleware *jwt.GinJWTMiddleware
+
+// CheckNATAccess 检查 WAF、内网穿透的访问策略与请求限制，拒绝访问时写入响应并返回 false
+func CheckNATAccess(w http.ResponseWriter, r *http.Request, n *model.NAT) bool {
+	singleton.NATShared.AddRequest(n.ID)
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = r
+
+	waf.RealIp(c)
+	if c.IsAborted() {
+		return false
+	}
+	// 未配置真实 IP 请求头时使用连接的对端地址
+	ip := c.GetString(model.CtxKeyRealIPStr)
+	if ip == "" {
+		ip = c.RemoteIP()
+	}
+	if err := model.CheckIP(singleton.DB, ip); err != nil {
+		waf.ShowBlockPage(c, err)
+		return false
+	}
+
+	if !singleton.NATShared.AllowRequest(n, ip) {
+		singleton.NATShared.AddLimited(n.ID)
+		waf.ShowBlockPageWithCode(c, http.StatusTooManyRequests, singleton.Localizer.ErrorT("too many requests"))
+		return false
+	}
+
+	switch n.AccessPolicy {
+	case model.NATAccessLogin:
+		if !checkNATLogin(c, n, ip) {
+			return false
+		}
+	case model.NATAccessBasicAuth:
+		if !checkNATBasicAuth(c, n, ip) {
+			return false
+		}
+	case model.NATAccessIPAllowlist:
+		if !n.IPAllowed(ip) {
+			waf.ShowBlockPage(c, singleton.Localizer.ErrorT("ip %s is not allowed to access %s", ip, n.Domain))
+			return false
+		}
+	}
+
+	return checkNATBodySize(c, n)
+}
+
+// checkNATBodySize 检查请求体大小，未声明长度的请求体在转发过程中限制读取的字节数，超出后中断转发
+func checkNATBodySize(c *gin.Context, n *model.NAT) bool {
+	if n.MaxBodySize <= 0 {
+		return true
+	}
+
+	r := c.Request
+	if r.ContentLength > n.MaxBodySize {
+		singleton.NATShared.AddLimited(n.ID)
+		waf.ShowBlockPageWithCode(c, http.StatusRequestEntityTooLarge, singleton.Localizer.ErrorT("request body exceeds the limit of %d bytes", n.MaxBodySize))
+		return false
+	}
+	if r.ContentLength < 0 {
+		r.Body = http.MaxBytesReader(c.Writer, r.Body, n.MaxBodySize)
+	}
+	return true
+}
+
+// checkNATLogin 使用面板的登录状态，仅管理员与配置所有者可以访问
+func checkNATLogin(c *gin.Context, n *model.NAT, ip string) bool {
+	// 使用面板签发的一次性票据换取当前域名下的会话，然后去掉链接中的票据
+	if ticket := c.Query(natTicketQuery); ticket != "" {
+		login, ok := takeNATLogin(model.CacheKeyNATTicket + ticket)
+		if !ok || login.natID != n.ID {
+			model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeBruteForceToken, model.BlockIDToken)
+		} else if startNATSession(c, login) {
+			u := *c.Request.URL
+			q := u.Query()
+			q.Del(natTicketQuery)
+			u.RawQuery = q.Encode()
+			c.Redirect(http.StatusFound, u.RequestURI())
+			return false
+		}
+	}
+
+	user := natUserFromSession(c, n)
+	if user == nil {
+		token, _ := c.Cookie(natJWTCookie)
+		user = natUserFromToken(token, ip)
+	}
+	if user == nil {
+		waf.ShowBlockPage(c, singleton.Localizer.ErrorT("please log in to the dashboard first"))
+		return false
+	}
+	if !natUserAllowed(user, n) {
+		waf.ShowBlockPage(c, singleton.Localizer.ErrorT("permission denied"))
+		return false
+	}
+
+	// 面板的登录令牌与会话不转发给内网服务
+	removeCookie(c.Request, natJWTCookie)
+	removeCookie(c.Request, natSessionCookie)
+	return true
+}
+
+// takeNATLogin 取出并作废一次性票据
+func takeNATLogin(key string) (*natLogin, bool) {
+	v, ok := singleton.Cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	singleton.Cache.Delete(key)
+	login, ok := v.(*natLogin)
+	return login, ok
+}
+
+// startNATSession 在内网穿透域名下创建会话，有效期与面板登录相同
+func startNATSession(c *gin.Context, login *natLogin) bool {
+	session, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return false
+	}
+	ttl := time.Hour * time.Duration(singleton.Conf.JWTTimeout)
+	singleton.Cache.Set(model.CacheKeyNATSession+session, login, ttl)
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     natSessionCookie,
+		Value:    session,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return true
+}
+
+func natUserFromSession(c *gin.Context, n *model.NAT) *model.User {
+	session, _ := c.Cookie(natSessionCookie)
+	if session == "" {
+		return nil
+	}
+	v, ok := singleton.Cache.Get(model.CacheKeyNATSession + session)
+	if !ok {
+		return nil
+	}
+	login, ok := v.(*natLogin)
+	if !ok || login.natID != n.ID {
+		return nil
+	}
+
+	var user model.User
+	if err := singleton.DB.First(&user, login.userID).Error; err != nil {
+		return nil
+	}
+	return &user
+}
+
+func natUserFromToken(token, ip string) *model.User {
+	if token == "" || natAuthMiddleware == nil {
+		return nil
+	}
+
+	t, err := natAuthMiddleware.ParseTokenString(token)
+	if err != nil || !t.Valid {
+		model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeBruteForceToken, model.BlockIDToken)
+		return nil
+	}
+	claims, ok := t.Claims.(gjwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	userId, ok := claims[model.CtxKeyAuthorizedUser].(string)
+	if !ok {
+		return nil
+	}
+
+	var user model.User
+	if err := singleton.DB.First(&user, userId).Error; err != nil {
+		return nil
+	}
+	return &user
+}
+
+func natUserAllowed(user *model.User, n *model.NAT) bool {
+	return user.Role == model.RoleAdmin || user.ID == n.UserID
+}
+
+// checkNATBasicAuth 检查 Basic 认证，失败时记录到 WAF
+func checkNATBasicAuth(c *gin.Context, n *model.NAT, ip string) bool {
+	username, password, ok := c.Request.BasicAuth()
+	if ok {
+		expected, err := utils.Decrypt(singleton.Conf.SecretEncryptionKey, n.BasicAuthPassword)
+		if err == nil &&
+			subtle.ConstantTimeCompare([]byte(username), []byte(n.BasicAuthUsername)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), expected) == 1 {
+			if _, failed := singleton.Cache.Get(cacheKeyNATAuthFail + ip); failed {
+				singleton.Cache.Delete(cacheKeyNATAuthFail + ip)
+				model.UnblockIP(singleton.DB, ip, model.BlockIDNAT)
+			}
+			// 认证信息不转发给内网服务
+			c.Request.Header.Del("Authorization")
+			return true
+		}
+
+		if ip != "" {
+			singleton.Cache.Set(cacheKeyNATAuthFail+ip, struct{}{}, 0)
+		}
+		model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeNATAuthFail, model.BlockIDNAT)
+	}
+
+	c.Header("WWW-Authenticate", `Basic realm="`+n.Name+`", charset="UTF-8"`)
+	c.AbortWithStatus(http.StatusUnauthorized)
+	return false
+}
+
+func removeCookie(r *http.Request, name string) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			r.AddCookie(cookie)
+		}
+	}
+}
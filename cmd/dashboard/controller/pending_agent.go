@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/labels"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// List pending agents
+// @Summary List pending agents
+// @Security BearerAuth
+// @Schemes
+// @Description List unknown agents waiting for approval, with their reported host info and connecting IP
+// @Tags admin required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.PendingAgent]
+// @Router /pending-agent [get]
+func listPendingAgent(c *gin.Context) ([]*model.PendingAgent, error) {
+	return singleton.PendingAgentShared.GetSortedList(), nil
+}
+
+// Approve pending agent
+// @Summary Approve pending agent
+// @Security BearerAuth
+// @Schemes
+// @Description Approve a pending agent, the server is created with the given name, groups and labels
+// @Tags admin required
+// @Accept json
+// @param id path uint true "Pending agent ID"
+// @param request body model.PendingAgentApproveForm true "PendingAgentApproveForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /pending-agent/{id}/approve [post]
+func approvePendingAgent(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	var af model.PendingAgentApproveForm
+	if err := c.ShouldBindJSON(&af); err != nil {
+		return 0, err
+	}
+
+	if err := checkServerGroups(c, af.ServerGroups); err != nil {
+		return 0, err
+	}
+	if err := labels.Validate(af.Labels); err != nil {
+		return 0, singleton.Localizer.ErrorT("invalid labels: %v", err)
+	}
+
+	s, err := singleton.PendingAgentShared.Approve(id, af.Name, af.ServerGroups, af.Labels)
+	if err != nil {
+		return 0, err
+	}
+	return s.ID, nil
+}
+
+// Reject pending agent
+// @Summary Reject pending agent
+// @Security BearerAuth
+// @Schemes
+// @Description Reject a pending agent, it stays rejected until the record is deleted
+// @Tags admin required
+// @param id path uint true "Pending agent ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /pending-agent/{id}/reject [post]
+func rejectPendingAgent(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return nil, singleton.PendingAgentShared.Reject(id)
+}
+
+// Batch delete pending agents
+// @Summary Batch delete pending agents
+// @Security BearerAuth
+// @Schemes
+// @Description Delete pending agent records, a deleted agent is queued again when it reconnects
+// @Tags admin required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/pending-agent [post]
+func batchDeletePendingAgent(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.PendingAgent{}, "id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.PendingAgentShared.Delete(ids)
+	return nil, nil
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// List secrets
+// @Summary List secrets
+// @Security BearerAuth
+// @Schemes
+// @Description List secrets, values are never returned
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.Secret]
+// @Router /secret [get]
+func listSecret(c *gin.Context) ([]*model.Secret, error) {
+	slist := singleton.SecretShared.GetSortedList()
+
+	var secrets []*model.Secret
+	if err := copier.Copy(&secrets, &slist); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// Create secret
+// @Summary Create secret
+// @Security BearerAuth
+// @Schemes
+// @Description Create secret, it can be referenced in schedule task commands as {{secret "name"}}
+// @Tags auth required
+// @Accept json
+// @param request body model.SecretForm true "SecretForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /secret [post]
+func createSecret(c *gin.Context) (uint64, error) {
+	var sf model.SecretForm
+	if err := c.ShouldBindJSON(&sf); err != nil {
+		return 0, err
+	}
+
+	if sf.Value == "" {
+		return 0, singleton.Localizer.ErrorT("secret value cannot be empty")
+	}
+	if err := validateSecretName(0, sf.Name); err != nil {
+		return 0, err
+	}
+
+	ciphertext, err := utils.Encrypt(singleton.Conf.SecretEncryptionKey, []byte(sf.Value))
+	if err != nil {
+		return 0, err
+	}
+
+	var s model.Secret
+	s.UserID = getUid(c)
+	s.Name = sf.Name
+	s.Description = sf.Description
+	s.Ciphertext = ciphertext
+
+	if err := singleton.DB.Create(&s).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.SecretShared.Update(&s, sf.Value)
+	return s.ID, nil
+}
+
+// Update secret
+// @Summary Update secret
+// @Security BearerAuth
+// @Schemes
+// @Description Update secret, an empty value keeps the stored one
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Secret ID"
+// @param request body model.SecretForm true "SecretForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /secret/{id} [patch]
+func updateSecret(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var sf model.SecretForm
+	if err := c.ShouldBindJSON(&sf); err != nil {
+		return nil, err
+	}
+
+	var s model.Secret
+	if err := singleton.DB.First(&s, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("secret id %d does not exist", id)
+	}
+
+	if !s.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := validateSecretName(s.ID, sf.Name); err != nil {
+		return nil, err
+	}
+
+	s.Name = sf.Name
+	s.Description = sf.Description
+	if sf.Value != "" {
+		if s.Ciphertext, err = utils.Encrypt(singleton.Conf.SecretEncryptionKey, []byte(sf.Value)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := singleton.DB.Save(&s).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.SecretShared.Update(&s, sf.Value)
+	return nil, nil
+}
+
+// Batch delete secrets
+// @Summary Batch delete secrets
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete secrets
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/secret [post]
+func batchDeleteSecret(c *gin.Context) (any, error) {
+	var secrets []uint64
+	if err := c.ShouldBindJSON(&secrets); err != nil {
+		return nil, err
+	}
+
+	if !singleton.SecretShared.CheckPermission(c, slices.Values(secrets)) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.Secret{}, "id in (?)", secrets).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.SecretShared.Delete(secrets)
+	return nil, nil
+}
+
+func validateSecretName(id uint64, name string) error {
+	if !cronParameterNameRe.MatchString(name) {
+		return singleton.Localizer.ErrorT("invalid secret name: %s", name)
+	}
+	if s, ok := singleton.SecretShared.GetByName(name); ok && s.ID != id {
+		return singleton.Localizer.ErrorT("secret %s already exists", name)
+	}
+	return nil
+}
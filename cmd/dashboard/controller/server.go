@@ -12,6 +12,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/labels"
 	pb "github.com/telexy324/billabong/proto"
 	"github.com/telexy324/billabong/service/singleton"
 )
@@ -23,12 +24,23 @@ import (
 // @Description List server
 // @Tags auth required
 // @Param id query uint false "Resource ID"
+// @Param selector query string false "Label selector, e.g. env=prod,role in (db,cache)"
 // @Produce json
 // @Success 200 {object} model.CommonResponse[[]model.Server]
 // @Router /server [get]
 func listServer(c *gin.Context) ([]*model.Server, error) {
 	slist := singleton.ServerShared.GetSortedList()
 
+	if q := c.Query("selector"); q != "" {
+		selector, err := labels.Parse(q)
+		if err != nil {
+			return nil, singleton.Localizer.ErrorT("invalid label selector: %v", err)
+		}
+		slist = slices.DeleteFunc(slices.Clone(slist), func(s *model.Server) bool {
+			return !selector.Matches(s.EffectiveLabels())
+		})
+	}
+
 	var ssl []*model.Server
 	if err := copier.Copy(&ssl, &slist); err != nil {
 		return nil, err
@@ -80,6 +92,7 @@ func updateServer(c *gin.Context) (any, error) {
 	s.EnableDDNS = sf.EnableDDNS
 	s.DDNSProfiles = sf.DDNSProfiles
 	s.OverrideDDNSDomains = sf.OverrideDDNSDomains
+	s.Labels = sf.Labels
 
 	ddnsProfilesRaw, err := json.Marshal(s.DDNSProfiles)
 	if err != nil {
@@ -93,6 +106,41 @@ func updateServer(c *gin.Context) (any, error) {
 	}
 	s.OverrideDDNSDomainsRaw = string(overrideDomainsRaw)
 
+	if err := labels.Validate(s.Labels); err != nil {
+		return nil, singleton.Localizer.ErrorT("invalid labels: %v", err)
+	}
+	labelsRaw, err := json.Marshal(s.Labels)
+	if err != nil {
+		return nil, err
+	}
+	s.LabelsRaw = string(labelsRaw)
+
+	// 文件管理路径限制仅管理员可修改
+	if isAdmin(c) {
+		if slices.Contains(sf.FMAllowPaths, "") || slices.Contains(sf.FMDenyPaths, "") {
+			return nil, singleton.Localizer.ErrorT("path cannot be empty")
+		}
+		for _, p := range slices.Concat(sf.FMAllowPaths, sf.FMDenyPaths) {
+			if !model.IsFMAbsPath(p) {
+				return nil, singleton.Localizer.ErrorT("path %s must be absolute", p)
+			}
+		}
+		s.FMAllowPaths = sf.FMAllowPaths
+		s.FMDenyPaths = sf.FMDenyPaths
+
+		fmAllowPathsRaw, err := json.Marshal(s.FMAllowPaths)
+		if err != nil {
+			return nil, err
+		}
+		s.FMAllowPathsRaw = string(fmAllowPathsRaw)
+
+		fmDenyPathsRaw, err := json.Marshal(s.FMDenyPaths)
+		if err != nil {
+			return nil, err
+		}
+		s.FMDenyPathsRaw = string(fmDenyPathsRaw)
+	}
+
 	if err := singleton.DB.Save(&s).Error; err != nil {
 		return nil, newGormError("%v", err)
 	}
@@ -138,6 +186,7 @@ func batchDeleteServer(c *gin.Context) (any, error) {
 	if err != nil {
 		return nil, newGormError("%v", err)
 	}
+	singleton.ServerGroupShared.ReloadMembers()
 
 	singleton.AlertsLock.Lock()
 	for _, sid := range servers {
@@ -152,6 +201,8 @@ func batchDeleteServer(c *gin.Context) (any, error) {
 	singleton.DB.Unscoped().Delete(&model.Transfer{}, "server_id in (?)", servers)
 	singleton.AlertsLock.Unlock()
 
+	singleton.DeleteHostSnapshots(servers)
+
 	singleton.ServerShared.Delete(servers)
 	return nil, nil
 }
@@ -309,3 +360,29 @@ func setServerConfig(c *gin.Context) (*model.ServerTaskResponse, error) {
 	wg.Wait()
 	return &resp, nil
 }
+
+func getServerWithPermission(c *gin.Context) (*model.Server, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	server, ok := singleton.ServerShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("server not found")
+	}
+
+	if !server.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	return server, nil
+}
+
+// validateSelector 检查作为目标的标签选择器，空选择器表示不使用
+func validateSelector(selector string) error {
+	if _, err := labels.Parse(selector); err != nil {
+		return singleton.Localizer.ErrorT("invalid label selector: %v", err)
+	}
+	return nil
+}
@@ -106,6 +106,8 @@ func createServerGroup(c *gin.Context) (uint64, error) {
 		return 0, newGormError("%v", err)
 	}
 
+	singleton.ServerGroupShared.Update(&sg)
+	singleton.ServerGroupShared.ReloadMembers()
 	return sg.ID, nil
 }
 
@@ -185,6 +187,8 @@ func updateServerGroup(c *gin.Context) (any, error) {
 		return nil, newGormError("%v", err)
 	}
 
+	singleton.ServerGroupShared.Update(&sgDB)
+	singleton.ServerGroupShared.ReloadMembers()
 	return nil, nil
 }
 
@@ -230,5 +234,21 @@ func batchDeleteServerGroup(c *gin.Context) (any, error) {
 		return nil, newGormError("%v", err)
 	}
 
+	singleton.ServerGroupShared.Delete(sgs)
+	singleton.ServerGroupShared.ReloadMembers()
 	return nil, nil
 }
+
+// checkServerGroups 检查作为目标的分组是否存在且有权限使用
+func checkServerGroups(c *gin.Context, groups []uint64) error {
+	for _, id := range groups {
+		sg, ok := singleton.ServerGroupShared.Get(id)
+		if !ok {
+			return singleton.Localizer.ErrorT("group id %d does not exist", id)
+		}
+		if !sg.HasPermission(c) {
+			return singleton.Localizer.ErrorT("permission denied")
+		}
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package controller
 
 import (
+	"encoding/csv"
+	"fmt"
 	"maps"
+	"net/http"
+	"net/url"
 	"slices"
 	"strconv"
 	"strings"
@@ -9,6 +13,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
+	"github.com/miekg/dns"
 
 	"github.com/telexy324/billabong/model"
 	"github.com/telexy324/billabong/pkg/utils"
@@ -190,7 +195,10 @@ func createService(c *gin.Context) (uint64, error) {
 	m.Target = strings.TrimSpace(mf.Target)
 	m.Type = mf.Type
 	m.SkipServers = mf.SkipServers
+	m.SkipServerGroups = mf.SkipServerGroups
+	m.SkipServerSelector = mf.SkipServerSelector
 	m.Cover = mf.Cover
+	m.RunOnDashboard = mf.RunOnDashboard
 	m.Notify = mf.Notify
 	m.NotificationGroupID = mf.NotificationGroupID
 	m.Duration = mf.Duration
@@ -201,6 +209,11 @@ func createService(c *gin.Context) (uint64, error) {
 	m.EnableTriggerTask = mf.EnableTriggerTask
 	m.RecoverTriggerTasks = mf.RecoverTriggerTasks
 	m.FailTriggerTasks = mf.FailTriggerTasks
+	m.CheckOptions = mf.CheckOptions
+
+	if err := validateServiceCheck(&m); err != nil {
+		return 0, err
+	}
 
 	if err := validateServers(c, &m); err != nil {
 		return 0, err
@@ -210,10 +223,8 @@ func createService(c *gin.Context) (uint64, error) {
 		return 0, newGormError("%v", err)
 	}
 
-	var skipServers []uint64
-	for k := range m.SkipServers {
-		skipServers = append(skipServers, k)
-	}
+	skipServers := append(utils.MapKeysToSlice(m.SkipServers), singleton.ServerGroupShared.Members(m.SkipServerGroups...)...)
+	skipServers = append(skipServers, singleton.ServerShared.Select(m.SkipServerSelector)...)
 
 	var err error
 	if m.Cover == 0 {
@@ -268,7 +279,10 @@ func updateService(c *gin.Context) (any, error) {
 	m.Target = strings.TrimSpace(mf.Target)
 	m.Type = mf.Type
 	m.SkipServers = mf.SkipServers
+	m.SkipServerGroups = mf.SkipServerGroups
+	m.SkipServerSelector = mf.SkipServerSelector
 	m.Cover = mf.Cover
+	m.RunOnDashboard = mf.RunOnDashboard
 	m.Notify = mf.Notify
 	m.NotificationGroupID = mf.NotificationGroupID
 	m.Duration = mf.Duration
@@ -279,6 +293,11 @@ func updateService(c *gin.Context) (any, error) {
 	m.EnableTriggerTask = mf.EnableTriggerTask
 	m.RecoverTriggerTasks = mf.RecoverTriggerTasks
 	m.FailTriggerTasks = mf.FailTriggerTasks
+	m.CheckOptions = mf.CheckOptions
+
+	if err := validateServiceCheck(&m); err != nil {
+		return 0, err
+	}
 
 	if err := validateServers(c, &m); err != nil {
 		return 0, err
@@ -288,7 +307,8 @@ func updateService(c *gin.Context) (any, error) {
 		return nil, newGormError("%v", err)
 	}
 
-	skipServers := utils.MapKeysToSlice(mf.SkipServers)
+	skipServers := append(utils.MapKeysToSlice(mf.SkipServers), singleton.ServerGroupShared.Members(m.SkipServerGroups...)...)
+	skipServers = append(skipServers, singleton.ServerShared.Select(m.SkipServerSelector)...)
 
 	if m.Cover == model.ServiceCoverAll {
 		err = singleton.DB.Unscoped().Delete(&model.ServiceHistory{}, "service_id = ? and server_id in (?)", m.ID, skipServers).Error
@@ -342,10 +362,197 @@ func batchDeleteService(c *gin.Context) (any, error) {
 	return nil, nil
 }
 
+// Get service SLA report
+// @Summary Get service SLA report
+// @Security BearerAuth
+// @Schemes
+// @Description Get SLA report of services for a period, period can be a month (2006-01) or a quarter (2006-Q1), or use from/to (2006-01-02)
+// @Tags auth required
+// @Param service_id query string false "Comma separated service IDs, all services if empty"
+// @Param period query string false "Month (2006-01) or quarter (2006-Q1)"
+// @Param from query string false "Start date (2006-01-02)"
+// @Param to query string false "End date (2006-01-02), inclusive"
+// @Param format query string false "json or csv"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.ServiceSLAReport]
+// @Router /service/sla [get]
+func getServiceSLAReport(c *gin.Context) ([]*model.ServiceSLAReport, error) {
+	from, to, err := parseReportPeriod(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		return nil, err
+	}
+
+	var services []*model.Service
+	if idStr := c.Query("service_id"); idStr != "" {
+		for _, v := range strings.Split(idStr, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			s, ok := singleton.ServiceSentinelShared.Get(id)
+			if !ok {
+				return nil, singleton.Localizer.ErrorT("service id %d does not exist", id)
+			}
+			if !s.HasPermission(c) {
+				return nil, singleton.Localizer.ErrorT("permission denied")
+			}
+			services = append(services, s)
+		}
+	} else {
+		services = filter(c, singleton.ServiceSentinelShared.GetSortedList())
+	}
+
+	reports, err := singleton.ServiceSLAReports(services, from, to)
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	if c.Query("format") != "csv" {
+		return reports, nil
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=sla-%s-%s.csv", from.Format("20060102"), to.Format("20060102")))
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"service_id", "service_name", "from", "to", "availability", "downtime_minutes", "incidents", "mean_latency", "up", "down"})
+	for _, r := range reports {
+		w.Write([]string{
+			strconv.FormatUint(r.ServiceID, 10),
+			r.ServiceName,
+			r.From,
+			r.To,
+			strconv.FormatFloat(r.Availability, 'f', 4, 64),
+			strconv.FormatFloat(r.DowntimeMinutes, 'f', 1, 64),
+			strconv.FormatUint(r.Incidents, 10),
+			strconv.FormatFloat(float64(r.MeanLatency), 'f', 2, 32),
+			strconv.FormatUint(r.Up, 10),
+			strconv.FormatUint(r.Down, 10),
+		})
+	}
+	w.Flush()
+	return nil, errNoop
+}
+
+// parseReportPeriod 解析报表周期，默认为当月
+func parseReportPeriod(period, fromStr, toStr string) (time.Time, time.Time, error) {
+	now := time.Now().In(singleton.Loc)
+
+	switch {
+	case period != "" && strings.Contains(period, "-Q"):
+		var year, quarter int
+		if _, err := fmt.Sscanf(period, "%d-Q%d", &year, &quarter); err != nil || quarter < 1 || quarter > 4 {
+			return time.Time{}, time.Time{}, singleton.Localizer.ErrorT("invalid period: %s", period)
+		}
+		from := time.Date(year, time.Month(quarter*3-2), 1, 0, 0, 0, 0, singleton.Loc)
+		return from, from.AddDate(0, 3, -1), nil
+	case period != "":
+		from, err := time.ParseInLocation("2006-01", period, singleton.Loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, singleton.Localizer.ErrorT("invalid period: %s", period)
+		}
+		return from, from.AddDate(0, 1, -1), nil
+	case fromStr != "" && toStr != "":
+		from, err := time.ParseInLocation(time.DateOnly, fromStr, singleton.Loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to, err := time.ParseInLocation(time.DateOnly, toStr, singleton.Loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if to.Before(from) {
+			return time.Time{}, time.Time{}, singleton.Localizer.ErrorT("the end date must not be earlier than the start date")
+		}
+		return from, to, nil
+	default:
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, singleton.Loc)
+		return from, from.AddDate(0, 1, -1), nil
+	}
+}
+
 func validateServers(c *gin.Context, ss *model.Service) error {
 	if !singleton.ServerShared.CheckPermission(c, maps.Keys(ss.SkipServers)) {
 		return singleton.Localizer.ErrorT("permission denied")
 	}
 
+	if err := checkServerGroups(c, ss.SkipServerGroups); err != nil {
+		return err
+	}
+	return validateSelector(ss.SkipServerSelector)
+}
+
+func validateServiceCheck(m *model.Service) error {
+	switch m.Type {
+	case model.TaskTypeHTTPGet, model.TaskTypeICMPPing, model.TaskTypeTCPPing:
+		m.CheckOptions = nil
+		return nil
+	case model.TaskTypeDNS, model.TaskTypeTLS, model.TaskTypeHTTP:
+	default:
+		return singleton.Localizer.ErrorT("unsupported service type: %d", m.Type)
+	}
+
+	if m.Target == "" {
+		return singleton.Localizer.ErrorT("service target cannot be empty")
+	}
+
+	// 仅保留与监控类型对应的配置
+	var opts model.ServiceCheckOptions
+	if m.CheckOptions != nil {
+		opts = *m.CheckOptions
+	}
+	m.CheckOptions = &model.ServiceCheckOptions{}
+
+	switch m.Type {
+	case model.TaskTypeDNS:
+		if opts.DNS != nil {
+			opts.DNS.RecordType = strings.ToUpper(strings.TrimSpace(opts.DNS.RecordType))
+			if opts.DNS.RecordType != "" {
+				if _, ok := dns.StringToType[opts.DNS.RecordType]; !ok {
+					return singleton.Localizer.ErrorT("invalid DNS record type: %s", opts.DNS.RecordType)
+				}
+			}
+			m.CheckOptions.DNS = opts.DNS
+		}
+	case model.TaskTypeTLS:
+		if strings.Contains(m.Target, "/") {
+			return singleton.Localizer.ErrorT("invalid target, expected host[:port]")
+		}
+		m.CheckOptions.TLS = opts.TLS
+	case model.TaskTypeHTTP:
+		u, err := url.Parse(m.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return singleton.Localizer.ErrorT("invalid target, expected an http(s) URL")
+		}
+		if opts.HTTP != nil {
+			opts.HTTP.Method = strings.ToUpper(strings.TrimSpace(opts.HTTP.Method))
+			if opts.HTTP.Method != "" && !slices.Contains(httpMethods, opts.HTTP.Method) {
+				return singleton.Localizer.ErrorT("invalid HTTP method: %s", opts.HTTP.Method)
+			}
+			for _, code := range opts.HTTP.ExpectedStatus {
+				if code < 100 || code > 599 {
+					return singleton.Localizer.ErrorT("invalid HTTP status code: %d", code)
+				}
+			}
+			switch opts.HTTP.KeywordMode {
+			case model.ServiceKeywordNone:
+			case model.ServiceKeywordRequired, model.ServiceKeywordForbidden:
+				if opts.HTTP.Keyword == "" {
+					return singleton.Localizer.ErrorT("keyword cannot be empty")
+				}
+			default:
+				return singleton.Localizer.ErrorT("invalid keyword mode: %d", opts.HTTP.KeywordMode)
+			}
+			if opts.HTTP.JSONValue != "" && opts.HTTP.JSONPath == "" {
+				return singleton.Localizer.ErrorT("JSON path cannot be empty")
+			}
+			m.CheckOptions.HTTP = opts.HTTP
+		}
+	}
+
 	return nil
 }
+
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
@@ -86,6 +86,9 @@ func updateConfig(c *gin.Context) (any, error) {
 	if !userTemplateValid {
 		return nil, errors.New("invalid user template")
 	}
+	if sf.FMMaxTransferSize < 0 {
+		return nil, singleton.Localizer.ErrorT("invalid transfer size")
+	}
 
 	singleton.Conf.Language = strings.Replace(sf.Language, "-", "_", -1)
 
@@ -95,13 +98,19 @@ func updateConfig(c *gin.Context) (any, error) {
 	singleton.Conf.InstallHost = sf.InstallHost
 	singleton.Conf.IgnoredIPNotification = sf.IgnoredIPNotification
 	singleton.Conf.IPChangeNotificationGroupID = sf.IPChangeNotificationGroupID
+	singleton.Conf.EnableHostChangeNotification = sf.EnableHostChangeNotification
+	singleton.Conf.HostChangeNotificationGroupID = sf.HostChangeNotificationGroupID
 	singleton.Conf.SiteName = sf.SiteName
 	singleton.Conf.DNSServers = sf.DNSServers
 	singleton.Conf.CustomCode = sf.CustomCode
 	singleton.Conf.CustomCodeDashboard = sf.CustomCodeDashboard
 	singleton.Conf.RealIPHeader = sf.RealIPHeader
 	singleton.Conf.AgentTLS = sf.AgentTLS
+	singleton.Conf.RequireAgentApproval = sf.RequireAgentApproval
 	singleton.Conf.UserTemplate = sf.UserTemplate
+	singleton.Conf.EnableTerminalRecording = sf.EnableTerminalRecording
+	singleton.Conf.RecordTerminalInput = sf.RecordTerminalInput
+	singleton.Conf.FMMaxTransferSize = sf.FMMaxTransferSize
 
 	if err := singleton.Conf.Save(); err != nil {
 		return nil, newGormError("%v", err)
@@ -111,3 +120,57 @@ func updateConfig(c *gin.Context) (any, error) {
 	singleton.OnUpdateLang(singleton.Conf.Language)
 	return nil, nil
 }
+
+// Get data retention
+// @Summary Get data retention
+// @Security BearerAuth
+// @Schemes
+// @Description Get data retention config with row counts and estimated sizes
+// @Tags admin required
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.RetentionResponse]
+// @Router /setting/retention [get]
+func getRetention(c *gin.Context) (*model.RetentionResponse, error) {
+	stats, err := singleton.RetentionStats()
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.RetentionResponse{
+		Config: singleton.Conf.Retention,
+		Tables: stats,
+	}, nil
+}
+
+// Edit data retention
+// @Summary Edit data retention
+// @Security BearerAuth
+// @Schemes
+// @Description Edit data retention config, only the given fields are changed, 0 means never clean by age
+// @Tags admin required
+// @Accept json
+// @Param body body model.RetentionConfig true "RetentionConfig"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /setting/retention [patch]
+func updateRetention(c *gin.Context) (any, error) {
+	// 在当前配置上覆盖，未提交的字段保持不变
+	rf := singleton.Conf.Retention
+	if err := c.ShouldBindJSON(&rf); err != nil {
+		return nil, err
+	}
+
+	for _, days := range []int{rf.ServiceHistoryDays, rf.PingHistoryDays, rf.TransferDays, rf.AuditLogDays,
+		rf.MessageDays, rf.CronExecutionDays, rf.TerminalRecordingDays, rf.NATTransferDays} {
+		if days < 0 {
+			return nil, singleton.Localizer.ErrorT("retention days must not be negative")
+		}
+	}
+
+	singleton.Conf.Retention = rf
+	if err := singleton.Conf.Save(); err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,479 @@
+package controller
+
+import (
+	"html/template"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/service/singleton"
+	"gorm.io/gorm"
+)
+
+var statusPageSlugRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// List status pages
+// @Summary List status pages
+// @Schemes
+// @Description List status pages
+// @Security BearerAuth
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.StatusPage]
+// @Router /status-page [get]
+func listStatusPage(c *gin.Context) ([]*model.StatusPage, error) {
+	var p []*model.StatusPage
+
+	slist := singleton.StatusPageShared.GetSortedList()
+
+	if err := copier.Copy(&p, &slist); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Add status page
+// @Summary Add status page
+// @Security BearerAuth
+// @Schemes
+// @Description Add status page
+// @Tags auth required
+// @Accept json
+// @param request body model.StatusPageForm true "Status Page Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /status-page [post]
+func createStatusPage(c *gin.Context) (uint64, error) {
+	var pf model.StatusPageForm
+	var p model.StatusPage
+
+	if err := c.ShouldBindJSON(&pf); err != nil {
+		return 0, err
+	}
+
+	if err := validateStatusPage(c, &pf, 0); err != nil {
+		return 0, err
+	}
+
+	p.UserID = getUid(c)
+	fillStatusPage(&p, &pf)
+
+	if err := singleton.DB.Create(&p).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Update(&p)
+	return p.ID, nil
+}
+
+// Edit status page
+// @Summary Edit status page
+// @Security BearerAuth
+// @Schemes
+// @Description Edit status page
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Status Page ID"
+// @param request body model.StatusPageForm true "Status Page Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /status-page/{id} [patch]
+func updateStatusPage(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var pf model.StatusPageForm
+	if err := c.ShouldBindJSON(&pf); err != nil {
+		return nil, err
+	}
+
+	var p model.StatusPage
+	if err := singleton.DB.First(&p, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("status page id %d does not exist", id)
+	}
+
+	if !p.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := validateStatusPage(c, &pf, p.ID); err != nil {
+		return nil, err
+	}
+
+	fillStatusPage(&p, &pf)
+
+	if err := singleton.DB.Save(&p).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Update(&p)
+	return nil, nil
+}
+
+// Batch delete status pages
+// @Summary Batch delete status pages
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete status pages
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/status-page [post]
+func batchDeleteStatusPage(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	if !singleton.StatusPageShared.CheckPermission(c, slices.Values(ids)) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	err := singleton.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&model.StatusPage{}, "id in (?)", ids).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.StatusPageIncident{}, "status_page_id in (?)", ids).Error
+	})
+	if err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Delete(ids)
+	return nil, nil
+}
+
+// List status page incidents
+// @Summary List status page incidents
+// @Security BearerAuth
+// @Schemes
+// @Description List incidents of a status page
+// @Tags auth required
+// @param id path uint true "Status Page ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.StatusPageIncident]
+// @Router /status-page/{id}/incident [get]
+func listStatusPageIncident(c *gin.Context) ([]*model.StatusPageIncident, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if p, ok := singleton.StatusPageShared.Get(id); !ok || !p.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	var incidents []*model.StatusPageIncident
+	if err := singleton.DB.Where("status_page_id = ?", id).Order("created_at DESC").Find(&incidents).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return incidents, nil
+}
+
+// Add status page incident
+// @Summary Add status page incident
+// @Security BearerAuth
+// @Schemes
+// @Description Add status page incident
+// @Tags auth required
+// @Accept json
+// @param request body model.StatusPageIncidentForm true "Incident Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /status-page-incident [post]
+func createStatusPageIncident(c *gin.Context) (uint64, error) {
+	var inf model.StatusPageIncidentForm
+	if err := c.ShouldBindJSON(&inf); err != nil {
+		return 0, err
+	}
+
+	p, ok := singleton.StatusPageShared.Get(inf.StatusPageID)
+	if !ok || !p.HasPermission(c) {
+		return 0, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	var incident model.StatusPageIncident
+	incident.UserID = getUid(c)
+	incident.StatusPageID = inf.StatusPageID
+	if err := fillStatusPageIncident(&incident, &inf); err != nil {
+		return 0, err
+	}
+
+	if err := singleton.DB.Create(&incident).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Update(p)
+	return incident.ID, nil
+}
+
+// Edit status page incident
+// @Summary Edit status page incident
+// @Security BearerAuth
+// @Schemes
+// @Description Edit status page incident
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Incident ID"
+// @param request body model.StatusPageIncidentForm true "Incident Request"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /status-page-incident/{id} [patch]
+func updateStatusPageIncident(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var inf model.StatusPageIncidentForm
+	if err := c.ShouldBindJSON(&inf); err != nil {
+		return nil, err
+	}
+
+	var incident model.StatusPageIncident
+	if err := singleton.DB.First(&incident, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("incident id %d does not exist", id)
+	}
+
+	p, ok := singleton.StatusPageShared.Get(incident.StatusPageID)
+	if !ok || !p.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := fillStatusPageIncident(&incident, &inf); err != nil {
+		return nil, err
+	}
+
+	if err := singleton.DB.Save(&incident).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.StatusPageShared.Update(p)
+	return nil, nil
+}
+
+// Batch delete status page incidents
+// @Summary Batch delete status page incidents
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete status page incidents
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/status-page-incident [post]
+func batchDeleteStatusPageIncident(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	var incidents []model.StatusPageIncident
+	if err := singleton.DB.Where("id in (?)", ids).Find(&incidents).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var pages []*model.StatusPage
+	for _, incident := range incidents {
+		p, ok := singleton.StatusPageShared.Get(incident.StatusPageID)
+		if !ok {
+			continue
+		}
+		if !p.HasPermission(c) {
+			return nil, singleton.Localizer.ErrorT("permission denied")
+		}
+		pages = append(pages, p)
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.StatusPageIncident{}, "id in (?)", ids).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	for _, p := range pages {
+		singleton.StatusPageShared.Update(p)
+	}
+	return nil, nil
+}
+
+// Show status page
+// @Summary Show status page
+// @Schemes
+// @Description Show a public status page
+// @Tags common
+// @param slug path string true "Status Page Slug"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.StatusPageResponse]
+// @Router /status/{slug} [get]
+func showStatusPage(c *gin.Context) (*model.StatusPageResponse, error) {
+	p := singleton.StatusPageShared.GetBySlug(c.Param("slug"))
+	if p == nil || !p.Enabled {
+		return nil, singleton.Localizer.ErrorT("status page not found")
+	}
+
+	res, err, _ := requestGroup.Do("status-page::"+p.Slug, func() (any, error) {
+		return singleton.StatusPageShared.Render(p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*model.StatusPageResponse), nil
+}
+
+// renderStatusPage 服务端渲染的简易状态页，无需前端资源
+func renderStatusPage(c *gin.Context) {
+	res, err := showStatusPage(c)
+	if err != nil {
+		c.String(http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := statusPageTemplate.Execute(c.Writer, res); err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+	}
+}
+
+func validateStatusPage(c *gin.Context, pf *model.StatusPageForm, id uint64) error {
+	pf.Slug = strings.ToLower(strings.TrimSpace(pf.Slug))
+	pf.Domain = strings.ToLower(strings.TrimSpace(pf.Domain))
+
+	if !statusPageSlugRegexp.MatchString(pf.Slug) {
+		return singleton.Localizer.ErrorT("invalid slug, only lowercase letters, digits and hyphens are allowed")
+	}
+	if p := singleton.StatusPageShared.GetBySlug(pf.Slug); p != nil && p.ID != id {
+		return singleton.Localizer.ErrorT("slug %s is already in use", pf.Slug)
+	}
+
+	if pf.Domain != "" {
+		if p := singleton.StatusPageShared.GetByDomain(pf.Domain); p != nil && p.ID != id {
+			return singleton.Localizer.ErrorT("domain %s is already in use", pf.Domain)
+		}
+		if singleton.NATShared.GetNATConfigByDomain(pf.Domain) != nil {
+			return singleton.Localizer.ErrorT("domain %s is already in use", pf.Domain)
+		}
+	}
+
+	if pf.LogoID > 0 {
+		var logo model.Upload
+		if err := singleton.DB.First(&logo, pf.LogoID).Error; err != nil {
+			return singleton.Localizer.ErrorT("file id %d does not exist", pf.LogoID)
+		}
+	}
+
+	for _, section := range pf.Sections {
+		if !singleton.ServiceSentinelShared.CheckPermission(c, slices.Values(section.Services)) ||
+			!singleton.ServerShared.CheckPermission(c, slices.Values(section.Servers)) {
+			return singleton.Localizer.ErrorT("permission denied")
+		}
+	}
+
+	return nil
+}
+
+func fillStatusPage(p *model.StatusPage, pf *model.StatusPageForm) {
+	p.Enabled = pf.Enabled
+	p.Name = pf.Name
+	p.Slug = pf.Slug
+	p.Domain = pf.Domain
+	p.Title = pf.Title
+	p.Description = pf.Description
+	p.LogoID = pf.LogoID
+	p.Sections = pf.Sections
+}
+
+func fillStatusPageIncident(incident *model.StatusPageIncident, inf *model.StatusPageIncidentForm) error {
+	if inf.Status > model.IncidentStatusResolved {
+		return singleton.Localizer.ErrorT("invalid incident status: %d", inf.Status)
+	}
+
+	incident.Title = inf.Title
+	incident.Content = inf.Content
+	if inf.Status == model.IncidentStatusResolved && incident.Status != model.IncidentStatusResolved {
+		now := time.Now()
+		incident.ResolvedAt = &now
+	} else if inf.Status != model.IncidentStatusResolved {
+		incident.ResolvedAt = nil
+	}
+	incident.Status = inf.Status
+	return nil
+}
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"logo": func(url string) string {
+		if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+			return url
+		}
+		return path.Join("/api/v1", url)
+	},
+	"incidentStatus": func(status uint8) string {
+		switch status {
+		case model.IncidentStatusInvestigating:
+			return singleton.Localizer.T("Investigating")
+		case model.IncidentStatusIdentified:
+			return singleton.Localizer.T("Identified")
+		case model.IncidentStatusMonitoring:
+			return singleton.Localizer.T("Monitoring")
+		default:
+			return singleton.Localizer.T("Resolved")
+		}
+	},
+	"dayClass": func(d model.StatusPageDayItem) string {
+		switch {
+		case d.Up+d.Down == 0:
+			return "none"
+		case d.Uptime() > 95:
+			return "good"
+		case d.Uptime() > 80:
+			return "low"
+		default:
+			return "down"
+		}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;max-width:860px;margin:0 auto;padding:24px;color:#222}
+header{display:flex;align-items:center;gap:12px}
+header img{max-height:48px}
+section{margin-top:32px}
+.item{display:flex;justify-content:space-between;margin-top:12px}
+.bar{display:flex;gap:2px;margin-top:4px}
+.bar span{flex:1;height:24px;border-radius:2px}
+.good{background:#3ba55c}.low{background:#faa61a}.down{background:#ed4245}.none{background:#ddd}
+.incident{border-left:4px solid #faa61a;padding:8px 12px;margin-top:12px;background:#fafafa}
+.muted{color:#888;font-size:12px}
+</style>
+</head>
+<body>
+<header>{{if .Logo}}<img src="{{logo .Logo}}" alt="">{{end}}<h1>{{.Title}}</h1></header>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{range .Incidents}}<div class="incident"><strong>{{.Title}}</strong> <span class="muted">{{incidentStatus .Status}} · {{.CreatedAt.Format "2006-01-02 15:04"}}</span><p>{{.Content}}</p></div>{{end}}
+{{range .Sections}}<section><h2>{{.Name}}</h2>
+{{range .Services}}<div class="item"><span>{{.Name}}</span><span>{{.Status}} · {{printf "%.2f" .Uptime}}%</span></div>
+<div class="bar">{{range .Days}}<span class="{{dayClass .}}" title="{{.Date}}"></span>{{end}}</div>{{end}}
+{{range .Servers}}<div class="item"><span>{{.Name}}</span><span>{{if .Online}}●{{else}}○{{end}}</span></div>{{end}}
+</section>{{end}}
+<p class="muted">{{.UpdatedAt.Format "2006-01-02 15:04:05"}}</p>
+</body>
+</html>`))
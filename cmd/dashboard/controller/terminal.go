@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"io"
+	"log"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -46,6 +48,15 @@ func createTerminal(c *gin.Context) (*model.CreateTerminalResponse, error) {
 
 	rpc.NezhaHandlerSingleton.CreateStream(streamId)
 
+	user := c.MustGet(model.CtxKeyAuthorizedUser).(*model.User)
+	singleton.Cache.Set(model.CacheKeyTerminal+streamId, &terminalSession{
+		UserID:     user.ID,
+		Username:   user.Username,
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		ClientIP:   c.GetString(model.CtxKeyRealIPStr),
+	}, time.Minute)
+
 	terminalData, _ := json.Marshal(&model.TerminalTask{
 		StreamID: streamId,
 	})
@@ -84,6 +95,30 @@ func terminalStream(c *gin.Context) (any, error) {
 	defer wsConn.Close()
 	conn := websocketx.NewConn(wsConn)
 
+	var userIo io.ReadWriteCloser = conn
+	if s, ok := singleton.Cache.Get(model.CacheKeyTerminal + streamId); ok {
+		singleton.Cache.Delete(model.CacheKeyTerminal + streamId)
+		session := s.(*terminalSession)
+
+		// 会话进行中可以分享给其他用户观看
+		liveTerminals.add(streamId, session)
+		defer liveTerminals.remove(streamId)
+
+		if singleton.Conf.EnableTerminalRecording {
+			recorder, err := newTerminalRecorder(conn, session)
+			if err != nil {
+				log.Printf("NEZHA>> Failed to start terminal recording: %v", err)
+			} else {
+				userIo = recorder
+				defer func() {
+					if err := recorder.save(); err != nil {
+						log.Printf("NEZHA>> Failed to save terminal recording: %v", err)
+					}
+				}()
+			}
+		}
+	}
+
 	go func() {
 		// PING 保活
 		for {
@@ -94,7 +129,7 @@ func terminalStream(c *gin.Context) (any, error) {
 		}
 	}()
 
-	if err = rpc.NezhaHandlerSingleton.UserConnected(streamId, conn); err != nil {
+	if err = rpc.NezhaHandlerSingleton.UserConnected(streamId, userIo); err != nil {
 		return nil, newWsError("%v", err)
 	}
 
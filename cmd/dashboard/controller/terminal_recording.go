@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/asciicast"
+	"github.com/telexy324/billabong/pkg/utils"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// terminalSession 创建终端或文件管理时记录的会话信息
+type terminalSession struct {
+	UserID     uint64
+	Username   string
+	ServerID   uint64
+	ServerName string
+	ClientIP   string
+	ShareToken string // 只读观看的分享令牌，由 liveTerminals.mu 保护
+}
+
+// terminalRecorder 记录浏览器与 agent 之间的终端数据
+type terminalRecorder struct {
+	io.ReadWriteCloser
+
+	session   *terminalSession
+	file      *os.File
+	cast      *asciicast.Writer
+	input     bool
+	startedAt time.Time
+}
+
+func newTerminalRecorder(conn io.ReadWriteCloser, session *terminalSession) (*terminalRecorder, error) {
+	// 录像只保存在私有目录，通过下载接口鉴权后读取
+	if err := os.MkdirAll(singleton.Conf.TerminalRecordingPath, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(singleton.Conf.TerminalRecordingPath, "terminal-*.cast.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cast, err := asciicast.NewWriter(f, asciicast.Header{
+		Width:     80,
+		Height:    24,
+		Timestamp: now.Unix(),
+		Title:     fmt.Sprintf("%s@%s", session.Username, session.ServerName),
+	})
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	return &terminalRecorder{
+		ReadWriteCloser: conn,
+		session:         session,
+		file:            f,
+		cast:            cast,
+		input:           singleton.Conf.RecordTerminalInput,
+		startedAt:       now,
+	}, nil
+}
+
+// Write agent 的输出
+func (r *terminalRecorder) Write(p []byte) (int, error) {
+	n, err := r.ReadWriteCloser.Write(p)
+	if n > 0 {
+		r.cast.Output(p[:n])
+	}
+	return n, err
+}
+
+// Read 浏览器发送的数据，首字节 0 为输入，1 为窗口大小调整
+func (r *terminalRecorder) Read(p []byte) (int, error) {
+	n, err := r.ReadWriteCloser.Read(p)
+	if n > 1 {
+		switch p[0] {
+		case 0:
+			if r.input {
+				r.cast.Input(p[1:n])
+			}
+		case 1:
+			var size struct {
+				Cols int `json:"cols"`
+				Rows int `json:"rows"`
+			}
+			if json.Unmarshal(p[1:n], &size) == nil && size.Cols > 0 && size.Rows > 0 {
+				r.cast.Resize(size.Cols, size.Rows)
+			}
+		}
+	}
+	return n, err
+}
+
+// save 保存录像文件并记录
+func (r *terminalRecorder) save() error {
+	defer func() {
+		r.file.Close()
+		os.Remove(r.file.Name())
+	}()
+
+	if err := r.cast.Flush(); err != nil {
+		return err
+	}
+	size, err := r.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+
+	random, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("terminal-%d-%d-%s.cast", r.session.ServerID, r.startedAt.Unix(), random)
+	if err := os.Rename(r.file.Name(), singleton.TerminalRecordingFile(key)); err != nil {
+		return err
+	}
+
+	recording := model.TerminalRecording{
+		Username:   r.session.Username,
+		ServerID:   r.session.ServerID,
+		ServerName: r.session.ServerName,
+		ClientIP:   r.session.ClientIP,
+		StartedAt:  r.startedAt,
+		EndedAt:    time.Now(),
+		Input:      r.input,
+		Key:        key,
+		Size:       size,
+	}
+	recording.UserID = r.session.UserID
+	return singleton.DB.Create(&recording).Error
+}
+
+// List terminal recordings
+// @Summary List terminal recordings
+// @Security BearerAuth
+// @Schemes
+// @Description List terminal session recordings
+// @Tags admin required
+// @Param server_id query uint false "Server ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.TerminalRecording, model.TerminalRecording]
+// @Router /terminal-recording [get]
+func listTerminalRecording(c *gin.Context) (*model.Value[[]*model.TerminalRecording], error) {
+	if !isAdmin(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	tx := singleton.DB.Model(&model.TerminalRecording{})
+	if serverID, err := strconv.ParseUint(c.Query("server_id"), 10, 64); err == nil {
+		tx = tx.Where("server_id = ?", serverID)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var recordings []*model.TerminalRecording
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&recordings).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.TerminalRecording]{
+		Value: recordings,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// Download terminal recording
+// @Summary Download terminal recording
+// @Security BearerAuth
+// @Schemes
+// @Description Download a terminal session recording in asciicast v2 format
+// @Tags admin required
+// @Param id path uint true "Recording ID"
+// @Produce application/x-asciicast
+// @Success 200 {file} file
+// @Router /terminal-recording/{id}/download [get]
+func downloadTerminalRecording(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var recording model.TerminalRecording
+	if err := singleton.DB.First(&recording, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("recording id %d does not exist", id)
+	}
+
+	f, err := os.Open(singleton.TerminalRecordingFile(recording.Key))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="terminal-%d-%s.cast"`, recording.ServerID, recording.StartedAt.Format("20060102150405")))
+	c.DataFromReader(http.StatusOK, recording.Size, "application/x-asciicast", f, nil)
+	return nil, errNoop
+}
+
+// Batch delete terminal recordings
+// @Summary Batch delete terminal recordings
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete terminal recordings
+// @Tags admin required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/terminal-recording [post]
+func batchDeleteTerminalRecording(c *gin.Context) (any, error) {
+	var ids []uint64
+	if err := c.ShouldBindJSON(&ids); err != nil {
+		return nil, err
+	}
+
+	var recordings []*model.TerminalRecording
+	if err := singleton.DB.Where("id in (?)", ids).Find(&recordings).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	if err := singleton.DeleteTerminalRecordings(recordings); err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
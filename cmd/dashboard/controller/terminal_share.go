@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/go-uuid"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/websocketx"
+	"github.com/telexy324/billabong/service/rpc"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// liveTerminals 进行中的终端会话及其分享令牌
+var liveTerminals = &terminalRegistry{
+	sessions: make(map[string]*terminalSession),
+	shares:   make(map[string]string),
+}
+
+type terminalRegistry struct {
+	mu       sync.RWMutex
+	sessions map[string]*terminalSession // stream id -> 会话
+	shares   map[string]string           // 分享令牌 -> stream id
+}
+
+func (r *terminalRegistry) add(streamId string, session *terminalSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[streamId] = session
+}
+
+func (r *terminalRegistry) remove(streamId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[streamId]; ok {
+		delete(r.shares, session.ShareToken)
+		delete(r.sessions, streamId)
+	}
+}
+
+// owned 返回当前用户创建的进行中会话
+func (r *terminalRegistry) owned(c *gin.Context) (string, *terminalSession, error) {
+	streamId := c.Param("id")
+
+	r.mu.RLock()
+	session, ok := r.sessions[streamId]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", nil, singleton.Localizer.ErrorT("terminal session not found")
+	}
+	if session.UserID != getUid(c) {
+		return "", nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return streamId, session, nil
+}
+
+// Share terminal session
+// @Summary Share terminal session
+// @Security BearerAuth
+// @Schemes
+// @Description Generate a token that lets other users watch the terminal session read-only
+// @Tags auth required
+// @Param id path string true "Stream UUID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[model.TerminalShareResponse]
+// @Router /terminal/{id}/share [post]
+func shareTerminal(c *gin.Context) (*model.TerminalShareResponse, error) {
+	streamId, session, err := liveTerminals.owned(c)
+	if err != nil {
+		return nil, err
+	}
+
+	liveTerminals.mu.Lock()
+	defer liveTerminals.mu.Unlock()
+
+	if session.ShareToken == "" {
+		token, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		session.ShareToken = token
+		liveTerminals.shares[token] = streamId
+	}
+	return &model.TerminalShareResponse{Token: session.ShareToken}, nil
+}
+
+// Stop sharing terminal session
+// @Summary Stop sharing terminal session
+// @Security BearerAuth
+// @Schemes
+// @Description Invalidate the share token and disconnect all viewers
+// @Tags auth required
+// @Param id path string true "Stream UUID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /terminal/{id}/unshare [post]
+func unshareTerminal(c *gin.Context) (any, error) {
+	streamId, session, err := liveTerminals.owned(c)
+	if err != nil {
+		return nil, err
+	}
+
+	liveTerminals.mu.Lock()
+	delete(liveTerminals.shares, session.ShareToken)
+	session.ShareToken = ""
+	liveTerminals.mu.Unlock()
+
+	for _, v := range rpc.NezhaHandlerSingleton.ListViewers(streamId) {
+		rpc.NezhaHandlerSingleton.RemoveViewer(streamId, v.ID)
+	}
+	return nil, nil
+}
+
+// List terminal viewers
+// @Summary List terminal viewers
+// @Security BearerAuth
+// @Schemes
+// @Description List users watching the terminal session
+// @Tags auth required
+// @Param id path string true "Stream UUID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.TerminalViewer]
+// @Router /terminal/{id}/viewer [get]
+func listTerminalViewer(c *gin.Context) ([]*model.TerminalViewer, error) {
+	streamId, _, err := liveTerminals.owned(c)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NezhaHandlerSingleton.ListViewers(streamId), nil
+}
+
+// Batch revoke terminal viewers
+// @Summary Batch revoke terminal viewers
+// @Security BearerAuth
+// @Schemes
+// @Description Disconnect viewers from the terminal session
+// @Tags auth required
+// @Accept json
+// @Param id path string true "Stream UUID"
+// @param request body []string true "viewer id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /terminal/{id}/viewer/batch-revoke [post]
+func batchRevokeTerminalViewer(c *gin.Context) (any, error) {
+	var viewers []string
+	if err := c.ShouldBindJSON(&viewers); err != nil {
+		return nil, err
+	}
+
+	streamId, _, err := liveTerminals.owned(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range viewers {
+		rpc.NezhaHandlerSingleton.RemoveViewer(streamId, id)
+	}
+	return nil, nil
+}
+
+// Terminal view stream
+// @Summary Terminal view stream
+// @Description Watch a shared terminal session read-only, input is ignored
+// @Tags auth required
+// @Param token path string true "Share token"
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /ws/terminal-view/{token} [get]
+func terminalViewStream(c *gin.Context) (any, error) {
+	liveTerminals.mu.RLock()
+	streamId, ok := liveTerminals.shares[c.Param("token")]
+	session := liveTerminals.sessions[streamId]
+	liveTerminals.mu.RUnlock()
+	if !ok || session == nil {
+		return nil, singleton.Localizer.ErrorT("terminal session not found")
+	}
+
+	// 分享令牌不代替服务器权限，观看者仍需有权访问会话所在的服务器
+	server, ok := singleton.ServerShared.Get(session.ServerID)
+	if !ok || !server.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	viewerId, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	user := c.MustGet(model.CtxKeyAuthorizedUser).(*model.User)
+
+	wsConn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return nil, newWsError("%v", err)
+	}
+	defer wsConn.Close()
+	conn := websocketx.NewConn(wsConn)
+
+	done, err := rpc.NezhaHandlerSingleton.AddViewer(streamId, &model.TerminalViewer{
+		ID:       viewerId,
+		UserID:   user.ID,
+		Username: user.Username,
+		ClientIP: c.GetString(model.CtxKeyRealIPStr),
+		JoinedAt: time.Now(),
+	}, conn)
+	if err != nil {
+		return nil, newWsError("%v", err)
+	}
+	defer rpc.NezhaHandlerSingleton.RemoveViewer(streamId, viewerId)
+
+	go func() {
+		// PING 保活
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Second * 10):
+				if err := conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// 丢弃观看者发送的数据，连接断开时结束
+	for {
+		if _, _, err := wsConn.ReadMessage(); err != nil {
+			return nil, newWsError("")
+		}
+	}
+}
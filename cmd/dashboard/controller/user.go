@@ -136,10 +136,14 @@ func createUser(c *gin.Context) (uint64, error) {
 	if uf.Role != model.RoleAdmin && uf.Role != model.RoleMember {
 		return 0, singleton.Localizer.ErrorT("invalid role")
 	}
+	if uf.FMMaxTransferSize < 0 {
+		return 0, singleton.Localizer.ErrorT("invalid transfer size")
+	}
 
 	var u model.User
 	u.Username = uf.Username
 	u.Role = uf.Role
+	u.FMMaxTransferSize = uf.FMMaxTransferSize
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(uf.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -155,6 +159,43 @@ func createUser(c *gin.Context) (uint64, error) {
 	return u.ID, nil
 }
 
+// Update user file manager limit
+// @Summary Update user file manager limit
+// @Security BearerAuth
+// @Schemes
+// @Description Set the maximum size of a single file manager transfer for the user
+// @Tags admin required
+// @Accept json
+// @param id path uint true "User ID"
+// @param request body model.UserFMLimitForm true "UserFMLimitForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /user/{id}/fm-limit [patch]
+func updateUserFMLimit(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var lf model.UserFMLimitForm
+	if err := c.ShouldBindJSON(&lf); err != nil {
+		return nil, err
+	}
+	if lf.FMMaxTransferSize < 0 {
+		return nil, singleton.Localizer.ErrorT("invalid transfer size")
+	}
+
+	var u model.User
+	if err := singleton.DB.First(&u, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("user id %d does not exist", id)
+	}
+
+	if err := singleton.DB.Model(&u).Update("fm_max_transfer_size", lf.FMMaxTransferSize).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+	return nil, nil
+}
+
 // Batch delete users
 // @Summary Batch delete users
 // @Security BearerAuth
@@ -50,7 +50,11 @@ func Waf(c *gin.Context) {
 }
 
 func ShowBlockPage(c *gin.Context, err error) {
-	c.Writer.WriteHeader(http.StatusForbidden)
+	ShowBlockPageWithCode(c, http.StatusForbidden, err)
+}
+
+func ShowBlockPageWithCode(c *gin.Context, code int, err error) {
+	c.Writer.WriteHeader(code)
 	c.Header("Content-Type", "text/html; charset=utf-8")
 	c.Writer.WriteString(strings.Replace(errorPageTemplate, "{error}", err.Error(), 1))
 	c.Abort()
@@ -0,0 +1,375 @@
+package controller
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/service/singleton"
+)
+
+// List workflows
+// @Summary List workflows
+// @Security BearerAuth
+// @Schemes
+// @Description List workflows
+// @Tags auth required
+// @Param id query uint false "Resource ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[[]model.Workflow]
+// @Router /workflow [get]
+func listWorkflow(c *gin.Context) ([]*model.Workflow, error) {
+	slist := singleton.WorkflowShared.GetSortedList()
+
+	var wf []*model.Workflow
+	if err := copier.Copy(&wf, &slist); err != nil {
+		return nil, err
+	}
+	return wf, nil
+}
+
+// Create new workflow
+// @Summary Create new workflow
+// @Security BearerAuth
+// @Schemes
+// @Description Create new workflow
+// @Tags auth required
+// @Accept json
+// @param request body model.WorkflowForm true "WorkflowForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /workflow [post]
+func createWorkflow(c *gin.Context) (uint64, error) {
+	var wff model.WorkflowForm
+	if err := c.ShouldBindJSON(&wff); err != nil {
+		return 0, err
+	}
+
+	if err := validateWorkflow(c, &wff); err != nil {
+		return 0, err
+	}
+
+	var wf model.Workflow
+	wf.UserID = getUid(c)
+	wf.Name = wff.Name
+	wf.Scheduler = wff.Scheduler
+	wf.NotificationGroupID = wff.NotificationGroupID
+	wf.PushSuccessful = wff.PushSuccessful
+	wf.Steps = wff.Steps
+	wf.Edges = wff.Edges
+
+	var err error
+	if wf.Scheduler != "" {
+		if wf.CronJobID, err = singleton.CronShared.AddFunc(wf.Scheduler, singleton.WorkflowTrigger(&wf, model.CronTriggerSchedule)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = singleton.DB.Create(&wf).Error; err != nil {
+		return 0, newGormError("%v", err)
+	}
+
+	singleton.WorkflowShared.Update(&wf)
+	return wf.ID, nil
+}
+
+// Update workflow
+// @Summary Update workflow
+// @Security BearerAuth
+// @Schemes
+// @Description Update workflow
+// @Tags auth required
+// @Accept json
+// @param id path uint true "Workflow ID"
+// @param request body model.WorkflowForm true "WorkflowForm"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /workflow/{id} [patch]
+func updateWorkflow(c *gin.Context) (any, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var wff model.WorkflowForm
+	if err := c.ShouldBindJSON(&wff); err != nil {
+		return nil, err
+	}
+
+	var wf model.Workflow
+	if err := singleton.DB.First(&wf, id).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("workflow id %d does not exist", id)
+	}
+
+	if !wf.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := validateWorkflow(c, &wff); err != nil {
+		return nil, err
+	}
+
+	wf.Name = wff.Name
+	wf.Scheduler = wff.Scheduler
+	wf.NotificationGroupID = wff.NotificationGroupID
+	wf.PushSuccessful = wff.PushSuccessful
+	wf.Steps = wff.Steps
+	wf.Edges = wff.Edges
+
+	if err = singleton.DB.Save(&wf).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	if wf.Scheduler != "" {
+		if wf.CronJobID, err = singleton.CronShared.AddFunc(wf.Scheduler, singleton.WorkflowTrigger(&wf, model.CronTriggerSchedule)); err != nil {
+			// 已保存的修改仍需生效，同时移除旧的定时
+			singleton.WorkflowShared.Update(&wf)
+			return nil, err
+		}
+	}
+
+	singleton.WorkflowShared.Update(&wf)
+	return nil, nil
+}
+
+// Trigger workflow
+// @Summary Trigger workflow
+// @Security BearerAuth
+// @Schemes
+// @Description Start a run of the workflow and return the run ID
+// @Tags auth required
+// @param id path uint true "Workflow ID"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[uint64]
+// @Router /workflow/{id}/manual [get]
+func manualTriggerWorkflow(c *gin.Context) (uint64, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	wf, ok := singleton.WorkflowShared.Get(id)
+	if !ok {
+		return 0, singleton.Localizer.ErrorT("workflow id %d does not exist", id)
+	}
+
+	if !wf.HasPermission(c) {
+		return 0, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	runID, err := singleton.WorkflowShared.Start(wf, model.CronTriggerManual, 0)
+	if err != nil {
+		return 0, newGormError("%v", err)
+	}
+	return runID, nil
+}
+
+// Batch delete workflows
+// @Summary Batch delete workflows
+// @Security BearerAuth
+// @Schemes
+// @Description Batch delete workflows
+// @Tags auth required
+// @Accept json
+// @param request body []uint64 true "id list"
+// @Produce json
+// @Success 200 {object} model.CommonResponse[any]
+// @Router /batch-delete/workflow [post]
+func batchDeleteWorkflow(c *gin.Context) (any, error) {
+	var wf []uint64
+	if err := c.ShouldBindJSON(&wf); err != nil {
+		return nil, err
+	}
+
+	if !singleton.WorkflowShared.CheckPermission(c, slices.Values(wf)) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+
+	if err := singleton.DB.Unscoped().Delete(&model.Workflow{}, "id in (?)", wf).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	singleton.WorkflowShared.Delete(wf)
+	return nil, nil
+}
+
+// List workflow runs
+// @Summary List workflow runs
+// @Security BearerAuth
+// @Schemes
+// @Description List run history of a workflow
+// @Tags auth required
+// @param id path uint true "Workflow ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.WorkflowRun, model.WorkflowRun]
+// @Router /workflow/{id}/run [get]
+func listWorkflowRun(c *gin.Context) (*model.Value[[]*model.WorkflowRun], error) {
+	wf, err := getWorkflowWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	var total int64
+	if err := singleton.DB.Model(&model.WorkflowRun{}).Where("workflow_id = ?", wf.ID).Count(&total).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	var runs []*model.WorkflowRun
+	if err := singleton.DB.Where("workflow_id = ?", wf.ID).Order("id DESC").Limit(limit).Offset(offset).Find(&runs).Error; err != nil {
+		return nil, newGormError("%v", err)
+	}
+
+	return &model.Value[[]*model.WorkflowRun]{
+		Value: runs,
+		Pagination: model.Pagination{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
+	}, nil
+}
+
+// List executions of workflow run
+// @Summary List executions of workflow run
+// @Security BearerAuth
+// @Schemes
+// @Description List schedule task executions dispatched by a workflow run
+// @Tags auth required
+// @param id path uint true "Workflow ID"
+// @param run path uint true "Run ID"
+// @Param limit query uint false "Page limit"
+// @Param offset query uint false "Page offset"
+// @Produce json
+// @Success 200 {object} model.PaginatedResponse[[]model.CronExecution, model.CronExecution]
+// @Router /workflow/{id}/run/{run}/execution [get]
+func listWorkflowRunExecution(c *gin.Context) (*model.Value[[]*model.CronExecution], error) {
+	wf, err := getWorkflowWithPermission(c)
+	if err != nil {
+		return nil, err
+	}
+
+	runID, err := strconv.ParseUint(c.Param("run"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	var run model.WorkflowRun
+	if err := singleton.DB.Where("id = ? AND workflow_id = ?", runID, wf.ID).First(&run).Error; err != nil {
+		return nil, singleton.Localizer.ErrorT("workflow run id %d does not exist", runID)
+	}
+
+	return paginateCronExecution(c, singleton.DB.Where("workflow_run_id = ?", run.ID))
+}
+
+func getWorkflowWithPermission(c *gin.Context) (*model.Workflow, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	wf, ok := singleton.WorkflowShared.Get(id)
+	if !ok {
+		return nil, singleton.Localizer.ErrorT("workflow id %d does not exist", id)
+	}
+
+	if !wf.HasPermission(c) {
+		return nil, singleton.Localizer.ErrorT("permission denied")
+	}
+	return wf, nil
+}
+
+// validateWorkflow 检查步骤引用的任务与服务器，并确保步骤之间不存在循环依赖
+func validateWorkflow(c *gin.Context, wff *model.WorkflowForm) error {
+	if len(wff.Steps) == 0 {
+		return singleton.Localizer.ErrorT("workflow must contain at least one step")
+	}
+
+	if wff.Scheduler != "" {
+		if err := validateCronSchedule(wff.Scheduler); err != nil {
+			return err
+		}
+	}
+
+	inDegree := make(map[string]int, len(wff.Steps))
+	for _, step := range wff.Steps {
+		if step.ID == "" {
+			return singleton.Localizer.ErrorT("step id cannot be empty")
+		}
+		if _, ok := inDegree[step.ID]; ok {
+			return singleton.Localizer.ErrorT("duplicate step id: %s", step.ID)
+		}
+		inDegree[step.ID] = 0
+
+		cr, ok := singleton.CronShared.Get(step.CronID)
+		if !ok {
+			return singleton.Localizer.ErrorT("task id %d does not exist", step.CronID)
+		}
+		if !cr.HasPermission(c) || !singleton.ServerShared.CheckPermission(c, slices.Values(step.Servers)) {
+			return singleton.Localizer.ErrorT("permission denied")
+		}
+		if err := checkServerGroups(c, step.ServerGroups); err != nil {
+			return err
+		}
+		if err := validateCronParameters(cr, step.Parameters); err != nil {
+			return err
+		}
+	}
+
+	next := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	for _, edge := range wff.Edges {
+		_, fromOK := inDegree[edge.From]
+		_, toOK := inDegree[edge.To]
+		if !fromOK || !toOK {
+			return singleton.Localizer.ErrorT("edge references unknown step: %s -> %s", edge.From, edge.To)
+		}
+		if edge.Condition > model.WorkflowEdgeAlways {
+			return singleton.Localizer.ErrorT("invalid edge condition: %d", edge.Condition)
+		}
+		if seen[[2]string{edge.From, edge.To}] {
+			return singleton.Localizer.ErrorT("duplicate edge: %s -> %s", edge.From, edge.To)
+		}
+		seen[[2]string{edge.From, edge.To}] = true
+		next[edge.From] = append(next[edge.From], edge.To)
+		inDegree[edge.To]++
+	}
+
+	// 拓扑排序，无法访问全部步骤说明存在环
+	var queue []string
+	for id, degree := range inDegree {
+		if degree == 0 {
+			queue = append(queue, id)
+		}
+	}
+	var visited int
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, to := range next[id] {
+			if inDegree[to]--; inDegree[to] == 0 {
+				queue = append(queue, to)
+			}
+		}
+	}
+	if visited != len(wff.Steps) {
+		return singleton.Localizer.ErrorT("workflow steps contain a cycle")
+	}
+
+	return nil
+}
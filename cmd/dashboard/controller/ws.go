@@ -15,6 +15,7 @@ import (
 	"golang.org/x/sync/singleflight"
 
 	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/labels"
 	"github.com/telexy324/billabong/pkg/utils"
 	"github.com/telexy324/billabong/service/singleton"
 )
@@ -100,6 +101,7 @@ func checkSameOrigin(r *http.Request) bool {
 // @Schemes
 // @Description Websocket server stream
 // @security BearerAuth
+// @Param selector query string false "Label selector, only applied for logged-in users"
 // @Produce json
 // @Success 200 {object} model.StreamServerData
 // @Router /ws/server [get]
@@ -109,6 +111,21 @@ func serverStream(c *gin.Context) (any, error) {
 		return nil, newWsError("%v", err)
 	}
 
+	u, isMember := c.Get(model.CtxKeyAuthorizedUser)
+	var userId uint64
+	if isMember {
+		userId = u.(*model.User).ID
+	}
+
+	// 标签仅对登录用户可见，游客的选择器被忽略
+	var selector string
+	if isMember {
+		selector = c.Query("selector")
+		if _, err := labels.Parse(selector); err != nil {
+			return nil, newWsError("%v", err)
+		}
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		return nil, newWsError("%v", err)
@@ -120,12 +137,6 @@ func serverStream(c *gin.Context) (any, error) {
 		userIp = c.RemoteIP()
 	}
 
-	u, isMember := c.Get(model.CtxKeyAuthorizedUser)
-	var userId uint64
-	if isMember {
-		userId = u.(*model.User).ID
-	}
-
 	singleton.AddOnlineUser(connId, &model.OnlineUser{
 		UserID:      userId,
 		IP:          userIp,
@@ -136,7 +147,7 @@ func serverStream(c *gin.Context) (any, error) {
 
 	count := 0
 	for {
-		stat, err := getServerStat(count == 0, isMember)
+		stat, err := getServerStat(count == 0, isMember, selector)
 		if err != nil {
 			continue
 		}
@@ -157,8 +168,8 @@ func serverStream(c *gin.Context) (any, error) {
 
 var requestGroup singleflight.Group
 
-func getServerStat(withPublicNote, authorized bool) ([]byte, error) {
-	v, err, _ := requestGroup.Do(fmt.Sprintf("serverStats::%t", authorized), func() (any, error) {
+func getServerStat(withPublicNote, authorized bool, selector string) ([]byte, error) {
+	v, err, _ := requestGroup.Do(fmt.Sprintf("serverStats::%t::%s", authorized, selector), func() (any, error) {
 		var serverList []*model.Server
 		if authorized {
 			serverList = singleton.ServerShared.GetSortedList()
@@ -168,6 +179,9 @@ func getServerStat(withPublicNote, authorized bool) ([]byte, error) {
 
 		servers := make([]model.StreamServer, 0, len(serverList))
 		for _, server := range serverList {
+			if selector != "" && !labels.Match(selector, server.EffectiveLabels()) {
+				continue
+			}
 			var countryCode string
 			if server.GeoIP != nil {
 				countryCode = server.GeoIP.CountryCode
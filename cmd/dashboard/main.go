@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"strings"
 	"time"
 	_ "time/tzdata"
@@ -63,15 +64,33 @@ func initSystem() {
 	// 启动 singleton 包下的所有服务
 	singleton.LoadSingleton()
 
-	// 每天的3:30 对 监控记录 和 流量记录 进行清理
+	// 每天的3:30 对 监控记录、流量记录 和 终端录像 进行清理
 	if _, err := singleton.CronShared.AddFunc("0 30 3 * * *", singleton.CleanServiceHistory); err != nil {
 		panic(err)
 	}
 
 	// 每小时对流量记录进行打点
+	if _, err := singleton.CronShared.AddFunc("0 0 * * * *", singleton.RecordNATHourlyUsage); err != nil {
+		panic(err)
+	}
 	if _, err := singleton.CronShared.AddFunc("0 0 * * * *", singleton.RecordTransferHourlyUsage); err != nil {
 		panic(err)
 	}
+
+	// 每10分钟校验一次 DDNS 记录是否与服务器 IP 一致
+	if _, err := singleton.CronShared.AddFunc("0 */10 * * * *", singleton.DDNSShared.VerifyRecords); err != nil {
+		panic(err)
+	}
+
+	// 每10秒推进 agent 分批升级
+	if _, err := singleton.CronShared.AddFunc("*/10 * * * * *", singleton.AgentRolloutShared.Tick); err != nil {
+		panic(err)
+	}
+
+	// 每30秒检查分组轮询解析的成员是否上下线
+	if _, err := singleton.CronShared.AddFunc("*/30 * * * * *", singleton.DDNSShared.RefreshGroupRecords); err != nil {
+		panic(err)
+	}
 }
 
 // @title           Nezha Monitoring API
@@ -172,6 +191,7 @@ func main() {
 	}, func(c context.Context) error {
 		log.Println("NEZHA>> Graceful::START")
 		singleton.RecordTransferHourlyUsage()
+		singleton.ServiceSentinelShared.SaveDailyStats()
 		log.Println("NEZHA>> Graceful::END")
 		var err error
 		if muxServerHTTPS != nil {
@@ -198,9 +218,35 @@ func newHTTPandGRPCMux(httpHandler http.Handler, grpcHandler http.Handler) http.
 				waf.ShowBlockPage(c, fmt.Errorf("nat host %s is disabled", natConfig.Domain))
 				return
 			}
+			if !controller.CheckNATAccess(w, r, natConfig) {
+				return
+			}
+			release, ok := singleton.NATShared.AcquireStream(natConfig)
+			if !ok {
+				singleton.NATShared.AddLimited(natConfig.ID)
+				c, _ := gin.CreateTestContext(w)
+				waf.ShowBlockPageWithCode(c, http.StatusTooManyRequests, fmt.Errorf("nat host %s has too many active connections", natConfig.Domain))
+				return
+			}
+			defer release()
 			rpc.ServeNAT(w, r, natConfig)
 			return
 		}
+		if page := singleton.StatusPageShared.GetByDomain(r.Host); page != nil {
+			// 状态页自定义域名仅提供状态页及其上传文件
+			switch {
+			case r.URL.Path == "/":
+				r.URL.Path = "/status/" + page.Slug
+			case r.URL.Path == "/api/v1/status":
+				r.URL.Path = "/api/v1/status/" + page.Slug
+			case strings.HasPrefix(r.URL.Path, path.Join("/api/v1", singleton.Conf.LocalPath)+"/"):
+			default:
+				http.NotFound(w, r)
+				return
+			}
+			httpHandler.ServeHTTP(w, r)
+			return
+		}
 		if r.ProtoMajor == 2 && r.Header.Get("Content-Type") == "application/grpc" &&
 			strings.HasPrefix(r.URL.Path, "/"+proto.NezhaService_ServiceDesc.ServiceName) {
 			grpcHandler.ServeHTTP(w, r)
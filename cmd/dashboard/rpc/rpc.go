@@ -3,9 +3,11 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/netip"
+	"sync/atomic"
 	"time"
 
 	"github.com/goccy/go-json"
@@ -81,31 +83,26 @@ func DispatchTask(serviceSentinelDispatchBus <-chan *model.Service) {
 			continue
 		}
 
-		switch task.Cover {
-		case model.ServiceCoverIgnoreAll:
-			for id, enabled := range task.SkipServers {
-				if !enabled {
-					continue
-				}
-
-				server, _ := singleton.ServerShared.Get(id)
-				if server == nil || server.TaskStream == nil {
-					continue
-				}
-
-				if canSendTaskToServer(task, server) {
-					server.TaskStream.Send(task.PB())
-				}
+		// 由面板执行的监控任务不下发给 agent，非管理员的任务只能探测公网地址
+		if task.RunsOnDashboard() {
+			singleton.ProbeShared.Submit(task)
+			continue
+		}
+
+		for id, server := range singleton.ServerShared.Range {
+			if server == nil || server.TaskStream == nil {
+				continue
 			}
-		case model.ServiceCoverAll:
-			for id, server := range singleton.ServerShared.Range {
-				if server == nil || server.TaskStream == nil || task.SkipServers[id] {
-					continue
-				}
-
-				if canSendTaskToServer(task, server) {
-					server.TaskStream.Send(task.PB())
-				}
+
+			// 仅覆盖列出的服务器时跳过未列出的，覆盖全部时跳过列出的
+			listed := task.SkipServers[id] || singleton.ServerGroupShared.Contains(task.SkipServerGroups, id) ||
+				server.MatchesSelector(task.SkipServerSelector)
+			if listed != (task.Cover == model.ServiceCoverIgnoreAll) {
+				continue
+			}
+
+			if canSendTaskToServer(task, server) {
+				server.TaskStream.Send(task.PB())
 			}
 		}
 	}
@@ -126,6 +123,7 @@ func DispatchKeepalive() {
 func ServeNAT(w http.ResponseWriter, r *http.Request, natConfig *model.NAT) {
 	server, _ := singleton.ServerShared.Get(natConfig.ServerID)
 	if server == nil || server.TaskStream == nil {
+		singleton.NATShared.AddError(natConfig.ID)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write([]byte("server not found or not connected"))
 		return
@@ -133,6 +131,7 @@ func ServeNAT(w http.ResponseWriter, r *http.Request, natConfig *model.NAT) {
 
 	streamId, err := uuid.GenerateUUID()
 	if err != nil {
+		singleton.NATShared.AddError(natConfig.ID)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write(fmt.Appendf(nil, "stream id error: %v", err))
 		return
@@ -146,6 +145,7 @@ func ServeNAT(w http.ResponseWriter, r *http.Request, natConfig *model.NAT) {
 		Host:     natConfig.Host,
 	})
 	if err != nil {
+		singleton.NATShared.AddError(natConfig.ID)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write(fmt.Appendf(nil, "task data error: %v", err))
 		return
@@ -155,6 +155,7 @@ func ServeNAT(w http.ResponseWriter, r *http.Request, natConfig *model.NAT) {
 		Type: model.TaskTypeNAT,
 		Data: string(taskData),
 	}); err != nil {
+		singleton.NATShared.AddError(natConfig.ID)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write(fmt.Appendf(nil, "send task error: %v", err))
 		return
@@ -162,18 +163,48 @@ func ServeNAT(w http.ResponseWriter, r *http.Request, natConfig *model.NAT) {
 
 	wWrapped, err := utils.NewRequestWrapper(r, w)
 	if err != nil {
+		singleton.NATShared.AddError(natConfig.ID)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write(fmt.Appendf(nil, "request wrapper error: %v", err))
 		return
 	}
 
-	if err := rpcService.NezhaHandlerSingleton.UserConnected(streamId, wWrapped); err != nil {
+	counter := &natTrafficCounter{ReadWriteCloser: wWrapped, natID: natConfig.ID}
+	if err := rpcService.NezhaHandlerSingleton.UserConnected(streamId, counter); err != nil {
+		singleton.NATShared.AddError(natConfig.ID)
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write(fmt.Appendf(nil, "user connected error: %v", err))
 		return
 	}
 
-	rpcService.NezhaHandlerSingleton.StartStream(streamId, time.Second*10)
+	// 连接断开也会返回错误，仅统计没有返回任何数据的请求
+	if err := rpcService.NezhaHandlerSingleton.StartStream(streamId, time.Second*10); err != nil && !counter.wrote.Load() {
+		singleton.NATShared.AddError(natConfig.ID)
+	}
+}
+
+// natTrafficCounter 统计内网穿透转发的字节数
+type natTrafficCounter struct {
+	io.ReadWriteCloser
+	natID uint64
+	wrote atomic.Bool
+}
+
+func (t *natTrafficCounter) Read(p []byte) (int, error) {
+	n, err := t.ReadWriteCloser.Read(p)
+	if n > 0 {
+		singleton.NATShared.AddTraffic(t.natID, uint64(n), 0)
+	}
+	return n, err
+}
+
+func (t *natTrafficCounter) Write(p []byte) (int, error) {
+	n, err := t.ReadWriteCloser.Write(p)
+	if n > 0 {
+		singleton.NATShared.AddTraffic(t.natID, 0, uint64(n))
+		t.wrote.Store(true)
+	}
+	return n, err
 }
 
 func canSendTaskToServer(task *model.Service, server *model.Server) bool {
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// Agent 分批升级的状态
+const (
+	AgentRolloutRunning = iota
+	AgentRolloutPaused
+	AgentRolloutCompleted
+	AgentRolloutCancelled
+)
+
+// 单台服务器的升级状态
+const (
+	AgentUpgradePending   = iota // 等待所在批次下发
+	AgentUpgradeSent             // 已下发，等待以新版本重新连接
+	AgentUpgradeSucceeded        // 已以最新版本重新连接
+	AgentUpgradeFailed           // 下发失败或超时未以新版本重新连接
+	AgentUpgradeSkipped          // 已是最新版本、离线、已删除或升级被取消
+)
+
+// AgentRollout 将 agent 分批升级到最新版本，首批为金丝雀，之后按批次间隔依次下发，
+// 失败数量超过 MaxFailures 时自动暂停。升级任务不携带版本，agent 自行更新到最新发布的版本
+type AgentRollout struct {
+	Common
+	Name             string     `json:"name"`
+	LatestVersion    string     `json:"latest_version"`    // 当前最新发布的 agent 版本，已是该版本的服务器不再下发
+	CanaryPercent    uint8      `json:"canary_percent"`    // 首批（金丝雀）占全部服务器的百分比，至少 1 台
+	BatchSize        uint64     `json:"batch_size"`        // 之后每批的服务器数量
+	BatchDelay       uint64     `json:"batch_delay"`       // 上一批全部结束后等待的秒数
+	ReconnectTimeout uint64     `json:"reconnect_timeout"` // 下发升级后等待以新版本重新连接的秒数
+	MaxFailures      uint64     `json:"max_failures"`      // 允许失败的数量，超出时自动暂停
+	Status           uint8      `json:"status"`            // 0:进行中 1:已暂停 2:已完成 3:已取消
+	PauseReason      string     `json:"pause_reason,omitempty"`
+	Failures         uint64     `json:"failures"`         // 上次开始或恢复以来失败的数量
+	DispatchedWaves  int        `json:"dispatched_waves"` // 已下发的批次数，第 0 批为金丝雀
+	WaveFinishedAt   *time.Time `json:"wave_finished_at,omitempty"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+
+	Servers    []uint64 `gorm:"-" json:"servers"`
+	ServersRaw string   `gorm:"type:text" json:"-"`
+}
+
+func (r *AgentRollout) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(r.Servers)
+	if err != nil {
+		return err
+	}
+	r.ServersRaw = string(data)
+	return nil
+}
+
+func (r *AgentRollout) AfterFind(tx *gorm.DB) error {
+	if r.ServersRaw != "" {
+		return json.Unmarshal([]byte(r.ServersRaw), &r.Servers)
+	}
+	return nil
+}
+
+// Waves 按金丝雀比例与批次大小为 n 台服务器分配批次，返回每台服务器所在的批次
+func (r *AgentRollout) Waves(n int) []int {
+	canary := (n*int(r.CanaryPercent) + 99) / 100
+	canary = max(min(canary, n), 1)
+	batch := max(int(r.BatchSize), 1)
+
+	waves := make([]int, n)
+	for i := canary; i < n; i++ {
+		waves[i] = 1 + (i-canary)/batch
+	}
+	return waves
+}
+
+// VersionReached 判断 agent 上报的版本是否已是最新版本
+func (r *AgentRollout) VersionReached(reported string) bool {
+	return reported != "" && SameAgentVersion(r.LatestVersion, reported)
+}
+
+// SameAgentVersion 忽略 v 前缀比较版本号
+func SameAgentVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// AgentRolloutTarget 分批升级中一台服务器的状态
+type AgentRolloutTarget struct {
+	ID          uint64     `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt   time.Time  `gorm:"<-:create" json:"created_at,omitempty"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at,omitempty"`
+	RolloutID   uint64     `gorm:"index" json:"rollout_id"`
+	ServerID    uint64     `json:"server_id"`
+	ServerName  string     `json:"server_name"`
+	Wave        int        `json:"wave"`
+	Status      uint8      `gorm:"index" json:"status"` // 0:等待 1:已下发 2:成功 3:失败 4:跳过
+	FromVersion string     `json:"from_version,omitempty"`
+	ToVersion   string     `json:"to_version,omitempty"` // 升级后上报的版本
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// AgentRolloutProgress 分批升级的进度
+type AgentRolloutProgress struct {
+	*AgentRollout
+	Waves     int                   `json:"waves"` // 总批次数
+	Total     int                   `json:"total"`
+	Pending   int                   `json:"pending"`
+	Sent      int                   `json:"sent"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Skipped   int                   `json:"skipped"`
+	Targets   []*AgentRolloutTarget `json:"targets"`
+}
+
+// AgentVersionCount 某个 agent 版本的服务器分布
+type AgentVersionCount struct {
+	Version string   `json:"version"` // 未上报版本的服务器为空
+	Count   int      `json:"count"`
+	Servers []uint64 `json:"servers"`
+}
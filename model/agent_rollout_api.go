@@ -0,0 +1,14 @@
+package model
+
+type AgentRolloutForm struct {
+	Name             string   `json:"name,omitempty" minLength:"1"`
+	LatestVersion    string   `json:"latest_version,omitempty" minLength:"1"` // 当前最新发布的 agent 版本
+	Servers          []uint64 `json:"servers,omitempty" validate:"optional"`
+	ServerGroups     []uint64 `json:"server_groups,omitempty" validate:"optional"`
+	ServerSelector   string   `json:"server_selector,omitempty" validate:"optional"`
+	CanaryPercent    uint8    `json:"canary_percent,omitempty" validate:"optional"`    // 默认 10
+	BatchSize        uint64   `json:"batch_size,omitempty" validate:"optional"`        // 默认 10
+	BatchDelay       uint64   `json:"batch_delay,omitempty" validate:"optional"`       // 秒，默认 300
+	ReconnectTimeout uint64   `json:"reconnect_timeout,omitempty" validate:"optional"` // 秒，默认 300
+	MaxFailures      uint64   `json:"max_failures,omitempty" validate:"optional"`
+}
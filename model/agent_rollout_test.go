@@ -0,0 +1,32 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAgentRolloutWaves(t *testing.T) {
+	cases := []struct {
+		rollout AgentRollout
+		n       int
+		want    []int
+	}{
+		{AgentRollout{CanaryPercent: 10, BatchSize: 3}, 10, []int{0, 1, 1, 1, 2, 2, 2, 3, 3, 3}},
+		{AgentRollout{CanaryPercent: 25, BatchSize: 4}, 8, []int{0, 0, 1, 1, 1, 1, 2, 2}},
+		{AgentRollout{CanaryPercent: 0, BatchSize: 0}, 3, []int{0, 1, 2}},
+		{AgentRollout{CanaryPercent: 100, BatchSize: 5}, 3, []int{0, 0, 0}},
+		{AgentRollout{CanaryPercent: 10, BatchSize: 5}, 0, []int{}},
+	}
+	for i, c := range cases {
+		if got := c.rollout.Waves(c.n); !slices.Equal(got, c.want) {
+			t.Errorf("case %d: Waves(%d) = %v, want %v", i, c.n, got, c.want)
+		}
+	}
+}
+
+func TestAgentRolloutVersionReached(t *testing.T) {
+	r := &AgentRollout{LatestVersion: "v1.2.0"}
+	if !r.VersionReached("1.2.0") || r.VersionReached("1.1.0") || r.VersionReached("") {
+		t.Fatal("latest version mismatch")
+	}
+}
@@ -24,6 +24,11 @@ type AlertRule struct {
 	Rules                  []*Rule  `gorm:"-" json:"rules"`
 	FailTriggerTasks       []uint64 `gorm:"-" json:"fail_trigger_tasks"`    // 失败时执行的触发任务id
 	RecoverTriggerTasks    []uint64 `gorm:"-" json:"recover_trigger_tasks"` // 恢复时执行的触发任务id
+
+	FailTriggerWorkflowsRaw    string   `gorm:"default:'[]'" json:"-"`
+	RecoverTriggerWorkflowsRaw string   `gorm:"default:'[]'" json:"-"`
+	FailTriggerWorkflows       []uint64 `gorm:"-" json:"fail_trigger_workflows"`    // 失败时执行的工作流id
+	RecoverTriggerWorkflows    []uint64 `gorm:"-" json:"recover_trigger_workflows"` // 恢复时执行的工作流id
 }
 
 func (r *AlertRule) BeforeSave(tx *gorm.DB) error {
@@ -42,6 +47,16 @@ func (r *AlertRule) BeforeSave(tx *gorm.DB) error {
 	} else {
 		r.RecoverTriggerTasksRaw = string(data)
 	}
+	if data, err := json.Marshal(r.FailTriggerWorkflows); err != nil {
+		return err
+	} else {
+		r.FailTriggerWorkflowsRaw = string(data)
+	}
+	if data, err := json.Marshal(r.RecoverTriggerWorkflows); err != nil {
+		return err
+	} else {
+		r.RecoverTriggerWorkflowsRaw = string(data)
+	}
 	return nil
 }
 
@@ -56,6 +71,16 @@ func (r *AlertRule) AfterFind(tx *gorm.DB) error {
 	if err = json.Unmarshal([]byte(r.RecoverTriggerTasksRaw), &r.RecoverTriggerTasks); err != nil {
 		return err
 	}
+	if r.FailTriggerWorkflowsRaw != "" {
+		if err = json.Unmarshal([]byte(r.FailTriggerWorkflowsRaw), &r.FailTriggerWorkflows); err != nil {
+			return err
+		}
+	}
+	if r.RecoverTriggerWorkflowsRaw != "" {
+		if err = json.Unmarshal([]byte(r.RecoverTriggerWorkflowsRaw), &r.RecoverTriggerWorkflows); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -64,11 +89,11 @@ func (r *AlertRule) Enabled() bool {
 }
 
 // Snapshot 对传入的Server进行该报警规则下所有type的检查 返回每项检查结果
-func (r *AlertRule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server, db *gorm.DB) []bool {
+func (r *AlertRule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server, db *gorm.DB, inGroups func([]uint64, uint64) bool) []bool {
 	point := make([]bool, len(r.Rules))
 
 	for i, rule := range r.Rules {
-		point[i] = rule.Snapshot(cycleTransferStats, server, db)
+		point[i] = rule.Snapshot(cycleTransferStats, server, db, inGroups)
 	}
 	return point
 }
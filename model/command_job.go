@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// CommandJobTaskFlag 批量命令下发给 agent 的任务 ID 带有此标记，以便与计划任务区分
+const CommandJobTaskFlag uint64 = 1 << 63
+
+// 批量命令执行状态
+const (
+	CommandJobRunning = iota
+	CommandJobFinished
+)
+
+// CommandJob 在多台服务器上执行的一次临时命令
+type CommandJob struct {
+	Common
+	Command    string     `gorm:"type:text" json:"command"`
+	Timeout    uint64     `json:"timeout"` // 等待结果的秒数
+	Status     uint8      `json:"status"`  // 0:执行中 1:已结束
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	Servers    []uint64 `gorm:"-" json:"servers"`
+	ServersRaw string   `gorm:"type:text" json:"-"`
+}
+
+func (j *CommandJob) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(j.Servers)
+	if err != nil {
+		return err
+	}
+	j.ServersRaw = string(data)
+	return nil
+}
+
+func (j *CommandJob) AfterFind(tx *gorm.DB) error {
+	if j.ServersRaw != "" {
+		return json.Unmarshal([]byte(j.ServersRaw), &j.Servers)
+	}
+	return nil
+}
+
+// CommandJobResult 批量命令在单台服务器上的执行结果，状态与计划任务执行记录相同
+type CommandJobResult struct {
+	ID         uint64     `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt  time.Time  `gorm:"index;<-:create" json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at,omitempty"`
+	JobID      uint64     `gorm:"index" json:"job_id"`
+	ServerID   uint64     `json:"server_id"`
+	ServerName string     `json:"server_name"`
+	Status     uint8      `json:"status"` // 0:已下发 1:成功 2:失败 3:服务器离线 4:超时
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Output     string     `gorm:"type:mediumtext" json:"output,omitempty"`
+}
+
+// CommandJobDetail 批量命令及各服务器的执行结果
+type CommandJobDetail struct {
+	*CommandJob
+	Results []*CommandJobResult `json:"results"`
+}
+
+// SetOutput 保存命令输出，超出长度限制时保留末尾部分
+func (r *CommandJobResult) SetOutput(output string) {
+	r.Output = truncateOutput(output)
+}
+
+// Finished 判断执行是否已结束
+func (r *CommandJobResult) Finished() bool {
+	return r.Status != CronExecutionDispatched
+}
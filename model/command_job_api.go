@@ -0,0 +1,8 @@
+package model
+
+type CommandJobForm struct {
+	Command      string   `json:"command,omitempty" minLength:"1"`
+	Servers      []uint64 `json:"servers,omitempty" validate:"optional"`
+	ServerGroups []uint64 `json:"server_groups,omitempty" validate:"optional"`
+	Timeout      uint64   `json:"timeout,omitempty" validate:"optional"` // 等待结果的秒数，默认 300
+}
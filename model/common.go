@@ -18,6 +18,11 @@ const (
 
 const (
 	CacheKeyOauth2State = "cko2s::"
+	CacheKeyStatusPage  = "cksp::"
+	CacheKeyTerminal    = "ckt::"
+	CacheKeyFM          = "ckfm::"
+	CacheKeyNATTicket   = "cknt::"
+	CacheKeyNATSession  = "ckns::"
 )
 
 type CtxKeyRealIP struct{}
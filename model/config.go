@@ -44,7 +44,21 @@ type ConfigDashboard struct {
 	Cover                       uint8  `koanf:"cover" json:"cover"`                                               // 覆盖范围（0:提醒未被 IgnoredIPNotification 包含的所有服务器; 1:仅提醒被 IgnoredIPNotification 包含的服务器;）
 	IgnoredIPNotification       string `koanf:"ignored_ip_notification" json:"ignored_ip_notification,omitempty"` // 特定服务器IP（多个服务器用逗号分隔）
 
+	// 主机信息（系统版本、agent 版本、硬件）变更提醒
+	EnableHostChangeNotification  bool   `koanf:"enable_host_change_notification" json:"enable_host_change_notification,omitempty"`
+	HostChangeNotificationGroupID uint64 `koanf:"host_change_notification_group_id" json:"host_change_notification_group_id"`
+
+	// 未知 agent 需管理员审核后才能注册
+	RequireAgentApproval bool `koanf:"require_agent_approval" json:"require_agent_approval,omitempty"`
+
 	DNSServers string `koanf:"dns_servers" json:"dns_servers,omitempty"`
+
+	// 终端录像
+	EnableTerminalRecording bool `koanf:"enable_terminal_recording" json:"enable_terminal_recording,omitempty"`
+	RecordTerminalInput     bool `koanf:"record_terminal_input" json:"record_terminal_input,omitempty"` // 同时记录输入，可能包含密码等敏感信息
+
+	// 文件管理单次上传或下载的最大字节数，0 表示不限制，用户可单独设置
+	FMMaxTransferSize int64 `koanf:"fm_max_transfer_size" json:"fm_max_transfer_size,omitempty"`
 }
 
 type Config struct {
@@ -52,6 +66,10 @@ type Config struct {
 	ConfigDashboard
 
 	AvgPingCount int `koanf:"avg_ping_count" json:"avg_ping_count,omitempty"`
+	ProbeWorkers int `koanf:"probe_workers" json:"probe_workers,omitempty"` // 面板执行服务监控的并发数
+
+	// 数据保留配置
+	Retention RetentionConfig `koanf:"retention" json:"retention"`
 
 	Debug          bool   `koanf:"debug" json:"debug,omitempty"`           // debug模式开关
 	Location       string `koanf:"location" json:"location,omitempty"`     // 时区，默认为 Asia/Shanghai
@@ -63,6 +81,9 @@ type Config struct {
 	ListenPort   uint16 `koanf:"listen_port" json:"listen_port,omitempty"`
 	ListenHost   string `koanf:"listen_host" json:"listen_host,omitempty"`
 
+	// 密钥库的加密密钥，修改后已保存的密钥将无法解密
+	SecretEncryptionKey string `koanf:"secret_encryption_key" json:"secret_encryption_key,omitempty"`
+
 	// oauth2 配置
 	Oauth2 map[string]*Oauth2Config `koanf:"oauth2" json:"oauth2,omitempty"`
 
@@ -72,10 +93,25 @@ type Config struct {
 	OssType   string `koanf:"oss_type" json:"oss_type,omitempty"`
 	LocalPath string `koanf:"local_path" json:"local_path,omitempty"`
 
+	// 终端录像目录，不能位于 LocalPath 下，LocalPath 无需认证即可访问
+	TerminalRecordingPath string `koanf:"terminal_recording_path" json:"terminal_recording_path,omitempty"`
+
 	k        *koanf.Koanf `json:"-"`
 	filePath string       `json:"-"`
 }
 
+// RetentionConfig 各类数据的保留天数，0 表示不按时间清理
+type RetentionConfig struct {
+	ServiceHistoryDays    int `koanf:"service_history_days" json:"service_history_days"`       // 服务监控汇总记录（server_id = 0），默认 30 天
+	PingHistoryDays       int `koanf:"ping_history_days" json:"ping_history_days"`             // 单服务器延迟记录，默认 1 天
+	TransferDays          int `koanf:"transfer_days" json:"transfer_days"`                     // 流量记录的最少保留天数，0 表示仅按报警规则计算
+	AuditLogDays          int `koanf:"audit_log_days" json:"audit_log_days"`                   // 审计日志，默认 180 天
+	MessageDays           int `koanf:"message_days" json:"message_days"`                       // 站内消息，默认 90 天
+	CronExecutionDays     int `koanf:"cron_execution_days" json:"cron_execution_days"`         // 计划任务、工作流与批量命令执行记录，默认 30 天
+	TerminalRecordingDays int `koanf:"terminal_recording_days" json:"terminal_recording_days"` // 终端录像，默认 180 天
+	NATTransferDays       int `koanf:"nat_transfer_days" json:"nat_transfer_days"`             // 内网穿透每小时流量记录，默认 90 天
+}
+
 type HTTPSConf struct {
 	InsecureTLS bool   `koanf:"insecure_tls" json:"insecure_tls,omitempty"`
 	ListenPort  uint16 `koanf:"listen_port" json:"listen_port,omitempty"`
@@ -137,6 +173,26 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 	if c.AvgPingCount == 0 {
 		c.AvgPingCount = 2
 	}
+	if c.ProbeWorkers == 0 {
+		c.ProbeWorkers = 8
+	}
+	// 未配置的保留天数使用默认值，显式配置为 0 时表示不按时间清理
+	for key, def := range map[string]struct {
+		value *int
+		days  int
+	}{
+		"retention.service_history_days":    {&c.Retention.ServiceHistoryDays, 30},
+		"retention.ping_history_days":       {&c.Retention.PingHistoryDays, 1},
+		"retention.audit_log_days":          {&c.Retention.AuditLogDays, 180},
+		"retention.message_days":            {&c.Retention.MessageDays, 90},
+		"retention.cron_execution_days":     {&c.Retention.CronExecutionDays, 30},
+		"retention.terminal_recording_days": {&c.Retention.TerminalRecordingDays, 180},
+		"retention.nat_transfer_days":       {&c.Retention.NATTransferDays, 90},
+	} {
+		if !c.k.Exists(key) {
+			*def.value = def.days
+		}
+	}
 	if c.Cover == 0 {
 		c.Cover = 1
 	}
@@ -165,12 +221,26 @@ func (c *Config) Read(path string, frontendTemplates []FrontendTemplate) error {
 		}
 	}
 
+	if c.SecretEncryptionKey == "" {
+		c.SecretEncryptionKey, err = utils.GenerateRandomString(32)
+		if err != nil {
+			return err
+		}
+		if err = c.Save(); err != nil {
+			return err
+		}
+	}
+
 	if c.OssType == "" {
 		c.OssType = "local"
 	}
 
 	if c.LocalPath == "" {
-		c.OssType = "uploads/file"
+		c.LocalPath = "uploads/file"
+	}
+
+	if c.TerminalRecordingPath == "" {
+		c.TerminalRecordingPath = "data/terminal-recordings"
 	}
 
 	return nil
@@ -16,6 +16,20 @@ const (
 	CronTypeTriggerTask = 1
 )
 
+// 同一服务器上任务仍在执行时的处理策略
+const (
+	CronConcurrencyAllow = iota // 允许同时执行
+	CronConcurrencySkip         // 跳过本次执行
+	CronConcurrencyQueue        // 排队等待上次执行完成
+)
+
+// CronParameter 计划任务命令中可使用的参数，手动执行时可覆盖默认值
+type CronParameter struct {
+	Name        string `json:"name"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
 type Cron struct {
 	Common
 	Name                string    `json:"name"`
@@ -28,9 +42,21 @@ type Cron struct {
 	LastExecutedAt      time.Time `json:"last_executed_at,omitempty"` // 最后一次执行时间
 	LastResult          bool      `json:"last_result,omitempty"`      // 最后一次执行结果
 	Cover               uint8     `json:"cover"`                      // 计划任务覆盖范围 (0:仅覆盖特定服务器 1:仅忽略特定服务器 2:由触发该计划任务的服务器执行)
+	Timeout             uint64    `json:"timeout,omitempty"`          // 最长执行时间（秒），超时未收到结果视为失败，0 为不限制
+	ConcurrencyPolicy   uint8     `json:"concurrency_policy"`         // 0:允许同时执行 1:跳过 2:排队
+	Jitter              uint64    `json:"jitter,omitempty"`           // 下发前的随机延迟上限（秒），用于错开大量服务器同时执行
+
+	Parameters    []CronParameter `gorm:"-" json:"parameters,omitempty"`
+	ParametersRaw string          `gorm:"type:text" json:"-"`
 
 	CronJobID  cron.EntryID `gorm:"-" json:"cron_job_id,omitempty"`
 	ServersRaw string       `json:"-"`
+
+	// 与 Servers 含义相同，分组成员在执行时动态解析
+	ServerGroups    []uint64 `gorm:"-" json:"server_groups"`
+	ServerGroupsRaw string   `gorm:"default:'[]'" json:"-"`
+	// 与 Servers 含义相同的标签选择器
+	ServerSelector string `json:"server_selector,omitempty"`
 }
 
 func (c *Cron) BeforeSave(tx *gorm.DB) error {
@@ -39,9 +65,43 @@ func (c *Cron) BeforeSave(tx *gorm.DB) error {
 	} else {
 		c.ServersRaw = string(data)
 	}
+	if data, err := json.Marshal(c.ServerGroups); err != nil {
+		return err
+	} else {
+		c.ServerGroupsRaw = string(data)
+	}
+	if data, err := json.Marshal(c.Parameters); err != nil {
+		return err
+	} else {
+		c.ParametersRaw = string(data)
+	}
 	return nil
 }
 
 func (c *Cron) AfterFind(tx *gorm.DB) error {
-	return json.Unmarshal([]byte(c.ServersRaw), &c.Servers)
+	if err := json.Unmarshal([]byte(c.ServersRaw), &c.Servers); err != nil {
+		return err
+	}
+	if c.ServerGroupsRaw != "" {
+		if err := json.Unmarshal([]byte(c.ServerGroupsRaw), &c.ServerGroups); err != nil {
+			return err
+		}
+	}
+	if c.ParametersRaw != "" {
+		return json.Unmarshal([]byte(c.ParametersRaw), &c.Parameters)
+	}
+	return nil
+}
+
+// Parameter 返回参数的值，优先使用 overrides 中的值
+func (c *Cron) Parameter(name string, overrides map[string]string) (string, bool) {
+	for _, p := range c.Parameters {
+		if p.Name == name {
+			if v, ok := overrides[name]; ok {
+				return v, true
+			}
+			return p.Default, true
+		}
+	}
+	return "", false
 }
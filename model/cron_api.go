@@ -1,12 +1,42 @@
 package model
 
+import "time"
+
 type CronForm struct {
 	TaskType            uint8    `json:"task_type,omitempty" default:"0"` // 0:计划任务 1:触发任务
 	Name                string   `json:"name,omitempty" minLength:"1"`
 	Scheduler           string   `json:"scheduler,omitempty"`
 	Command             string   `json:"command,omitempty" validate:"optional"`
 	Servers             []uint64 `json:"servers,omitempty"`
+	ServerGroups        []uint64 `json:"server_groups,omitempty" validate:"optional"`
+	ServerSelector      string   `json:"server_selector,omitempty" validate:"optional"`
 	Cover               uint8    `json:"cover,omitempty" default:"0"`
 	PushSuccessful      bool     `json:"push_successful,omitempty" validate:"optional"`
 	NotificationGroupID uint64   `json:"notification_group_id,omitempty"`
+	Timeout             uint64   `json:"timeout,omitempty" validate:"optional"`
+	ConcurrencyPolicy   uint8    `json:"concurrency_policy,omitempty" validate:"optional"`
+	Jitter              uint64   `json:"jitter,omitempty" validate:"optional"`
+
+	Parameters []CronParameter `json:"parameters,omitempty" validate:"optional"`
+}
+
+type CronTriggerForm struct {
+	Parameters map[string]string `json:"parameters,omitempty" validate:"optional"` // 覆盖参数的默认值
+}
+
+type CronSchedulePreview struct {
+	Description string      `json:"description"`
+	Next        []time.Time `json:"next"`
+}
+
+type CronCalendarItem struct {
+	Time       time.Time `json:"time"`
+	CronID     uint64    `json:"cron_id,omitempty"`
+	WorkflowID uint64    `json:"workflow_id,omitempty"`
+	Name       string    `json:"name"`
+}
+
+type CronCalendarResponse struct {
+	Items     []CronCalendarItem `json:"items"`
+	Truncated bool               `json:"truncated,omitempty"` // 超出数量限制，仅返回了部分执行时间
 }
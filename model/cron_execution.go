@@ -0,0 +1,92 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// 计划任务触发来源
+const (
+	CronTriggerSchedule = iota
+	CronTriggerManual
+	CronTriggerAlert
+	CronTriggerService
+	CronTriggerWorkflow
+)
+
+// 计划任务执行状态
+const (
+	CronExecutionDispatched = iota // 已下发，等待结果
+	CronExecutionSucceeded
+	CronExecutionFailed
+	CronExecutionOffline // 服务器离线，未能下发
+	CronExecutionTimeout // 超时未收到结果
+	CronExecutionSkipped // 上次执行未完成，已跳过
+	CronExecutionQueued  // 等待上次执行完成
+)
+
+// CronExecutionTaskFlag 计划任务下发给 agent 的任务 ID 带有此标记，其余位为执行记录 ID，
+// agent 原样返回任务 ID，据此将结果对应到具体的一次执行
+const CronExecutionTaskFlag uint64 = 1 << 62
+
+// CronExecutionOutputLimit 保存的执行输出最大长度
+const CronExecutionOutputLimit = 64 * 1024
+
+// CronExecution 计划任务在单台服务器上的一次执行记录
+type CronExecution struct {
+	ID            uint64     `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt     time.Time  `gorm:"index;<-:create" json:"created_at,omitempty"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at,omitempty"`
+	CronID        uint64     `gorm:"index" json:"cron_id"`
+	ServerID      uint64     `gorm:"index" json:"server_id"`
+	Trigger       uint8      `json:"trigger"`                                // 0:计划 1:手动 2:报警规则 3:服务监控 4:工作流
+	Status        uint8      `json:"status"`                                 // 0:已下发 1:成功 2:失败 3:服务器离线 4:超时 5:已跳过 6:排队中
+	WorkflowRunID uint64     `gorm:"index" json:"workflow_run_id,omitempty"` // 由工作流下发时对应的工作流执行记录
+	DispatchedAt  time.Time  `json:"dispatched_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	Output        string     `gorm:"type:mediumtext" json:"output,omitempty"` // 截断后的命令输出
+
+	Parameters    map[string]string `gorm:"-" json:"parameters,omitempty"` // 手动执行时覆盖的参数
+	ParametersRaw string            `gorm:"type:text" json:"-"`
+}
+
+func (e *CronExecution) BeforeSave(tx *gorm.DB) error {
+	if len(e.Parameters) == 0 {
+		e.ParametersRaw = ""
+		return nil
+	}
+	data, err := json.Marshal(e.Parameters)
+	if err != nil {
+		return err
+	}
+	e.ParametersRaw = string(data)
+	return nil
+}
+
+func (e *CronExecution) AfterFind(tx *gorm.DB) error {
+	if e.ParametersRaw != "" {
+		return json.Unmarshal([]byte(e.ParametersRaw), &e.Parameters)
+	}
+	return nil
+}
+
+// Finished 判断执行是否已结束
+func (e *CronExecution) Finished() bool {
+	return e.Status != CronExecutionDispatched && e.Status != CronExecutionQueued
+}
+
+// SetOutput 保存命令输出，超出长度限制时保留末尾部分
+func (e *CronExecution) SetOutput(output string) {
+	e.Output = truncateOutput(output)
+}
+
+func truncateOutput(output string) string {
+	if len(output) > CronExecutionOutputLimit {
+		output = strings.ToValidUTF8("...(truncated)\n"+output[len(output)-CronExecutionOutputLimit:], "")
+	}
+	return output
+}
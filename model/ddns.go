@@ -1,8 +1,13 @@
 package model
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/goccy/go-json"
 	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/pkg/utils"
 )
 
 const (
@@ -10,17 +15,21 @@ const (
 	ProviderWebHook      = "webhook"
 	ProviderCloudflare   = "cloudflare"
 	ProviderTencentCloud = "tencentcloud"
+	ProviderRFC2136      = "rfc2136"
+	ProviderAliDNS       = "alidns"
+	ProviderGoDaddy      = "godaddy"
+	ProviderGandi        = "gandi"
+	ProviderDuckDNS      = "duckdns"
 )
 
-var ProviderList = []string{
-	ProviderDummy, ProviderWebHook, ProviderCloudflare, ProviderTencentCloud,
-}
-
 type DDNSProfile struct {
 	Common
 	EnableIPv4         *bool    `json:"enable_ipv4,omitempty"`
 	EnableIPv6         *bool    `json:"enable_ipv6,omitempty"`
 	MaxRetries         uint64   `json:"max_retries"`
+	RepushOnDrift      bool     `json:"repush_on_drift,omitempty"` // 校验发现解析漂移时重新推送
+	TTL                uint32   `json:"ttl,omitempty"`             // 记录的 TTL（秒），0 为默认的 60 秒
+	ServerGroupID      uint64   `json:"server_group_id,omitempty"` // 不为 0 时以轮询记录集发布该分组内所有在线服务器的 IP
 	Name               string   `json:"name"`
 	Provider           string   `json:"provider"`
 	AccessID           string   `json:"access_id,omitempty"`
@@ -32,21 +41,77 @@ type DDNSProfile struct {
 	WebhookHeaders     string   `json:"webhook_headers,omitempty"`
 	Domains            []string `json:"domains" gorm:"-"`
 	DomainsRaw         string   `json:"-"`
+
+	// 自动应用到这些分组内的服务器，与服务器上配置的 DDNS 合并
+	ServerGroups    []uint64 `json:"server_groups,omitempty" gorm:"-"`
+	ServerGroupsRaw string   `gorm:"default:'[]'" json:"-"`
+
+	// 提供者的其余参数，字段名见 /ddns/providers，加密后保存在 ConfigRaw 中
+	Config    map[string]string `json:"config,omitempty" gorm:"-"`
+	ConfigRaw string            `json:"-"`
 }
 
 func (d DDNSProfile) TableName() string {
 	return "ddns"
 }
 
+// Proxied 记录是否经过 Cloudflare 代理，代理记录解析到的是边缘节点 IP
+func (d *DDNSProfile) Proxied() bool {
+	proxied, _ := strconv.ParseBool(d.Config["proxied"])
+	return d.Provider == ProviderCloudflare && proxied
+}
+
 func (d *DDNSProfile) BeforeSave(tx *gorm.DB) error {
 	if data, err := json.Marshal(d.Domains); err != nil {
 		return err
 	} else {
 		d.DomainsRaw = string(data)
 	}
+	if data, err := json.Marshal(d.ServerGroups); err != nil {
+		return err
+	} else {
+		d.ServerGroupsRaw = string(data)
+	}
 	return nil
 }
 
 func (d *DDNSProfile) AfterFind(tx *gorm.DB) error {
-	return json.Unmarshal([]byte(d.DomainsRaw), &d.Domains)
+	if err := json.Unmarshal([]byte(d.DomainsRaw), &d.Domains); err != nil {
+		return err
+	}
+	if d.ServerGroupsRaw != "" {
+		if err := json.Unmarshal([]byte(d.ServerGroupsRaw), &d.ServerGroups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncryptConfig 使用密钥库的加密密钥加密 Config 并写入 ConfigRaw，保存前调用
+func (d *DDNSProfile) EncryptConfig(key string) error {
+	if len(d.Config) == 0 {
+		d.ConfigRaw = ""
+		return nil
+	}
+	data, err := json.Marshal(d.Config)
+	if err != nil {
+		return err
+	}
+	d.ConfigRaw, err = utils.Encrypt(key, data)
+	return err
+}
+
+// DecryptConfig 从 ConfigRaw 解密出 Config，兼容未加密保存的旧数据
+func (d *DDNSProfile) DecryptConfig(key string) error {
+	if d.ConfigRaw == "" {
+		return nil
+	}
+	data := []byte(d.ConfigRaw)
+	if !strings.HasPrefix(d.ConfigRaw, "{") {
+		var err error
+		if data, err = utils.Decrypt(key, d.ConfigRaw); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, &d.Config)
 }
@@ -4,6 +4,10 @@ type DDNSForm struct {
 	MaxRetries         uint64   `json:"max_retries,omitempty" default:"3"`
 	EnableIPv4         bool     `json:"enable_ipv4,omitempty" validate:"optional"`
 	EnableIPv6         bool     `json:"enable_ipv6,omitempty" validate:"optional"`
+	RepushOnDrift      bool     `json:"repush_on_drift,omitempty" validate:"optional"`
+	TTL                uint32   `json:"ttl,omitempty" validate:"optional"`
+	ServerGroupID      uint64   `json:"server_group_id,omitempty" validate:"optional"`
+	ServerGroups       []uint64 `json:"server_groups,omitempty" validate:"optional"`
 	Name               string   `json:"name,omitempty" minLength:"1"`
 	Provider           string   `json:"provider,omitempty"`
 	Domains            []string `json:"domains,omitempty"`
@@ -14,4 +18,6 @@ type DDNSForm struct {
 	WebhookRequestType uint8    `json:"webhook_request_type,omitempty" validate:"optional" default:"1"`
 	WebhookRequestBody string   `json:"webhook_request_body,omitempty" validate:"optional"`
 	WebhookHeaders     string   `json:"webhook_headers,omitempty" validate:"optional"`
+
+	Config map[string]string `json:"config,omitempty" validate:"optional"`
 }
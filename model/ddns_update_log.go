@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// DDNS 更新的触发来源
+const (
+	DDNSTriggerIPChange    = iota // 服务器 IP 变动
+	DDNSTriggerDrift              // 校验发现解析漂移后重新推送
+	DDNSTriggerGroupChange        // 分组成员 IP 变动或上下线
+)
+
+// DDNSUpdateLog 一次 DDNS 记录更新尝试
+type DDNSUpdateLog struct {
+	ID         uint64    `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt  time.Time `gorm:"index;<-:create" json:"created_at,omitempty"`
+	ProfileID  uint64    `gorm:"index" json:"profile_id"`
+	ServerID   uint64    `gorm:"index" json:"server_id"`
+	Domain     string    `json:"domain"`
+	RecordType string    `json:"record_type"`
+	OldValue   string    `json:"old_value,omitempty"` // 更新前解析到的值，多个值以逗号分隔
+	NewValue   string    `json:"new_value"`
+	Trigger    uint8     `json:"trigger"` // 0:IP 变动 1:漂移修复 2:分组成员变动
+	Success    bool      `json:"success"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+}
+
+// DDNSDriftCheck 最近一次解析校验的结果，仅保存在内存中
+type DDNSDriftCheck struct {
+	ServerID   uint64    `json:"server_id"`
+	ProfileID  uint64    `json:"profile_id"`
+	Domain     string    `json:"domain"`
+	RecordType string    `json:"record_type"`
+	Expected   string    `json:"expected"`
+	Published  []string  `json:"published"`
+	Drift      bool      `json:"drift"`
+	Repushed   bool      `json:"repushed,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// EnrollmentTokenPrefix 注册令牌的前缀，agent 将令牌作为 client_secret 使用
+const EnrollmentTokenPrefix = "enr_"
+
+// EnrollmentToken 管理员签发的 agent 注册令牌，只保存哈希。
+// 令牌过期、用尽或撤销后不能再注册新服务器，已通过该令牌注册的服务器仍可使用它连接
+type EnrollmentToken struct {
+	Common
+	Name         string            `json:"name"`
+	TokenHash    string            `gorm:"uniqueIndex;size:64" json:"-"`
+	TokenPrefix  string            `json:"token_prefix"`              // 令牌开头的几位，用于辨认
+	MaxUses      uint64            `json:"max_uses"`                  // 最多注册的服务器数量，0 表示不限制
+	Uses         uint64            `json:"uses"`                      // 已注册的服务器数量
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty"`      // 为空时不过期
+	RevokedAt    *time.Time        `json:"revoked_at,omitempty"`      // 撤销时间，撤销后不能再注册新服务器，已注册的服务器仍可连接
+	NameTemplate string            `json:"name_template,omitempty"`   // 服务器名称模板，支持 {petname} {uuid} {short_uuid} {n}
+	ServerGroups []uint64          `gorm:"-" json:"server_groups"`    // 注册后加入的服务器分组
+	Labels       map[string]string `gorm:"-" json:"labels,omitempty"` // 注册后设置的标签
+
+	ServerGroupsRaw string `gorm:"default:'[]'" json:"-"`
+	LabelsRaw       string `gorm:"default:'{}'" json:"-"`
+}
+
+// HashEnrollmentToken 计算令牌的哈希
+func HashEnrollmentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Usable 判断令牌能否注册新服务器
+func (t *EnrollmentToken) Usable(now time.Time) bool {
+	return t.RevokedAt == nil &&
+		(t.ExpiresAt == nil || now.Before(*t.ExpiresAt)) &&
+		(t.MaxUses == 0 || t.Uses < t.MaxUses)
+}
+
+// ServerName 按模板生成服务器名称，n 为该令牌注册的第几台服务器
+func (t *EnrollmentToken) ServerName(petname, uuid string, n uint64) string {
+	if t.NameTemplate == "" {
+		return petname
+	}
+	shortUUID, _, _ := strings.Cut(uuid, "-")
+	return strings.NewReplacer(
+		"{petname}", petname,
+		"{uuid}", uuid,
+		"{short_uuid}", shortUUID,
+		"{n}", strconv.FormatUint(n, 10),
+	).Replace(t.NameTemplate)
+}
+
+func (t *EnrollmentToken) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(t.ServerGroups); err != nil {
+		return err
+	} else {
+		t.ServerGroupsRaw = string(data)
+	}
+	if data, err := json.Marshal(t.Labels); err != nil {
+		return err
+	} else {
+		t.LabelsRaw = string(data)
+	}
+	return nil
+}
+
+func (t *EnrollmentToken) AfterFind(tx *gorm.DB) error {
+	if t.ServerGroupsRaw != "" {
+		if err := json.Unmarshal([]byte(t.ServerGroupsRaw), &t.ServerGroups); err != nil {
+			log.Println("NEZHA>> EnrollmentToken.AfterFind:", err)
+			return nil
+		}
+	}
+	if t.LabelsRaw != "" {
+		if err := json.Unmarshal([]byte(t.LabelsRaw), &t.Labels); err != nil {
+			log.Println("NEZHA>> EnrollmentToken.AfterFind:", err)
+			return nil
+		}
+	}
+	return nil
+}
+
+// AgentEnrollment 通过注册令牌注册服务器的审计记录
+type AgentEnrollment struct {
+	ID         uint64    `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt  time.Time `gorm:"index;<-:create" json:"created_at,omitempty"`
+	TokenID    uint64    `gorm:"index" json:"token_id"`
+	TokenName  string    `json:"token_name"`
+	ServerID   uint64    `json:"server_id"`
+	ServerUUID string    `json:"server_uuid"`
+	ServerName string    `json:"server_name"`
+	IP         string    `json:"ip,omitempty"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type EnrollmentTokenForm struct {
+	Name         string            `json:"name,omitempty" minLength:"1"`
+	MaxUses      uint64            `json:"max_uses,omitempty" validate:"optional"` // 1 为一次性令牌，0 表示不限制
+	ExpiresAt    *time.Time        `json:"expires_at,omitempty" validate:"optional"`
+	NameTemplate string            `json:"name_template,omitempty" validate:"optional"` // 支持 {petname} {uuid} {short_uuid} {n}
+	ServerGroups []uint64          `json:"server_groups,omitempty" validate:"optional"`
+	Labels       map[string]string `json:"labels,omitempty" validate:"optional"`
+}
+
+// EnrollmentTokenResponse 创建令牌的结果，明文令牌只返回这一次
+type EnrollmentTokenResponse struct {
+	ID    uint64 `json:"id"`
+	Token string `json:"token"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnrollmentTokenUsable(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	cases := []struct {
+		name  string
+		token EnrollmentToken
+		want  bool
+	}{
+		{"unlimited", EnrollmentToken{}, true},
+		{"not expired", EnrollmentToken{ExpiresAt: &future}, true},
+		{"expired", EnrollmentToken{ExpiresAt: &past}, false},
+		{"revoked", EnrollmentToken{RevokedAt: &past}, false},
+		{"uses left", EnrollmentToken{MaxUses: 2, Uses: 1}, true},
+		{"used up", EnrollmentToken{MaxUses: 1, Uses: 1}, false},
+	}
+	for _, c := range cases {
+		if got := c.token.Usable(now); got != c.want {
+			t.Errorf("%s: Usable() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestEnrollmentTokenServerName(t *testing.T) {
+	const uuid = "0b6f1c2a-1111-2222-3333-444455556666"
+
+	token := EnrollmentToken{}
+	if got := token.ServerName("brave-otter", uuid, 3); got != "brave-otter" {
+		t.Errorf("empty template: got %q", got)
+	}
+
+	token.NameTemplate = "web-{n}-{short_uuid}-{petname}"
+	if got, want := token.ServerName("brave-otter", uuid, 3), "web-3-0b6f1c2a-brave-otter"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// 文件管理操作类型，与 agent 的文件管理协议一致
+const (
+	FMOperationList = iota
+	FMOperationDownload
+	FMOperationUpload
+)
+
+// FMAuditLog 文件管理操作记录
+type FMAuditLog struct {
+	ID         uint64    `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt  time.Time `gorm:"index;<-:create" json:"created_at,omitempty"`
+	UserID     uint64    `gorm:"index" json:"user_id"`
+	Username   string    `json:"username"`
+	ServerID   uint64    `gorm:"index" json:"server_id"`
+	ServerName string    `json:"server_name"`
+	ClientIP   string    `json:"client_ip,omitempty"`
+	Operation  uint8     `json:"operation"` // 0:列出目录 1:下载 2:上传
+	Path       string    `gorm:"type:text" json:"path"`
+	Size       int64     `json:"size"`             // 传输的字节数
+	Denied     bool      `json:"denied,omitempty"` // 操作被拒绝
+	Reason     string    `json:"reason,omitempty"` // 拒绝原因
+}
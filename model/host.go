@@ -109,6 +109,8 @@ type Host struct {
 	BootTime        uint64   `json:"boot_time,omitempty"`
 	Version         string   `json:"version,omitempty"`
 	GPU             []string `json:"gpu,omitempty"`
+	// Labels agent 自行上报的标签，合并到 Server.AgentLabels
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 func (h *Host) PB() *pb.Host {
@@ -124,6 +126,7 @@ func (h *Host) PB() *pb.Host {
 		BootTime:        h.BootTime,
 		Version:         h.Version,
 		Gpu:             h.GPU,
+		Labels:          h.Labels,
 	}
 }
 
@@ -155,6 +158,7 @@ func PB2Host(h *pb.Host) Host {
 		BootTime:        h.GetBootTime(),
 		Version:         h.GetVersion(),
 		GPU:             h.GetGpu(),
+		Labels:          h.GetLabels(),
 	}
 }
 
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// HostSnapshot 服务器上报的主机信息发生变化时记录一条，启动时间与标签不参与比较
+type HostSnapshot struct {
+	ID              uint64    `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt       time.Time `gorm:"index;<-:create" json:"created_at,omitempty"`
+	ServerID        uint64    `gorm:"index" json:"server_id"`
+	Platform        string    `json:"platform,omitempty"`
+	PlatformVersion string    `json:"platform_version,omitempty"`
+	Arch            string    `json:"arch,omitempty"`
+	Virtualization  string    `json:"virtualization,omitempty"`
+	MemTotal        uint64    `json:"mem_total,omitempty"`
+	DiskTotal       uint64    `json:"disk_total,omitempty"`
+	SwapTotal       uint64    `json:"swap_total,omitempty"`
+	Version         string    `json:"version,omitempty"` // agent 版本
+	CPURaw          string    `gorm:"type:text" json:"-"`
+	GPURaw          string    `gorm:"type:text" json:"-"`
+	ChangesRaw      string    `gorm:"type:text" json:"-"`
+
+	CPU     []string     `gorm:"-" json:"cpu,omitempty"`
+	GPU     []string     `gorm:"-" json:"gpu,omitempty"`
+	Changes []HostChange `gorm:"-" json:"changes,omitempty"` // 与上一条记录相比的变化，首条记录为空
+}
+
+// HostChange 主机信息中一个字段的变化
+type HostChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+func (c HostChange) String() string {
+	return fmt.Sprintf("%s: %s => %s", c.Field, c.Old, c.New)
+}
+
+func NewHostSnapshot(serverID uint64, h *Host) *HostSnapshot {
+	return &HostSnapshot{
+		ServerID:        serverID,
+		Platform:        h.Platform,
+		PlatformVersion: h.PlatformVersion,
+		Arch:            h.Arch,
+		Virtualization:  h.Virtualization,
+		MemTotal:        h.MemTotal,
+		DiskTotal:       h.DiskTotal,
+		SwapTotal:       h.SwapTotal,
+		Version:         h.Version,
+		CPU:             h.CPU,
+		GPU:             h.GPU,
+	}
+}
+
+// Diff 返回 next 相对于 s 变化的字段
+func (s *HostSnapshot) Diff(next *HostSnapshot) []HostChange {
+	var changes []HostChange
+	add := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, HostChange{Field: field, Old: old, New: new})
+		}
+	}
+	size := func(v uint64) string { return fmt.Sprintf("%d", v) }
+
+	add("platform", s.Platform, next.Platform)
+	add("platform_version", s.PlatformVersion, next.PlatformVersion)
+	add("arch", s.Arch, next.Arch)
+	add("virtualization", s.Virtualization, next.Virtualization)
+	add("mem_total", size(s.MemTotal), size(next.MemTotal))
+	add("disk_total", size(s.DiskTotal), size(next.DiskTotal))
+	add("swap_total", size(s.SwapTotal), size(next.SwapTotal))
+	if !slices.Equal(s.CPU, next.CPU) {
+		add("cpu", strings.Join(s.CPU, "; "), strings.Join(next.CPU, "; "))
+	}
+	if !slices.Equal(s.GPU, next.GPU) {
+		add("gpu", strings.Join(s.GPU, "; "), strings.Join(next.GPU, "; "))
+	}
+	add("version", s.Version, next.Version)
+	return changes
+}
+
+func (s *HostSnapshot) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(s.CPU); err != nil {
+		return err
+	} else {
+		s.CPURaw = string(data)
+	}
+	if data, err := json.Marshal(s.GPU); err != nil {
+		return err
+	} else {
+		s.GPURaw = string(data)
+	}
+	if data, err := json.Marshal(s.Changes); err != nil {
+		return err
+	} else {
+		s.ChangesRaw = string(data)
+	}
+	return nil
+}
+
+func (s *HostSnapshot) AfterFind(tx *gorm.DB) error {
+	if s.CPURaw != "" {
+		if err := json.Unmarshal([]byte(s.CPURaw), &s.CPU); err != nil {
+			log.Println("NEZHA>> HostSnapshot.AfterFind:", err)
+			return nil
+		}
+	}
+	if s.GPURaw != "" {
+		if err := json.Unmarshal([]byte(s.GPURaw), &s.GPU); err != nil {
+			log.Println("NEZHA>> HostSnapshot.AfterFind:", err)
+			return nil
+		}
+	}
+	if s.ChangesRaw != "" {
+		if err := json.Unmarshal([]byte(s.ChangesRaw), &s.Changes); err != nil {
+			log.Println("NEZHA>> HostSnapshot.AfterFind:", err)
+			return nil
+		}
+	}
+	return nil
+}
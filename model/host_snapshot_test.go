@@ -0,0 +1,37 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHostSnapshotDiff(t *testing.T) {
+	host := &Host{
+		Platform:        "ubuntu",
+		PlatformVersion: "22.04",
+		CPU:             []string{"AMD EPYC 7B13 2 Virtual Core"},
+		MemTotal:        2 << 30,
+		BootTime:        100,
+		Version:         "1.0.0",
+	}
+	prev := NewHostSnapshot(1, host)
+
+	rebooted := *host
+	rebooted.BootTime = 200
+	rebooted.Labels = map[string]string{"env": "prod"}
+	if changes := prev.Diff(NewHostSnapshot(1, &rebooted)); len(changes) != 0 {
+		t.Fatalf("boot time and labels should be ignored, got %v", changes)
+	}
+
+	upgraded := *host
+	upgraded.PlatformVersion = "24.04"
+	upgraded.MemTotal = 4 << 30
+	upgraded.CPU = []string{"AMD EPYC 7B13 4 Virtual Core"}
+	var fields []string
+	for _, c := range prev.Diff(NewHostSnapshot(1, &upgraded)) {
+		fields = append(fields, c.Field)
+	}
+	if !slices.Equal(fields, []string{"platform_version", "mem_total", "cpu"}) {
+		t.Fatalf("unexpected changed fields: %v", fields)
+	}
+}
@@ -1,5 +1,20 @@
 package model
 
+import (
+	"net/netip"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// 内网穿透的访问策略
+const (
+	NATAccessPublic      = iota // 不限制
+	NATAccessLogin              // 需要登录面板，仅管理员与配置所有者可以访问
+	NATAccessBasicAuth          // HTTP Basic 认证
+	NATAccessIPAllowlist        // 仅允许指定的 IP 或网段
+)
+
 type NAT struct {
 	Common
 	Enabled  bool   `json:"enabled"`
@@ -7,4 +22,85 @@ type NAT struct {
 	ServerID uint64 `json:"server_id"`
 	Host     string `json:"host"`
 	Domain   string `json:"domain" gorm:"unique"`
+
+	AccessPolicy      uint8  `json:"access_policy"` // 0:公开 1:面板登录 2:Basic 认证 3:IP 白名单
+	BasicAuthUsername string `json:"basic_auth_username,omitempty"`
+	BasicAuthPassword string `json:"-"` // 使用面板密钥加密保存
+
+	AllowedIPs    []string       `gorm:"-" json:"allowed_ips,omitempty"`
+	AllowedIPsRaw string         `gorm:"type:text" json:"-"`
+	allowedPrefix []netip.Prefix `gorm:"-"`
+
+	MaxStreams  int64  `json:"max_streams,omitempty"`   // 最大并发连接数，0 表示不限制
+	RateLimit   uint64 `json:"rate_limit,omitempty"`    // 单个客户端 IP 每秒的最大请求数，0 表示不限制
+	MaxBodySize int64  `json:"max_body_size,omitempty"` // 请求体最大字节数，0 表示不限制
+
+	Stats *NATStats `gorm:"-" json:"stats,omitempty"`
+}
+
+// NATStats 面板启动以来内网穿透的流量统计
+type NATStats struct {
+	Requests      uint64 `json:"requests"`
+	In            uint64 `json:"in"`  // 客户端发送的字节数
+	Out           uint64 `json:"out"` // 返回给客户端的字节数
+	ActiveStreams int64  `json:"active_streams"`
+	Errors        uint64 `json:"errors"`  // 转发失败的请求数
+	Limited       uint64 `json:"limited"` // 超出限制被拒绝的请求数
+}
+
+func (n *NAT) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(n.AllowedIPs)
+	if err != nil {
+		return err
+	}
+	n.AllowedIPsRaw = string(data)
+	return nil
+}
+
+func (n *NAT) AfterFind(tx *gorm.DB) error {
+	if n.AllowedIPsRaw != "" {
+		if err := json.Unmarshal([]byte(n.AllowedIPsRaw), &n.AllowedIPs); err != nil {
+			return err
+		}
+	}
+	n.allowedPrefix, _ = ParseIPPrefixes(n.AllowedIPs)
+	return nil
+}
+
+func (n *NAT) AfterSave(tx *gorm.DB) error {
+	n.allowedPrefix, _ = ParseIPPrefixes(n.AllowedIPs)
+	return nil
+}
+
+// IPAllowed 判断 IP 是否在白名单中
+func (n *NAT) IPAllowed(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range n.allowedPrefix {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIPPrefixes 解析 IP 或 CIDR 网段列表，单个 IP 视为只包含自身的网段
+func ParseIPPrefixes(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		if addr, err := netip.ParseAddr(s); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
 }
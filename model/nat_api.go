@@ -1,9 +1,24 @@
 package model
 
+// NATLoginTicketResponse 一次性登录票据，跳转到 //<domain>/?nz-nat-ticket=<ticket> 后换取内网穿透域名下的会话
+type NATLoginTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"` // 秒
+}
+
 type NATForm struct {
 	Name     string `json:"name,omitempty" minLength:"1"`
 	Enabled  bool   `json:"enabled,omitempty"`
 	ServerID uint64 `json:"server_id,omitempty"`
 	Host     string `json:"host,omitempty"`
 	Domain   string `json:"domain,omitempty"`
+
+	AccessPolicy      uint8    `json:"access_policy,omitempty" validate:"optional"` // 0:公开 1:面板登录 2:Basic 认证 3:IP 白名单
+	BasicAuthUsername string   `json:"basic_auth_username,omitempty" validate:"optional"`
+	BasicAuthPassword string   `json:"basic_auth_password,omitempty" validate:"optional"` // 留空时保留原密码
+	AllowedIPs        []string `json:"allowed_ips,omitempty" validate:"optional"`         // IP 或 CIDR 网段
+
+	MaxStreams  int64  `json:"max_streams,omitempty" validate:"optional"`   // 最大并发连接数，0 表示不限制
+	RateLimit   uint64 `json:"rate_limit,omitempty" validate:"optional"`    // 单个客户端 IP 每秒的最大请求数，0 表示不限制
+	MaxBodySize int64  `json:"max_body_size,omitempty" validate:"optional"` // 请求体最大字节数，0 表示不限制
 }
@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestNATIPAllowed(t *testing.T) {
+	n := &NAT{AllowedIPs: []string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32"}}
+	if err := n.AfterFind(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"10.1.2.3":        true,
+		"::ffff:10.1.2.3": true,
+		"192.168.1.10":    true,
+		"192.168.1.11":    false,
+		"2001:db8::1":     true,
+		"2001:db9::1":     false,
+		"not an ip":       false,
+		"":                false,
+	}
+	for ip, want := range cases {
+		if got := n.IPAllowed(ip); got != want {
+			t.Errorf("IPAllowed(%q) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if _, err := ParseIPPrefixes([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected error for invalid cidr")
+	}
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// NATTransfer 内网穿透每小时的流量记录
+type NATTransfer struct {
+	ID        uint64    `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at,omitempty"`
+	NATID     uint64    `gorm:"index;column:nat_id" json:"nat_id"`
+	Requests  uint64    `json:"requests"`
+	In        uint64    `json:"in"`
+	Out       uint64    `json:"out"`
+	Errors    uint64    `json:"errors"`
+	Limited   uint64    `json:"limited"`
+}
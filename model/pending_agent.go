@@ -0,0 +1,52 @@
+package model
+
+import (
+	"log"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+// PendingAgent 开启 agent 审核后，使用合法密钥连接的未知 UUID 先进入待审核列表，
+// 管理员批准后才会创建服务器，拒绝的记录保留以阻止该 agent 再次进入列表
+type PendingAgent struct {
+	Common
+	UUID       string     `gorm:"uniqueIndex;size:36" json:"uuid"`
+	IP         string     `json:"ip,omitempty"` // 连接 IP
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RejectedAt *time.Time `json:"rejected_at,omitempty"`
+	Host       *Host      `gorm:"-" json:"host,omitempty"` // agent 上报的主机信息
+
+	HostRaw string `json:"-"`
+}
+
+// Rejected 是否已被拒绝
+func (p *PendingAgent) Rejected() bool {
+	return p.RejectedAt != nil
+}
+
+func (p *PendingAgent) BeforeSave(tx *gorm.DB) error {
+	if p.Host == nil {
+		p.HostRaw = ""
+		return nil
+	}
+	data, err := json.Marshal(p.Host)
+	if err != nil {
+		return err
+	}
+	p.HostRaw = string(data)
+	return nil
+}
+
+func (p *PendingAgent) AfterFind(tx *gorm.DB) error {
+	if p.HostRaw != "" {
+		var host Host
+		if err := json.Unmarshal([]byte(p.HostRaw), &host); err != nil {
+			log.Println("NEZHA>> PendingAgent.AfterFind:", err)
+			return nil
+		}
+		p.Host = &host
+	}
+	return nil
+}
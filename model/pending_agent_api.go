@@ -0,0 +1,7 @@
+package model
+
+type PendingAgentApproveForm struct {
+	Name         string            `json:"name,omitempty" validate:"optional"` // 为空时随机生成
+	ServerGroups []uint64          `json:"server_groups,omitempty" validate:"optional"`
+	Labels       map[string]string `json:"labels,omitempty" validate:"optional"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPendingAgentHostRoundTrip(t *testing.T) {
+	p := PendingAgent{Host: &Host{Platform: "debian", CPU: []string{"Intel Xeon 2 Virtual Core"}, Version: "1.2.0"}}
+	if err := p.BeforeSave(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := PendingAgent{HostRaw: p.HostRaw}
+	if err := loaded.AfterFind(nil); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Host == nil || loaded.Host.Platform != "debian" || loaded.Host.Version != "1.2.0" ||
+		!slices.Equal(loaded.Host.CPU, p.Host.CPU) {
+		t.Fatalf("host not restored: %+v", loaded.Host)
+	}
+
+	empty := PendingAgent{}
+	if err := empty.BeforeSave(nil); err != nil || empty.HostRaw != "" {
+		t.Fatalf("expected empty HostRaw, got %q (%v)", empty.HostRaw, err)
+	}
+}
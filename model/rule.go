@@ -23,15 +23,17 @@ type Rule struct {
 	// 指标类型，cpu、memory、swap、disk、net_in_speed、net_out_speed
 	// net_all_speed、transfer_in、transfer_out、transfer_all、offline
 	// transfer_in_cycle、transfer_out_cycle、transfer_all_cycle
-	Type          string          `json:"type"`
-	Min           float64         `json:"min,omitempty" validate:"optional"`                                                        // 最小阈值 (百分比、字节 kb ÷ 1024)
-	Max           float64         `json:"max,omitempty" validate:"optional"`                                                        // 最大阈值 (百分比、字节 kb ÷ 1024)
-	CycleStart    *time.Time      `json:"cycle_start,omitempty" validate:"optional"`                                                // 流量统计的开始时间
-	CycleInterval uint64          `json:"cycle_interval,omitempty" validate:"optional"`                                             // 流量统计周期
-	CycleUnit     string          `json:"cycle_unit,omitempty" enums:"hour,day,week,month,year" validate:"optional" default:"hour"` // 流量统计周期单位，默认hour,可选(hour, day, week, month, year)
-	Duration      uint64          `json:"duration,omitempty" validate:"optional"`                                                   // 持续时间 (秒)
-	Cover         uint64          `json:"cover"`                                                                                    // 覆盖范围 RuleCoverAll/IgnoreAll
-	Ignore        map[uint64]bool `json:"ignore,omitempty" validate:"optional"`                                                     // 覆盖范围的排除
+	Type           string          `json:"type"`
+	Min            float64         `json:"min,omitempty" validate:"optional"`                                                        // 最小阈值 (百分比、字节 kb ÷ 1024)
+	Max            float64         `json:"max,omitempty" validate:"optional"`                                                        // 最大阈值 (百分比、字节 kb ÷ 1024)
+	CycleStart     *time.Time      `json:"cycle_start,omitempty" validate:"optional"`                                                // 流量统计的开始时间
+	CycleInterval  uint64          `json:"cycle_interval,omitempty" validate:"optional"`                                             // 流量统计周期
+	CycleUnit      string          `json:"cycle_unit,omitempty" enums:"hour,day,week,month,year" validate:"optional" default:"hour"` // 流量统计周期单位，默认hour,可选(hour, day, week, month, year)
+	Duration       uint64          `json:"duration,omitempty" validate:"optional"`                                                   // 持续时间 (秒)
+	Cover          uint64          `json:"cover"`                                                                                    // 覆盖范围 RuleCoverAll/IgnoreAll
+	Ignore         map[uint64]bool `json:"ignore,omitempty" validate:"optional"`                                                     // 覆盖范围的排除
+	IgnoreGroups   []uint64        `json:"ignore_groups,omitempty" validate:"optional"`                                              // 与 Ignore 含义相同的服务器分组，检查时动态解析
+	IgnoreSelector string          `json:"ignore_selector,omitempty" validate:"optional"`                                            // 与 Ignore 含义相同的标签选择器，如 env=prod,role in (db,cache)
 
 	// 只作为缓存使用，记录下次该检测的时间
 	NextTransferAt  map[uint64]time.Time `json:"-"`
@@ -45,14 +47,24 @@ func percentage(used, total uint64) float64 {
 	return float64(used) * 100 / float64(total)
 }
 
-// Snapshot 未通过规则返回 false, 通过返回 true
-func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server, db *gorm.DB) bool {
+// Covers 判断规则是否覆盖该服务器，inGroups 判断服务器是否属于给定的分组
+func (u *Rule) Covers(server *Server, inGroups func(groups []uint64, serverID uint64) bool) bool {
+	listed := u.Ignore[server.ID] || (len(u.IgnoreGroups) > 0 && inGroups(u.IgnoreGroups, server.ID)) ||
+		server.MatchesSelector(u.IgnoreSelector)
 	// 监控全部但是排除了此服务器
-	if u.Cover == RuleCoverAll && u.Ignore[server.ID] {
-		return true
+	if u.Cover == RuleCoverAll && listed {
+		return false
 	}
 	// 忽略全部但是指定监控了此服务器
-	if u.Cover == RuleCoverIgnoreAll && !u.Ignore[server.ID] {
+	if u.Cover == RuleCoverIgnoreAll && !listed {
+		return false
+	}
+	return true
+}
+
+// Snapshot 未通过规则返回 false, 通过返回 true
+func (u *Rule) Snapshot(cycleTransferStats *CycleTransferStats, server *Server, db *gorm.DB, inGroups func([]uint64, uint64) bool) bool {
+	if !u.Covers(server, inGroups) {
 		return true
 	}
 
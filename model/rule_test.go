@@ -0,0 +1,48 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRuleCovers(t *testing.T) {
+	groups := map[uint64][]uint64{1: {10, 11}}
+	inGroups := func(ids []uint64, serverID uint64) bool {
+		for _, id := range ids {
+			if slices.Contains(groups[id], serverID) {
+				return true
+			}
+		}
+		return false
+	}
+
+	cases := []struct {
+		rule   Rule
+		server uint64
+		want   bool
+	}{
+		{Rule{Cover: RuleCoverAll}, 10, true},
+		{Rule{Cover: RuleCoverAll, Ignore: map[uint64]bool{10: true}}, 10, false},
+		{Rule{Cover: RuleCoverAll, IgnoreGroups: []uint64{1}}, 11, false},
+		{Rule{Cover: RuleCoverAll, IgnoreGroups: []uint64{1}}, 12, true},
+		{Rule{Cover: RuleCoverIgnoreAll}, 10, false},
+		{Rule{Cover: RuleCoverIgnoreAll, Ignore: map[uint64]bool{12: true}}, 12, true},
+		{Rule{Cover: RuleCoverIgnoreAll, IgnoreGroups: []uint64{1}}, 10, true},
+		{Rule{Cover: RuleCoverIgnoreAll, IgnoreGroups: []uint64{2}}, 10, false},
+		{Rule{Cover: RuleCoverAll, IgnoreSelector: "env=prod"}, 10, false},
+		{Rule{Cover: RuleCoverAll, IgnoreSelector: "env=prod"}, 11, true},
+		{Rule{Cover: RuleCoverIgnoreAll, IgnoreSelector: "role in (db,cache)"}, 10, true},
+		{Rule{Cover: RuleCoverIgnoreAll, IgnoreSelector: "role in (db,cache)"}, 11, false},
+	}
+	servers := map[uint64]*Server{
+		10: {Common: Common{ID: 10}, Labels: map[string]string{"env": "prod"}, AgentLabels: map[string]string{"env": "dev", "role": "db"}},
+		11: {Common: Common{ID: 11}, AgentLabels: map[string]string{"env": "dev"}},
+		12: {Common: Common{ID: 12}},
+	}
+
+	for i, c := range cases {
+		if got := c.rule.Covers(servers[c.server], inGroups); got != c.want {
+			t.Errorf("case %d: Covers(%d) = %v, want %v", i, c.server, got, c.want)
+		}
+	}
+}
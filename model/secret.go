@@ -0,0 +1,9 @@
+package model
+
+// Secret 密钥库中的一项，值加密保存，仅在下发计划任务时解密替换
+type Secret struct {
+	Common
+	Name        string `gorm:"uniqueIndex;size:128" json:"name"`
+	Description string `json:"description,omitempty"`
+	Ciphertext  string `gorm:"type:text" json:"-"`
+}
@@ -0,0 +1,7 @@
+package model
+
+type SecretForm struct {
+	Name        string `json:"name" minLength:"1"`
+	Description string `json:"description,omitempty" validate:"optional"`
+	Value       string `json:"value,omitempty" validate:"optional"` // 更新时留空则保留原值
+}
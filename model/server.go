@@ -2,12 +2,16 @@ package model
 
 import (
 	"log"
+	"maps"
+	"path"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
 	"gorm.io/gorm"
 
+	"github.com/telexy324/billabong/pkg/labels"
 	pb "github.com/telexy324/billabong/proto"
 )
 
@@ -23,9 +27,18 @@ type Server struct {
 	EnableDDNS             bool   `json:"enable_ddns,omitempty"`    // 启用DDNS
 	DDNSProfilesRaw        string `gorm:"default:'[]';column:ddns_profiles_raw" json:"-"`
 	OverrideDDNSDomainsRaw string `gorm:"default:'{}';column:override_ddns_domains_raw" json:"-"`
+	FMAllowPathsRaw        string `gorm:"default:'[]'" json:"-"`
+	FMDenyPathsRaw         string `gorm:"default:'[]'" json:"-"`
+	LabelsRaw              string `gorm:"default:'{}'" json:"-"`
+	AgentLabelsRaw         string `gorm:"default:'{}'" json:"-"`
+	EnrollmentTokenID      uint64 `json:"enrollment_token_id,omitempty"` // 通过注册令牌注册时的令牌
 
 	DDNSProfiles        []uint64            `gorm:"-" json:"ddns_profiles,omitempty" validate:"optional"` // DDNS配置
 	OverrideDDNSDomains map[uint64][]string `gorm:"-" json:"override_ddns_domains,omitempty" validate:"optional"`
+	FMAllowPaths        []string            `gorm:"-" json:"fm_allow_paths,omitempty" validate:"optional"` // 文件管理可访问的目录，为空时不限制
+	FMDenyPaths         []string            `gorm:"-" json:"fm_deny_paths,omitempty" validate:"optional"`  // 文件管理禁止访问的目录，优先于 FMAllowPaths
+	Labels              map[string]string   `gorm:"-" json:"labels,omitempty" validate:"optional"`         // 手动设置的标签，优先于 agent 上报的同名标签
+	AgentLabels         map[string]string   `gorm:"-" json:"agent_labels,omitempty" validate:"optional"`   // agent 上报的标签
 
 	Host       *Host      `gorm:"-" json:"host,omitempty"`
 	State      *HostState `gorm:"-" json:"state,omitempty"`
@@ -70,9 +83,84 @@ func (s *Server) AfterFind(tx *gorm.DB) error {
 			return nil
 		}
 	}
+	if s.FMAllowPathsRaw != "" {
+		if err := json.Unmarshal([]byte(s.FMAllowPathsRaw), &s.FMAllowPaths); err != nil {
+			log.Println("NEZHA>> Server.AfterFind:", err)
+			return nil
+		}
+	}
+	if s.FMDenyPathsRaw != "" {
+		if err := json.Unmarshal([]byte(s.FMDenyPathsRaw), &s.FMDenyPaths); err != nil {
+			log.Println("NEZHA>> Server.AfterFind:", err)
+			return nil
+		}
+	}
+	if s.LabelsRaw != "" {
+		if err := json.Unmarshal([]byte(s.LabelsRaw), &s.Labels); err != nil {
+			log.Println("NEZHA>> Server.AfterFind:", err)
+			return nil
+		}
+	}
+	if s.AgentLabelsRaw != "" {
+		if err := json.Unmarshal([]byte(s.AgentLabelsRaw), &s.AgentLabels); err != nil {
+			log.Println("NEZHA>> Server.AfterFind:", err)
+			return nil
+		}
+	}
 	return nil
 }
 
+// EffectiveLabels 合并 agent 上报与手动设置的标签，同名时以手动设置为准
+func (s *Server) EffectiveLabels() map[string]string {
+	if len(s.AgentLabels) == 0 {
+		return s.Labels
+	}
+	set := maps.Clone(s.AgentLabels)
+	maps.Copy(set, s.Labels)
+	return set
+}
+
+// MatchesSelector 判断服务器标签是否满足选择器，选择器为空或不合法时返回 false
+func (s *Server) MatchesSelector(selector string) bool {
+	return selector != "" && labels.Match(selector, s.EffectiveLabels())
+}
+
+// FMPathAllowed 判断文件管理是否可以访问该路径，按目录前缀匹配，相对路径一律拒绝
+func (s *Server) FMPathAllowed(p string) bool {
+	p, ok := cleanFMPath(p)
+	if !ok {
+		return false
+	}
+	if slices.ContainsFunc(s.FMDenyPaths, func(dir string) bool { return fmPathUnder(p, dir) }) {
+		return false
+	}
+	return len(s.FMAllowPaths) == 0 ||
+		slices.ContainsFunc(s.FMAllowPaths, func(dir string) bool { return fmPathUnder(p, dir) })
+}
+
+// IsFMAbsPath 判断是否为 Unix 绝对路径或带盘符的 Windows 绝对路径
+func IsFMAbsPath(p string) bool {
+	_, ok := cleanFMPath(p)
+	return ok
+}
+
+func fmPathUnder(p, dir string) bool {
+	dir, ok := cleanFMPath(dir)
+	return ok && (p == dir || dir == "/" || strings.HasPrefix(p, dir+"/"))
+}
+
+// cleanFMPath 统一 Windows 路径分隔符与盘符大小写后清理路径，不是绝对路径时返回 false
+func cleanFMPath(p string) (string, bool) {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if len(p) >= 3 && p[1] == ':' && p[2] == '/' {
+		p = "/" + strings.ToUpper(p[:1]) + p[1:]
+	}
+	if !strings.HasPrefix(p, "/") {
+		return "", false
+	}
+	return path.Clean(p), true
+}
+
 // Split a sorted server list into two separate lists:
 // The first list contains servers with a priority set (DisplayIndex != 0).
 // The second list contains servers without a priority set (DisplayIndex == 0).
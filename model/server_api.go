@@ -29,6 +29,9 @@ type ServerForm struct {
 	EnableDDNS          bool                `json:"enable_ddns,omitempty" validate:"optional"`    // 启用DDNS
 	DDNSProfiles        []uint64            `json:"ddns_profiles,omitempty" validate:"optional"`  // DDNS配置
 	OverrideDDNSDomains map[uint64][]string `json:"override_ddns_domains,omitempty" validate:"optional"`
+	FMAllowPaths        []string            `json:"fm_allow_paths,omitempty" validate:"optional"` // 文件管理可访问的目录，仅管理员可修改
+	FMDenyPaths         []string            `json:"fm_deny_paths,omitempty" validate:"optional"`  // 文件管理禁止访问的目录，仅管理员可修改
+	Labels              map[string]string   `json:"labels,omitempty" validate:"optional"`         // 手动设置的标签，优先于 agent 上报的同名标签
 }
 
 type ServerConfigForm struct {
@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestServerFMPathAllowed(t *testing.T) {
+	cases := []struct {
+		allow, deny []string
+		path        string
+		want        bool
+	}{
+		{nil, nil, "/etc/passwd", true},
+		{nil, []string{"/etc"}, "/etc/passwd", false},
+		{nil, []string{"/etc"}, "/etc", false},
+		{nil, []string{"/etc"}, "/etcd/data", true},
+		{nil, []string{"/etc/"}, "/var/../etc/shadow", false},
+		{[]string{"/var/log"}, nil, "/var/log/syslog", true},
+		{[]string{"/var/log"}, nil, "/var/lib", false},
+		{[]string{"/var/log"}, nil, "/var/log/../../root", false},
+		{[]string{"/var"}, []string{"/var/secret"}, "/var/secret/key", false},
+		{[]string{"/"}, []string{"/root"}, "/home", true},
+		{[]string{`C:\Users`}, nil, `c:\users\..\Users\me`, true},
+		{nil, []string{`c:\Windows`}, `C:\Windows\System32`, false},
+		{nil, []string{"/etc"}, "etc/passwd", false},
+		{nil, nil, "../etc/passwd", false},
+		{nil, nil, `C:Windows`, false},
+		{[]string{"var/log"}, nil, "/var/log/syslog", false},
+	}
+
+	for _, c := range cases {
+		s := &Server{FMAllowPaths: c.allow, FMDenyPaths: c.deny}
+		if got := s.FMPathAllowed(c.path); got != c.want {
+			t.Errorf("FMPathAllowed(%q) with allow %v deny %v = %v, want %v", c.path, c.allow, c.deny, got, c.want)
+		}
+	}
+}
@@ -26,8 +26,14 @@ const (
 	TaskTypeFM
 	TaskTypeReportConfig
 	TaskTypeApplyConfig
+	TaskTypeDNS
+	TaskTypeTLS
+	TaskTypeHTTP
 )
 
+// ServiceTLSErrorPrefix 证书获取失败时上报数据的前缀，与 agent 保持一致
+const ServiceTLSErrorPrefix = "SSL证书错误："
+
 type TerminalTask struct {
 	StreamID string
 }
@@ -46,6 +52,42 @@ const (
 	ServiceCoverIgnoreAll
 )
 
+const (
+	ServiceKeywordNone = iota
+	ServiceKeywordRequired
+	ServiceKeywordForbidden
+)
+
+// ServiceDNSCheck DNS 查询监控配置
+type ServiceDNSCheck struct {
+	RecordType string   `json:"record_type,omitempty"` // 记录类型，默认 A
+	Resolver   string   `json:"resolver,omitempty"`    // 解析服务器，留空则使用面板设置的 DNS 服务器
+	Expected   []string `json:"expected,omitempty"`    // 期望出现在解析结果中的值
+}
+
+// ServiceTLSCheck 仅检查 TLS 证书的监控配置
+type ServiceTLSCheck struct {
+	ExpiryDays uint32 `json:"expiry_days,omitempty"` // 证书剩余有效期低于该天数时视为异常，默认 7 天
+}
+
+// ServiceHTTPCheck 自定义 HTTP 请求监控配置
+type ServiceHTTPCheck struct {
+	Method         string            `json:"method,omitempty"` // 默认 GET
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	ExpectedStatus []int             `json:"expected_status,omitempty"` // 留空则 200-399 视为正常
+	KeywordMode    uint8             `json:"keyword_mode,omitempty"`    // 0:不检查 1:必须包含 2:不得包含
+	Keyword        string            `json:"keyword,omitempty"`
+	JSONPath       string            `json:"json_path,omitempty"`  // gjson 路径，响应中必须存在该路径
+	JSONValue      string            `json:"json_value,omitempty"` // 不为空时路径的值必须与之相等
+}
+
+type ServiceCheckOptions struct {
+	DNS  *ServiceDNSCheck  `json:"dns,omitempty"`
+	TLS  *ServiceTLSCheck  `json:"tls,omitempty"`
+	HTTP *ServiceHTTPCheck `json:"http,omitempty"`
+}
+
 type Service struct {
 	Common
 	Name                string `json:"name"`
@@ -56,6 +98,7 @@ type Service struct {
 	Notify              bool   `json:"notify,omitempty"`
 	NotificationGroupID uint64 `json:"notification_group_id"` // 当前服务监控所属的通知组 ID
 	Cover               uint8  `json:"cover"`
+	RunOnDashboard      bool   `gorm:"default: false" json:"run_on_dashboard,omitempty"` // 由面板执行监控，无需 agent
 
 	EnableTriggerTask      bool   `gorm:"default: false" json:"enable_trigger_task,omitempty"`
 	EnableShowInService    bool   `gorm:"default: false" json:"enable_show_in_service,omitempty"`
@@ -69,8 +112,17 @@ type Service struct {
 	MaxLatency    float32 `json:"max_latency"`
 	LatencyNotify bool    `json:"latency_notify,omitempty"`
 
+	CheckOptionsRaw string               `gorm:"type:text" json:"-"`
+	CheckOptions    *ServiceCheckOptions `gorm:"-" json:"check_options,omitempty"`
+
 	SkipServers map[uint64]bool `gorm:"-" json:"skip_servers"`
 	CronJobID   cron.EntryID    `gorm:"-" json:"-"`
+
+	// 与 SkipServers 含义相同，分组成员在下发监控时动态解析
+	SkipServerGroupsRaw string   `gorm:"default:'[]'" json:"-"`
+	SkipServerGroups    []uint64 `gorm:"-" json:"skip_server_groups"`
+	// 与 SkipServers 含义相同的标签选择器，如 env=prod,role in (db,cache)
+	SkipServerSelector string `json:"skip_server_selector,omitempty"`
 }
 
 func (m *Service) PB() *pb.Task {
@@ -96,6 +148,11 @@ func (m *Service) BeforeSave(tx *gorm.DB) error {
 	} else {
 		m.SkipServersRaw = string(data)
 	}
+	if data, err := json.Marshal(m.SkipServerGroups); err != nil {
+		return err
+	} else {
+		m.SkipServerGroupsRaw = string(data)
+	}
 	if data, err := json.Marshal(m.FailTriggerTasks); err != nil {
 		return err
 	} else {
@@ -106,6 +163,11 @@ func (m *Service) BeforeSave(tx *gorm.DB) error {
 	} else {
 		m.RecoverTriggerTasksRaw = string(data)
 	}
+	if data, err := json.Marshal(m.CheckOptions); err != nil {
+		return err
+	} else {
+		m.CheckOptionsRaw = string(data)
+	}
 	return nil
 }
 
@@ -116,6 +178,12 @@ func (m *Service) AfterFind(tx *gorm.DB) error {
 		return nil
 	}
 
+	if m.SkipServerGroupsRaw != "" {
+		if err := json.Unmarshal([]byte(m.SkipServerGroupsRaw), &m.SkipServerGroups); err != nil {
+			return err
+		}
+	}
+
 	// 加载触发任务列表
 	if err := json.Unmarshal([]byte(m.FailTriggerTasksRaw), &m.FailTriggerTasks); err != nil {
 		return err
@@ -124,9 +192,48 @@ func (m *Service) AfterFind(tx *gorm.DB) error {
 		return err
 	}
 
+	if m.CheckOptionsRaw != "" {
+		if err := json.Unmarshal([]byte(m.CheckOptionsRaw), &m.CheckOptions); err != nil {
+			log.Println("NEZHA>> Service.AfterFind:", err)
+		}
+	}
+
 	return nil
 }
 
+// TLSExpiryDays 返回证书到期提醒的提前天数
+func (m *Service) TLSExpiryDays() int {
+	if m.Type == TaskTypeTLS && m.CheckOptions != nil && m.CheckOptions.TLS != nil && m.CheckOptions.TLS.ExpiryDays > 0 {
+		return int(m.CheckOptions.TLS.ExpiryDays)
+	}
+	return 7
+}
+
+// RunsOnDashboard 判断该服务监控是否由面板执行
+func (m *Service) RunsOnDashboard() bool {
+	return m.RunOnDashboard || IsDashboardTask(m.Type)
+}
+
+// IsDashboardTask 判断该任务类型是否只能由面板执行（agent 不支持）
+func IsDashboardTask(t uint8) bool {
+	switch t {
+	case TaskTypeDNS, TaskTypeTLS, TaskTypeHTTP:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCertReportingTask 判断该任务类型上报的数据是否可能包含证书信息
+func IsCertReportingTask(t uint64) bool {
+	switch t {
+	case TaskTypeHTTPGet, TaskTypeTLS, TaskTypeHTTP:
+		return true
+	default:
+		return false
+	}
+}
+
 // IsServiceSentinelNeeded 判断该任务类型是否需要进行服务监控 需要则返回true
 func IsServiceSentinelNeeded(t uint64) bool {
 	switch t {
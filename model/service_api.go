@@ -7,6 +7,7 @@ type ServiceForm struct {
 	Target              string          `json:"target,omitempty"`
 	Type                uint8           `json:"type,omitempty"`
 	Cover               uint8           `json:"cover,omitempty"`
+	RunOnDashboard      bool            `json:"run_on_dashboard,omitempty" validate:"optional"`
 	Notify              bool            `json:"notify,omitempty" validate:"optional"`
 	Duration            uint64          `json:"duration,omitempty"`
 	MinLatency          float32         `json:"min_latency,omitempty" default:"0.0"`
@@ -17,7 +18,11 @@ type ServiceForm struct {
 	FailTriggerTasks    []uint64        `json:"fail_trigger_tasks,omitempty"`
 	RecoverTriggerTasks []uint64        `json:"recover_trigger_tasks,omitempty"`
 	SkipServers         map[uint64]bool `json:"skip_servers,omitempty"`
+	SkipServerGroups    []uint64        `json:"skip_server_groups,omitempty" validate:"optional"`
+	SkipServerSelector  string          `json:"skip_server_selector,omitempty" validate:"optional"`
 	NotificationGroupID uint64          `json:"notification_group_id,omitempty"`
+
+	CheckOptions *ServiceCheckOptions `json:"check_options,omitempty" validate:"optional"`
 }
 
 type ServiceResponseItem struct {
@@ -53,3 +58,16 @@ type ServiceResponse struct {
 	Services           map[uint64]ServiceResponseItem `json:"services,omitempty"`
 	CycleTransferStats map[uint64]CycleTransferStats  `json:"cycle_transfer_stats,omitempty"`
 }
+
+type ServiceSLAReport struct {
+	ServiceID       uint64  `json:"service_id"`
+	ServiceName     string  `json:"service_name"`
+	From            string  `json:"from"`
+	To              string  `json:"to"`
+	Availability    float64 `json:"availability"` // 可用率，百分比
+	DowntimeMinutes float64 `json:"downtime_minutes"`
+	Incidents       uint64  `json:"incidents"`
+	MeanLatency     float32 `json:"mean_latency"` // 平均延迟，毫秒
+	Up              uint64  `json:"up"`
+	Down            uint64  `json:"down"`
+}
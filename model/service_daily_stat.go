@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// ServiceDailyStat 服务每日可用性汇总，不随监控记录清理，用于长期 SLA 统计
+type ServiceDailyStat struct {
+	ID        uint64    `gorm:"primaryKey" json:"id,omitempty"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at,omitempty"`
+	ServiceID uint64    `gorm:"uniqueIndex:idx_service_id_date" json:"service_id"`
+	Date      string    `gorm:"uniqueIndex:idx_service_id_date;size:10" json:"date"` // 2006-01-02，按面板时区
+	Up        uint64    `json:"up"`
+	Down      uint64    `json:"down"`
+	AvgDelay  float32   `json:"avg_delay"` // 平均延迟，毫秒
+	Incidents uint64    `json:"incidents"` // 当日进入故障状态的次数
+}
+
+// DowntimeMinutes 按异常检查占比估算的故障分钟数，minutes 为当日已经过的分钟数，完整的一天为 1440
+func (s *ServiceDailyStat) DowntimeMinutes(minutes float64) float64 {
+	if s.Up+s.Down == 0 {
+		return 0
+	}
+	return float64(s.Down) / float64(s.Up+s.Down) * minutes
+}
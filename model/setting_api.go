@@ -1,21 +1,28 @@
 package model
 
 type SettingForm struct {
-	DNSServers                  string `json:"dns_servers,omitempty" validate:"optional"`
-	IgnoredIPNotification       string `json:"ignored_ip_notification,omitempty" validate:"optional"`
-	IPChangeNotificationGroupID uint64 `json:"ip_change_notification_group_id,omitempty"` // IP变更提醒的通知组
-	Cover                       uint8  `json:"cover,omitempty"`
-	SiteName                    string `json:"site_name,omitempty" minLength:"1"`
-	Language                    string `json:"language,omitempty" minLength:"2"`
-	InstallHost                 string `json:"install_host,omitempty" validate:"optional"`
-	CustomCode                  string `json:"custom_code,omitempty" validate:"optional"`
-	CustomCodeDashboard         string `json:"custom_code_dashboard,omitempty" validate:"optional"`
-	RealIPHeader                string `json:"real_ip_header,omitempty" validate:"optional"` // 真实IP
-	UserTemplate                string `json:"user_template,omitempty" validate:"optional"`
-
-	AgentTLS                    bool `json:"tls,omitempty" validate:"optional"`
-	EnableIPChangeNotification  bool `json:"enable_ip_change_notification,omitempty" validate:"optional"`
-	EnablePlainIPInNotification bool `json:"enable_plain_ip_in_notification,omitempty" validate:"optional"`
+	DNSServers                    string `json:"dns_servers,omitempty" validate:"optional"`
+	IgnoredIPNotification         string `json:"ignored_ip_notification,omitempty" validate:"optional"`
+	IPChangeNotificationGroupID   uint64 `json:"ip_change_notification_group_id,omitempty"` // IP变更提醒的通知组
+	Cover                         uint8  `json:"cover,omitempty"`
+	HostChangeNotificationGroupID uint64 `json:"host_change_notification_group_id,omitempty" validate:"optional"` // 主机信息变更提醒的通知组
+	SiteName                      string `json:"site_name,omitempty" minLength:"1"`
+	Language                      string `json:"language,omitempty" minLength:"2"`
+	InstallHost                   string `json:"install_host,omitempty" validate:"optional"`
+	CustomCode                    string `json:"custom_code,omitempty" validate:"optional"`
+	CustomCodeDashboard           string `json:"custom_code_dashboard,omitempty" validate:"optional"`
+	RealIPHeader                  string `json:"real_ip_header,omitempty" validate:"optional"` // 真实IP
+	UserTemplate                  string `json:"user_template,omitempty" validate:"optional"`
+
+	AgentTLS                     bool `json:"tls,omitempty" validate:"optional"`
+	EnableIPChangeNotification   bool `json:"enable_ip_change_notification,omitempty" validate:"optional"`
+	EnableHostChangeNotification bool `json:"enable_host_change_notification,omitempty" validate:"optional"`
+	EnablePlainIPInNotification  bool `json:"enable_plain_ip_in_notification,omitempty" validate:"optional"`
+	RequireAgentApproval         bool `json:"require_agent_approval,omitempty" validate:"optional"`
+	EnableTerminalRecording      bool `json:"enable_terminal_recording,omitempty" validate:"optional"`
+	RecordTerminalInput          bool `json:"record_terminal_input,omitempty" validate:"optional"`
+
+	FMMaxTransferSize int64 `json:"fm_max_transfer_size,omitempty" validate:"optional"` // 文件管理单次传输的最大字节数
 }
 
 type Setting struct {
@@ -42,3 +49,16 @@ type SettingResponse struct {
 	Version           string             `json:"version,omitempty"`
 	FrontendTemplates []FrontendTemplate `json:"frontend_templates,omitempty"`
 }
+
+type RetentionTableStat struct {
+	Name          string `json:"name"`
+	Table         string `json:"table"`
+	RetentionDays int    `json:"retention_days"` // 0 表示不按时间清理
+	Rows          int64  `json:"rows"`
+	Size          int64  `json:"size"` // 估算占用空间，字节
+}
+
+type RetentionResponse struct {
+	Config RetentionConfig      `json:"config"`
+	Tables []RetentionTableStat `json:"tables"`
+}
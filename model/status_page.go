@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+)
+
+const (
+	IncidentStatusInvestigating = iota
+	IncidentStatusIdentified
+	IncidentStatusMonitoring
+	IncidentStatusResolved
+)
+
+// StatusPageSection 状态页中的一个分组，包含选定的服务监控与服务器
+type StatusPageSection struct {
+	Name     string   `json:"name"`
+	Services []uint64 `json:"services,omitempty"`
+	Servers  []uint64 `json:"servers,omitempty"`
+}
+
+type StatusPage struct {
+	Common
+	Enabled     bool   `json:"enabled"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug" gorm:"unique"`
+	Domain      string `json:"domain,omitempty" gorm:"index"` // 自定义域名，访问该域名时直接展示此状态页
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	LogoID      uint64 `json:"logo_id,omitempty"` // 上传文件 ID
+
+	Sections    []StatusPageSection `gorm:"-" json:"sections"`
+	SectionsRaw string              `gorm:"type:text" json:"-"`
+}
+
+func (p *StatusPage) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(p.Sections); err != nil {
+		return err
+	} else {
+		p.SectionsRaw = string(data)
+	}
+	return nil
+}
+
+func (p *StatusPage) AfterFind(tx *gorm.DB) error {
+	if p.SectionsRaw == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(p.SectionsRaw), &p.Sections)
+}
+
+// StatusPageIncident 状态页上的事件公告
+type StatusPageIncident struct {
+	Common
+	StatusPageID uint64     `json:"status_page_id" gorm:"index"`
+	Title        string     `json:"title"`
+	Content      string     `json:"content"`
+	Status       uint8      `json:"status"` // 0:调查中 1:已确认 2:观察中 3:已解决
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+}
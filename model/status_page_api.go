@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+type StatusPageForm struct {
+	Enabled     bool                `json:"enabled,omitempty" validate:"optional"`
+	Name        string              `json:"name" minLength:"1"`
+	Slug        string              `json:"slug" minLength:"1"`
+	Domain      string              `json:"domain,omitempty" validate:"optional"`
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty" validate:"optional"`
+	LogoID      uint64              `json:"logo_id,omitempty" validate:"optional"`
+	Sections    []StatusPageSection `json:"sections"`
+}
+
+type StatusPageIncidentForm struct {
+	StatusPageID uint64 `json:"status_page_id"`
+	Title        string `json:"title" minLength:"1"`
+	Content      string `json:"content"`
+	Status       uint8  `json:"status"`
+}
+
+// StatusPageResponse 公开状态页数据，不包含监控目标等内部信息
+type StatusPageResponse struct {
+	Title       string                       `json:"title"`
+	Description string                       `json:"description,omitempty"`
+	Logo        string                       `json:"logo,omitempty"`
+	Sections    []StatusPageSectionResponse  `json:"sections"`
+	Incidents   []StatusPageIncidentResponse `json:"incidents,omitempty"`
+	UpdatedAt   time.Time                    `json:"updated_at"`
+}
+
+type StatusPageSectionResponse struct {
+	Name     string                     `json:"name"`
+	Services []StatusPageServiceItem    `json:"services,omitempty"`
+	Servers  []StatusPageServerResponse `json:"servers,omitempty"`
+}
+
+type StatusPageServiceItem struct {
+	ID     uint64              `json:"id"`
+	Name   string              `json:"name"`
+	Status string              `json:"status"`
+	Uptime float32             `json:"uptime"` // 90 天可用率
+	Days   []StatusPageDayItem `json:"days"`
+}
+
+type StatusPageDayItem struct {
+	Date  string  `json:"date"`
+	Up    uint64  `json:"up"`
+	Down  uint64  `json:"down"`
+	Delay float32 `json:"delay"`
+}
+
+func (d StatusPageDayItem) Uptime() float32 {
+	if d.Up+d.Down == 0 {
+		return 0
+	}
+	return float32(d.Up) / float32(d.Up+d.Down) * 100
+}
+
+type StatusPageServerResponse struct {
+	ID     uint64 `json:"id"`
+	Name   string `json:"name"`
+	Online bool   `json:"online"`
+}
+
+type StatusPageIncidentResponse struct {
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Status     uint8      `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type TerminalForm struct {
 	Protocol string `json:"protocol,omitempty"`
 	ServerID uint64 `json:"server_id,omitempty"`
@@ -10,3 +12,16 @@ type CreateTerminalResponse struct {
 	ServerID   uint64 `json:"server_id,omitempty"`
 	ServerName string `json:"server_name,omitempty"`
 }
+
+type TerminalShareResponse struct {
+	Token string `json:"token,omitempty"` // 只读观看链接的令牌
+}
+
+// TerminalViewer 以只读方式观看终端的用户
+type TerminalViewer struct {
+	ID       string    `json:"id"`
+	UserID   uint64    `json:"user_id"`
+	Username string    `json:"username"`
+	ClientIP string    `json:"client_ip,omitempty"`
+	JoinedAt time.Time `json:"joined_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// TerminalRecording 终端会话录像，文件为 asciicast v2 格式
+type TerminalRecording struct {
+	Common
+	Username   string    `json:"username"`
+	ServerID   uint64    `gorm:"index" json:"server_id"`
+	ServerName string    `json:"server_name"`
+	ClientIP   string    `json:"client_ip"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	Input      bool      `json:"input,omitempty"` // 是否包含输入
+	Key        string    `json:"-"`               // 存储中的文件名
+	Size       int64     `json:"size"`
+}
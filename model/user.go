@@ -20,6 +20,8 @@ type User struct {
 	Role           uint8  `json:"role,omitempty"`
 	AgentSecret    string `json:"agent_secret,omitempty" gorm:"type:char(32)"`
 	RejectPassword bool   `json:"reject_password,omitempty"`
+
+	FMMaxTransferSize int64 `json:"fm_max_transfer_size,omitempty"` // 文件管理单次传输的最大字节数，0 表示使用全局设置
 }
 
 type UserInfo struct {
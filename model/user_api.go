@@ -4,6 +4,12 @@ type UserForm struct {
 	Role     uint8  `json:"role,omitempty"`
 	Username string `json:"username,omitempty"`
 	Password string `json:"password,omitempty" gorm:"type:char(72)"`
+
+	FMMaxTransferSize int64 `json:"fm_max_transfer_size,omitempty" validate:"optional"` // 文件管理单次传输的最大字节数，0 表示使用全局设置
+}
+
+type UserFMLimitForm struct {
+	FMMaxTransferSize int64 `json:"fm_max_transfer_size,omitempty" validate:"optional"` // 0 表示使用全局设置
 }
 
 type ProfileForm struct {
@@ -16,6 +16,7 @@ const (
 	WAFBlockReasonTypeAgentAuthFail
 	WAFBlockReasonTypeManual
 	WAFBlockReasonTypeBruteForceOauth2
+	WAFBlockReasonTypeNATAuthFail
 )
 
 const (
@@ -23,6 +24,7 @@ const (
 	BlockIDToken
 	BlockIDUnknownUser
 	BlockIDManual
+	BlockIDNAT
 )
 
 type WAFApiMock struct {
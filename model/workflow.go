@@ -0,0 +1,116 @@
+package model
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// 工作流连线的执行条件
+const (
+	WorkflowEdgeOnSuccess = iota
+	WorkflowEdgeOnFailure
+	WorkflowEdgeAlways
+)
+
+// 工作流及其步骤的执行状态
+const (
+	WorkflowStatusPending = iota
+	WorkflowStatusRunning
+	WorkflowStatusSucceeded
+	WorkflowStatusFailed
+	WorkflowStatusSkipped // 前置条件不满足，未执行
+)
+
+// WorkflowStep 工作流中的一个步骤，执行一个已有计划任务的命令
+type WorkflowStep struct {
+	ID      string   `json:"id"`                // 步骤标识，在工作流内唯一
+	CronID  uint64   `json:"cron_id"`           // 执行的计划任务
+	Servers []uint64 `json:"servers,omitempty"` // 指定执行的服务器，留空则使用计划任务的覆盖范围
+
+	ServerGroups []uint64 `json:"server_groups,omitempty"` // 指定执行的服务器分组，与 Servers 合并
+
+	Parameters map[string]string `json:"parameters,omitempty"` // 覆盖计划任务参数的默认值
+}
+
+// WorkflowEdge 步骤之间的依赖，From 执行结果满足 Condition 时才会执行 To
+type WorkflowEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Condition uint8  `json:"condition"` // 0:成功时 1:失败时 2:总是
+}
+
+type Workflow struct {
+	Common
+	Name                string `json:"name"`
+	Scheduler           string `json:"scheduler,omitempty"` // 定时执行，留空则仅手动或由报警触发
+	NotificationGroupID uint64 `json:"notification_group_id"`
+	PushSuccessful      bool   `json:"push_successful,omitempty"`
+
+	Steps    []WorkflowStep `gorm:"-" json:"steps"`
+	Edges    []WorkflowEdge `gorm:"-" json:"edges"`
+	StepsRaw string         `gorm:"type:text" json:"-"`
+	EdgesRaw string         `gorm:"type:text" json:"-"`
+
+	CronJobID cron.EntryID `gorm:"-" json:"-"`
+}
+
+func (w *Workflow) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(w.Steps); err != nil {
+		return err
+	} else {
+		w.StepsRaw = string(data)
+	}
+	if data, err := json.Marshal(w.Edges); err != nil {
+		return err
+	} else {
+		w.EdgesRaw = string(data)
+	}
+	return nil
+}
+
+func (w *Workflow) AfterFind(tx *gorm.DB) error {
+	if err := json.Unmarshal([]byte(w.StepsRaw), &w.Steps); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(w.EdgesRaw), &w.Edges)
+}
+
+// WorkflowStepRun 单个步骤的执行情况
+type WorkflowStepRun struct {
+	StepID       string     `json:"step_id"`
+	Status       uint8      `json:"status"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	ExecutionIDs []uint64   `json:"execution_ids,omitempty"` // 对应的计划任务执行记录
+}
+
+// WorkflowRun 工作流的一次执行记录
+type WorkflowRun struct {
+	ID            uint64     `gorm:"primaryKey" json:"id,omitempty"`
+	CreatedAt     time.Time  `gorm:"index;<-:create" json:"created_at,omitempty"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at,omitempty"`
+	WorkflowID    uint64     `gorm:"index" json:"workflow_id"`
+	Trigger       uint8      `json:"trigger"`                  // 0:计划 1:手动 2:报警规则
+	TriggerServer uint64     `json:"trigger_server,omitempty"` // 触发报警的服务器
+	Status        uint8      `json:"status"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+
+	Steps    []WorkflowStepRun `gorm:"-" json:"steps"`
+	StepsRaw string            `gorm:"type:mediumtext" json:"-"`
+}
+
+func (r *WorkflowRun) BeforeSave(tx *gorm.DB) error {
+	if data, err := json.Marshal(r.Steps); err != nil {
+		return err
+	} else {
+		r.StepsRaw = string(data)
+	}
+	return nil
+}
+
+func (r *WorkflowRun) AfterFind(tx *gorm.DB) error {
+	return json.Unmarshal([]byte(r.StepsRaw), &r.Steps)
+}
@@ -0,0 +1,10 @@
+package model
+
+type WorkflowForm struct {
+	Name                string         `json:"name" minLength:"1"`
+	Scheduler           string         `json:"scheduler,omitempty" validate:"optional"`
+	NotificationGroupID uint64         `json:"notification_group_id,omitempty"`
+	PushSuccessful      bool           `json:"push_successful,omitempty" validate:"optional"`
+	Steps               []WorkflowStep `json:"steps"`
+	Edges               []WorkflowEdge `json:"edges"`
+}
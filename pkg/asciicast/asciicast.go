@@ -0,0 +1,108 @@
+// Package asciicast 以 asciicast v2 格式录制终端会话
+// https://docs.asciinema.org/manual/asciicast/v2/
+package asciicast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r"
+)
+
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+type Writer struct {
+	mu    sync.Mutex
+	w     *bufio.Writer
+	start time.Time
+	carry map[string][]byte // 被截断的 UTF-8 字符，与下一次同类事件合并
+}
+
+// NewWriter 写入文件头，时间戳与版本号未设置时自动填充
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	now := time.Now()
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = now.Unix()
+	}
+
+	cw := &Writer{
+		w:     bufio.NewWriter(w),
+		start: now,
+		carry: make(map[string][]byte),
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cw.w.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (w *Writer) Output(p []byte) error {
+	return w.event(EventOutput, p)
+}
+
+func (w *Writer) Input(p []byte) error {
+	return w.event(EventInput, p)
+}
+
+func (w *Writer) Resize(cols, rows int) error {
+	return w.event(EventResize, []byte(fmt.Sprintf("%dx%d", cols, rows)))
+}
+
+func (w *Writer) event(typ string, p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.carry[typ], p...)
+	data, w.carry[typ] = splitIncomplete(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	line, err := json.Marshal([]any{time.Since(w.start).Round(time.Microsecond).Seconds(), typ, string(data)})
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(line, '\n'))
+	return err
+}
+
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Flush()
+}
+
+// splitIncomplete 拆分出末尾不完整的 UTF-8 字符
+func splitIncomplete(p []byte) ([]byte, []byte) {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return p[:i], append([]byte(nil), p[i:]...)
+			}
+			break
+		}
+	}
+	return p, nil
+}
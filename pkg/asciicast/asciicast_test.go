@@ -0,0 +1,64 @@
+package asciicast
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header{Width: 80, Height: 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// “你”被拆分到两次输出中
+	s := []byte("ls\r\n你好")
+	if err := w.Output(s[:6]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Output(s[6:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Input([]byte("q")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Resize(120, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, but got %d: %s", len(lines), buf.String())
+	}
+
+	var header Header
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Timestamp == 0 {
+		t.Fatalf("Unexpected header: %+v", header)
+	}
+
+	want := []struct{ typ, data string }{
+		{EventOutput, "ls\r\n"},
+		{EventOutput, "你好"},
+		{EventInput, "q"},
+		{EventResize, "120x40"},
+	}
+	for i, w := range want {
+		var event []any
+		if err := json.Unmarshal([]byte(lines[i+1]), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event[1] != w.typ || event[2] != w.data {
+			t.Fatalf("Expected %s %q, but got %v", w.typ, w.data, event)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/libdns/cloudflare"
+	"github.com/libdns/libdns"
+
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+var baseURL = "https://api.cloudflare.com/client/v4"
+
+// Provider 在 libdns/cloudflare 的基础上设置 A/AAAA 记录的代理状态，
+// libdns/cloudflare 创建的记录总是仅 DNS，更新时也不会修改代理状态
+type Provider struct {
+	cloudflare.Provider
+	// Proxied 为空时保留记录原有的代理状态
+	Proxied *bool
+
+	zones   map[string]string // 区域名 -> 区域 ID
+	zonesMu sync.Mutex
+}
+
+type response struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result json.RawMessage `json:"result"`
+}
+
+func (p *Provider) SetRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	results, err := p.Provider.SetRecords(ctx, zone, recs)
+	if err != nil || p.Proxied == nil {
+		return results, err
+	}
+
+	zoneID, err := p.zoneID(ctx, zone)
+	if err != nil {
+		return results, err
+	}
+	for _, rec := range results {
+		if rec.Type != "A" && rec.Type != "AAAA" {
+			continue
+		}
+		if err := p.setProxied(ctx, zoneID, rec.ID, *p.Proxied); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func (p *Provider) zoneID(ctx context.Context, zone string) (string, error) {
+	p.zonesMu.Lock()
+	defer p.zonesMu.Unlock()
+
+	name := strings.TrimSuffix(zone, ".")
+	if id, ok := p.zones[name]; ok {
+		return id, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/zones?"+url.Values{"name": {name}}.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	var zones []struct {
+		ID string `json:"id"`
+	}
+	if err := p.do(req, &zones); err != nil {
+		return "", err
+	}
+	if len(zones) != 1 {
+		return "", fmt.Errorf("expected 1 zone, got %d for %s", len(zones), name)
+	}
+
+	if p.zones == nil {
+		p.zones = make(map[string]string)
+	}
+	p.zones[name] = zones[0].ID
+	return zones[0].ID, nil
+}
+
+func (p *Provider) setProxied(ctx context.Context, zoneID, recordID string, proxied bool) error {
+	body, err := json.Marshal(map[string]bool{"proxied": proxied})
+	if err != nil {
+		return err
+	}
+
+	reqURL := fmt.Sprintf("%s/zones/%s/dns_records/%s", baseURL, zoneID, recordID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return p.do(req, nil)
+}
+
+func (p *Provider) do(req *http.Request, result any) error {
+	req.Header.Set("Authorization", "Bearer "+p.APIToken)
+
+	resp, err := utils.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 || !r.Success {
+		return fmt.Errorf("cloudflare: HTTP %d: %+v", resp.StatusCode, r.Errors)
+	}
+	if result != nil {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
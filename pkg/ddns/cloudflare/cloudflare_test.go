@@ -0,0 +1,58 @@
+package cloudflare
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetProxied(t *testing.T) {
+	var patched string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Unexpected authorization: %s", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones":
+			if name := r.URL.Query().Get("name"); name != "example.com" {
+				t.Errorf("Unexpected zone name: %s", name)
+			}
+			io.WriteString(w, `{"success":true,"errors":[],"result":[{"id":"zone1"}]}`)
+		case r.Method == http.MethodPatch && r.URL.Path == "/zones/zone1/dns_records/rec1":
+			body, _ := io.ReadAll(r.Body)
+			patched = string(body)
+			io.WriteString(w, `{"success":true,"errors":[],"result":{}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"success":false,"errors":[{"code":7003,"message":"not found"}]}`)
+		}
+	}))
+	defer srv.Close()
+
+	baseURL = srv.URL
+	defer func() { baseURL = "https://api.cloudflare.com/client/v4" }()
+
+	p := &Provider{}
+	p.APIToken = "token"
+
+	zoneID, err := p.zoneID(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if zoneID != "zone1" {
+		t.Fatalf("Expected zone1, but got %s", zoneID)
+	}
+
+	if err := p.setProxied(context.Background(), zoneID, "rec1", true); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if patched != `{"proxied":true}` {
+		t.Fatalf("Unexpected body: %s", patched)
+	}
+
+	if err := p.setProxied(context.Background(), zoneID, "missing", false); err == nil {
+		t.Fatal("Expected error for missing record")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +19,8 @@ import (
 var (
 	dnsTimeOut       = 10 * time.Second
 	customDNSServers []string
+
+	authoritativePort = "53"
 )
 
 type Provider struct {
@@ -25,10 +29,18 @@ type Provider struct {
 	recordType string
 	prefix     string
 	zone       string
+	domain     string
 
 	DDNSProfile *model.DDNSProfile
 	IPAddrs     *model.IP
 	Setter      libdns.RecordSetter
+
+	// Members 不为空时为分组模式，发布所有成员的 IP
+	Members  []*model.IP
+	ServerID uint64
+	Trigger  uint8
+	// OnUpdate 不为空时，每次更新尝试结束后都会被调用
+	OnUpdate func(*model.DDNSUpdateLog)
 }
 
 func InitDNSServers(s string) {
@@ -58,24 +70,25 @@ func (provider *Provider) UpdateDomain(ctx context.Context, overrideDomains ...s
 
 func (provider *Provider) updateDomain(domain string) error {
 	var err error
+	provider.domain = domain
 	provider.prefix, provider.zone, err = splitDomainSOA(domain)
 	if err != nil {
+		provider.recordType, provider.ipAddr = "", ""
+		provider.report(nil, err)
 		return err
 	}
 
 	// 当IPv4和IPv6同时成功才算作成功
 	if *provider.DDNSProfile.EnableIPv4 {
 		provider.recordType = getRecordString(true)
-		provider.ipAddr = provider.IPAddrs.IPv4Addr
-		if err = provider.addDomainRecord(); err != nil {
+		if err = provider.addDomainRecord(provider.values(true)); err != nil {
 			return err
 		}
 	}
 
 	if *provider.DDNSProfile.EnableIPv6 {
 		provider.recordType = getRecordString(false)
-		provider.ipAddr = provider.IPAddrs.IPv6Addr
-		if err = provider.addDomainRecord(); err != nil {
+		if err = provider.addDomainRecord(provider.values(false)); err != nil {
 			return err
 		}
 	}
@@ -83,29 +96,178 @@ func (provider *Provider) updateDomain(domain string) error {
 	return nil
 }
 
-func (provider *Provider) addDomainRecord() error {
-	_, err := provider.Setter.SetRecords(provider.ctx, provider.zone,
-		[]libdns.Record{
-			{
-				Type:  provider.recordType,
-				Name:  provider.prefix,
-				Value: provider.ipAddr,
-				TTL:   time.Minute,
-			},
+// values 返回需要发布的记录值，分组模式下为所有成员的 IP
+func (provider *Provider) values(isIpv4 bool) []string {
+	addrs := []*model.IP{provider.IPAddrs}
+	if provider.Members != nil {
+		addrs = provider.Members
+	}
+
+	values := make([]string, 0, len(addrs))
+	for _, ip := range addrs {
+		v := utils.IfOr(isIpv4, ip.IPv4Addr, ip.IPv6Addr)
+		if v != "" && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values)
+	return values
+}
+
+func (provider *Provider) addDomainRecord(values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	provider.ipAddr = strings.Join(values, ",")
+
+	var old []string
+	if provider.OnUpdate != nil {
+		old, _ = Resolve(provider.domain, provider.recordType)
+	}
+
+	ttl := time.Minute
+	if provider.DDNSProfile.TTL > 0 {
+		ttl = time.Duration(provider.DDNSProfile.TTL) * time.Second
+	}
+
+	recs := make([]libdns.Record, 0, len(values))
+	for _, v := range values {
+		recs = append(recs, libdns.Record{
+			Type:  provider.recordType,
+			Name:  provider.prefix,
+			Value: v,
+			TTL:   ttl,
 		})
+	}
+
+	_, err := provider.Setter.SetRecords(provider.ctx, provider.zone, recs)
+	provider.report(old, err)
 	return err
 }
 
+func (provider *Provider) report(old []string, err error) {
+	if provider.OnUpdate == nil {
+		return
+	}
+
+	l := &model.DDNSUpdateLog{
+		ProfileID:  provider.DDNSProfile.ID,
+		ServerID:   provider.ServerID,
+		Domain:     provider.domain,
+		RecordType: provider.recordType,
+		OldValue:   strings.Join(old, ","),
+		NewValue:   provider.ipAddr,
+		Trigger:    provider.Trigger,
+		Success:    err == nil,
+	}
+	if err != nil {
+		l.Error = err.Error()
+	}
+	provider.OnUpdate(l)
+}
+
+// Resolve 向域名所在区域的权威服务器查询当前发布的 A 或 AAAA 记录，避免递归服务器的缓存造成误判。
+// 找不到或无法连接权威服务器时退回到配置的 DNS 服务器
+func Resolve(domain, recordType string) ([]string, error) {
+	if servers, err := authoritativeServers(domain); err == nil && len(servers) > 0 {
+		if values, err := query(servers, domain, recordType, false); err == nil {
+			return values, nil
+		}
+	}
+	return query(dnsServers(), domain, recordType, true)
+}
+
+// authoritativeServers 通过配置的 DNS 服务器查找域名所在区域的权威服务器地址
+func authoritativeServers(domain string) ([]string, error) {
+	_, zone, err := splitDomainSOA(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &dns.Client{Timeout: dnsTimeOut}
+
+	var m dns.Msg
+	m.SetQuestion(zone, dns.TypeNS)
+
+	for _, server := range dnsServers() {
+		var r *dns.Msg
+		r, _, err = c.Exchange(&m, server)
+		if err != nil {
+			continue
+		}
+		if r.Rcode != dns.RcodeSuccess {
+			err = fmt.Errorf("query NS %s on %s: %s", zone, server, dns.RcodeToString[r.Rcode])
+			continue
+		}
+
+		var servers []string
+		for _, rr := range r.Answer {
+			ns, ok := rr.(*dns.NS)
+			if !ok {
+				continue
+			}
+			for _, recordType := range []string{"A", "AAAA"} {
+				addrs, _ := query(dnsServers(), ns.Ns, recordType, true)
+				for _, addr := range addrs {
+					servers = append(servers, net.JoinHostPort(addr, authoritativePort))
+				}
+			}
+		}
+		return servers, nil
+	}
+
+	return nil, err
+}
+
+// query 依次向 servers 查询记录，recursive 为 false 时不要求服务器递归解析
+func query(servers []string, domain, recordType string, recursive bool) ([]string, error) {
+	c := &dns.Client{Timeout: dnsTimeOut}
+
+	var m dns.Msg
+	m.SetQuestion(dns.Fqdn(domain), dns.StringToType[recordType])
+	m.RecursionDesired = recursive
+
+	var err error
+	for _, server := range servers {
+		var r *dns.Msg
+		r, _, err = c.Exchange(&m, server)
+		if err != nil {
+			continue
+		}
+		if r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError {
+			err = fmt.Errorf("query %s %s on %s: %s", recordType, domain, server, dns.RcodeToString[r.Rcode])
+			continue
+		}
+
+		values := make([]string, 0, len(r.Answer))
+		for _, rr := range r.Answer {
+			switch v := rr.(type) {
+			case *dns.A:
+				values = append(values, v.A.String())
+			case *dns.AAAA:
+				values = append(values, v.AAAA.String())
+			}
+		}
+		return values, nil
+	}
+
+	return nil, err
+}
+
+func dnsServers() []string {
+	if len(customDNSServers) > 0 {
+		return customDNSServers
+	}
+	return utils.DNSServers
+}
+
 func splitDomainSOA(domain string) (prefix string, zone string, err error) {
 	c := &dns.Client{Timeout: dnsTimeOut}
 
 	domain += "."
 	indexes := dns.Split(domain)
 
-	servers := utils.DNSServers
-	if len(customDNSServers) > 0 {
-		servers = customDNSServers
-	}
+	servers := dnsServers()
 
 	var r *dns.Msg
 	for _, idx := range indexes {
@@ -1,8 +1,12 @@
 package ddns
 
 import (
+	"net"
 	"os"
+	"slices"
 	"testing"
+
+	"github.com/miekg/dns"
 )
 
 type testSt struct {
@@ -47,3 +51,68 @@ func TestSplitDomainSOA(t *testing.T) {
 		}
 	}
 }
+
+func TestResolve(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	srv := &dns.Server{
+		PacketConn:        pc,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			q := r.Question[0]
+			switch {
+			case q.Qtype == dns.TypeSOA && q.Name == "example.internal.":
+				rr, _ := dns.NewRR("example.internal. 60 IN SOA ns1.example.internal. admin.example.internal. 1 60 60 60 60")
+				m.Answer = append(m.Answer, rr)
+			case q.Qtype == dns.TypeNS && q.Name == "example.internal.":
+				rr, _ := dns.NewRR("example.internal. 60 IN NS ns1.example.internal.")
+				m.Answer = append(m.Answer, rr)
+			case q.Qtype == dns.TypeA && q.Name == "ns1.example.internal.":
+				rr, _ := dns.NewRR(q.Name + " 60 IN A 127.0.0.1")
+				m.Answer = append(m.Answer, rr)
+			case q.Name != "www.example.internal.":
+				m.Rcode = dns.RcodeNameError
+			case q.Qtype == dns.TypeA:
+				// 递归查询返回缓存中的旧记录，只有权威查询能拿到当前记录
+				values := []string{"192.0.2.100"}
+				if !r.RecursionDesired {
+					values = []string{"192.0.2.1", "192.0.2.2"}
+				}
+				for _, v := range values {
+					rr, _ := dns.NewRR(q.Name + " 60 IN A " + v)
+					m.Answer = append(m.Answer, rr)
+				}
+			}
+			w.WriteMsg(m)
+		}),
+	}
+	go srv.ActivateAndServe()
+	defer srv.Shutdown()
+	<-started
+
+	customDNSServers = []string{pc.LocalAddr().String()}
+	defer func() { customDNSServers = nil }()
+
+	_, port, _ := net.SplitHostPort(pc.LocalAddr().String())
+	authoritativePort = port
+	defer func() { authoritativePort = "53" }()
+
+	values, err := Resolve("www.example.internal", "A")
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if !slices.Equal(values, []string{"192.0.2.1", "192.0.2.2"}) {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+
+	values, err = Resolve("missing.example.internal", "A")
+	if err != nil || len(values) != 0 {
+		t.Fatalf("Expected empty result for NXDOMAIN, got %v, %v", values, err)
+	}
+}
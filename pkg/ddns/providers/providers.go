@@ -0,0 +1,135 @@
+// Package providers 注册内置的 DDNS 提供者，使用方以空白导入方式引入
+package providers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/libdns/alidns"
+	libdnscloudflare "github.com/libdns/cloudflare"
+	"github.com/libdns/duckdns"
+	"github.com/libdns/gandi"
+	"github.com/libdns/godaddy"
+	"github.com/libdns/libdns"
+	tencentcloud "github.com/nezhahq/libdns-tencentcloud"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/ddns"
+	"github.com/telexy324/billabong/pkg/ddns/cloudflare"
+	"github.com/telexy324/billabong/pkg/ddns/dummy"
+	"github.com/telexy324/billabong/pkg/ddns/rfc2136"
+	"github.com/telexy324/billabong/pkg/ddns/webhook"
+)
+
+func init() {
+	ddns.Register(ddns.ProviderInfo{
+		Name:       model.ProviderDummy,
+		MultiValue: true,
+	}, func(*model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &dummy.Provider{}, nil
+	})
+
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderWebHook,
+		Fields: []ddns.Field{
+			{Name: "webhook_url"},
+			{Name: "webhook_method", Optional: true},
+			{Name: "webhook_request_type", Optional: true},
+			{Name: "webhook_request_body", Optional: true},
+			{Name: "webhook_headers", Optional: true},
+		},
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &webhook.Provider{DDNSProfile: p}, nil
+	})
+
+	// proxied 为 true 或 false 时每次更新都会设置记录的代理状态，留空则保留原有状态。
+	// 开启代理的记录解析结果为 Cloudflare 的边缘节点 IP，不做漂移校验
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderCloudflare,
+		Fields: []ddns.Field{
+			{Name: "access_secret", Secret: true},
+			{Name: "proxied", Optional: true},
+		},
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		provider := &cloudflare.Provider{Provider: libdnscloudflare.Provider{APIToken: p.AccessSecret}}
+		if v := p.Config["proxied"]; v != "" {
+			proxied, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxied %q: %w", v, err)
+			}
+			provider.Proxied = &proxied
+		}
+		return provider, nil
+	})
+
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderTencentCloud,
+		Fields: []ddns.Field{
+			{Name: "access_id"},
+			{Name: "access_secret", Secret: true},
+		},
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &tencentcloud.Provider{SecretId: p.AccessID, SecretKey: p.AccessSecret}, nil
+	})
+
+	// access_id 为 TSIG 密钥名，access_secret 为 base64 编码的密钥，不填则发送未签名的更新
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderRFC2136,
+		Fields: []ddns.Field{
+			{Name: "server"},
+			{Name: "net", Optional: true},
+			{Name: "access_id", Optional: true},
+			{Name: "access_secret", Secret: true, Optional: true},
+			{Name: "tsig_algorithm", Optional: true},
+		},
+		MultiValue: true,
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &rfc2136.Provider{
+			Server:       p.Config["server"],
+			Net:          p.Config["net"],
+			KeyName:      p.AccessID,
+			KeyAlgorithm: p.Config["tsig_algorithm"],
+			KeySecret:    p.AccessSecret,
+		}, nil
+	})
+
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderAliDNS,
+		Fields: []ddns.Field{
+			{Name: "access_id"},
+			{Name: "access_secret", Secret: true},
+			{Name: "region_id", Optional: true},
+		},
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &alidns.Provider{AccKeyID: p.AccessID, AccKeySecret: p.AccessSecret, RegionID: p.Config["region_id"]}, nil
+	})
+
+	// access_secret 格式为 key:secret
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderGoDaddy,
+		Fields: []ddns.Field{
+			{Name: "access_secret", Secret: true},
+		},
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &godaddy.Provider{APIToken: p.AccessSecret}, nil
+	})
+
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderGandi,
+		Fields: []ddns.Field{
+			{Name: "access_secret", Secret: true},
+		},
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &gandi.Provider{BearerToken: p.AccessSecret}, nil
+	})
+
+	ddns.Register(ddns.ProviderInfo{
+		Name: model.ProviderDuckDNS,
+		Fields: []ddns.Field{
+			{Name: "access_secret", Secret: true},
+			{Name: "override_domain", Optional: true},
+		},
+	}, func(p *model.DDNSProfile) (libdns.RecordSetter, error) {
+		return &duckdns.Provider{APIToken: p.AccessSecret, OverrideDomain: p.Config["override_domain"]}, nil
+	})
+}
@@ -0,0 +1,140 @@
+package ddns
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/libdns/libdns"
+
+	"github.com/telexy324/billabong/model"
+)
+
+// Field 描述提供者需要的一项凭据或参数
+type Field struct {
+	// Name 为 access_id、access_secret 及 webhook_* 时对应 DDNSProfile 上的同名字段，
+	// 其余保存在 DDNSProfile.Config 中
+	Name     string `json:"name"`
+	Secret   bool   `json:"secret,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type ProviderInfo struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields"`
+	// MultiValue 表示同名同类型的多条记录会作为一个记录集整体写入，可用于服务器分组轮询解析
+	MultiValue bool `json:"multi_value,omitempty"`
+}
+
+type Factory func(profile *model.DDNSProfile) (libdns.RecordSetter, error)
+
+type registration struct {
+	info    ProviderInfo
+	factory Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+)
+
+// Register 注册一个 DDNS 提供者，重复注册同名提供者会 panic
+func Register(info ProviderInfo, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[info.Name]; ok {
+		panic("ddns: provider registered twice: " + info.Name)
+	}
+	registry[info.Name] = registration{info: info, factory: factory}
+}
+
+// Providers 按名称排序返回所有已注册的提供者
+func Providers() []ProviderInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]ProviderInfo, 0, len(registry))
+	for _, r := range registry {
+		list = append(list, r.info)
+	}
+	slices.SortFunc(list, func(a, b ProviderInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+func lookup(name string) (registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registry[name]
+	return r, ok
+}
+
+// Registered 判断提供者是否存在
+func Registered(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+// SupportsMultiValue 判断提供者能否发布多值记录集
+func SupportsMultiValue(name string) bool {
+	r, ok := lookup(name)
+	return ok && r.info.MultiValue
+}
+
+// MissingField 返回配置中第一个缺失的必填字段，全部填写时返回空字符串
+func MissingField(profile *model.DDNSProfile) string {
+	r, ok := lookup(profile.Provider)
+	if !ok {
+		return ""
+	}
+	for _, f := range r.info.Fields {
+		if !f.Optional && Credential(profile, f.Name) == "" {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// NewSetter 根据配置中的提供者名称创建对应的 RecordSetter
+func NewSetter(profile *model.DDNSProfile) (libdns.RecordSetter, error) {
+	r, ok := lookup(profile.Provider)
+	if !ok {
+		return nil, fmt.Errorf("无法找到配置的DDNS提供者 %s", profile.Provider)
+	}
+	if f := MissingField(profile); f != "" {
+		return nil, fmt.Errorf("DDNS配置 %s 缺少字段 %s", profile.Name, f)
+	}
+	return r.factory(profile)
+}
+
+// Credential 读取配置中名为 name 的字段
+func Credential(profile *model.DDNSProfile, name string) string {
+	switch name {
+	case "access_id":
+		return profile.AccessID
+	case "access_secret":
+		return profile.AccessSecret
+	case "webhook_url":
+		return profile.WebhookURL
+	case "webhook_method":
+		return formatUint8(profile.WebhookMethod)
+	case "webhook_request_type":
+		return formatUint8(profile.WebhookRequestType)
+	case "webhook_request_body":
+		return profile.WebhookRequestBody
+	case "webhook_headers":
+		return profile.WebhookHeaders
+	}
+	return profile.Config[name]
+}
+
+func formatUint8(v uint8) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
@@ -0,0 +1,114 @@
+package rfc2136
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Provider 通过 RFC 2136 动态更新（可选 TSIG 签名）修改权威服务器上的记录
+type Provider struct {
+	// Server 权威服务器地址，缺省端口为 53
+	Server string
+	// Net 传输协议，udp 或 tcp，缺省为 udp
+	Net string
+
+	KeyName      string
+	KeyAlgorithm string
+	// KeySecret 为 base64 编码的 TSIG 密钥
+	KeySecret string
+}
+
+func (provider *Provider) SetRecords(ctx context.Context, zone string,
+	recs []libdns.Record) ([]libdns.Record, error) {
+	zone = dns.Fqdn(zone)
+
+	m := new(dns.Msg)
+	m.SetUpdate(zone)
+	rrs := make([]dns.RR, 0, len(recs))
+	for _, rec := range recs {
+		rr, err := toRR(zone, rec)
+		if err != nil {
+			return nil, err
+		}
+		rrs = append(rrs, rr)
+	}
+	// 先删除涉及的记录集，再写入全部新值，同名同类型的多条记录组成一个记录集
+	m.RemoveRRset(rrs)
+	m.Insert(rrs)
+
+	c := &dns.Client{Net: provider.Net, Timeout: defaultTimeout}
+	if provider.KeyName != "" {
+		algo, err := tsigAlgorithm(provider.KeyAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		keyName := dns.Fqdn(provider.KeyName)
+		c.TsigSecret = map[string]string{keyName: provider.KeySecret}
+		m.SetTsig(keyName, algo, 300, time.Now().Unix())
+	}
+
+	r, _, err := c.ExchangeContext(ctx, m, provider.server())
+	if err != nil {
+		return nil, err
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("dns update of zone %s rejected: %s", zone, dns.RcodeToString[r.Rcode])
+	}
+
+	return recs, nil
+}
+
+func (provider *Provider) server() string {
+	if _, _, err := net.SplitHostPort(provider.Server); err == nil {
+		return provider.Server
+	}
+	return net.JoinHostPort(strings.Trim(provider.Server, "[]"), "53")
+}
+
+func toRR(zone string, rec libdns.Record) (dns.RR, error) {
+	rrType, ok := dns.StringToType[strings.ToUpper(rec.Type)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type: %s", rec.Type)
+	}
+
+	ttl := uint32(rec.TTL / time.Second)
+	if ttl == 0 {
+		ttl = 60
+	}
+
+	hdr := dns.RR_Header{
+		Name:   libdns.AbsoluteName(rec.Name, zone),
+		Rrtype: rrType,
+		Class:  dns.ClassINET,
+		Ttl:    ttl,
+	}
+	rr, err := dns.NewRR(hdr.String() + rec.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s record %s: %v", rec.Type, rec.Value, err)
+	}
+	return rr, nil
+}
+
+func tsigAlgorithm(s string) (string, error) {
+	switch strings.TrimSuffix(strings.ToLower(s), ".") {
+	case "", "hmac-sha256":
+		return dns.HmacSHA256, nil
+	case "hmac-sha1":
+		return dns.HmacSHA1, nil
+	case "hmac-sha224":
+		return dns.HmacSHA224, nil
+	case "hmac-sha384":
+		return dns.HmacSHA384, nil
+	case "hmac-sha512":
+		return dns.HmacSHA512, nil
+	}
+	return "", fmt.Errorf("unsupported TSIG algorithm: %s", s)
+}
@@ -0,0 +1,100 @@
+package rfc2136
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+const (
+	testKeyName   = "ddns-key."
+	testKeySecret = "c2VjcmV0LXRzaWcta2V5LWZvci10ZXN0aW5nLW9ubHk="
+)
+
+type testServer struct {
+	mu      sync.Mutex
+	updates []dns.RR
+	addr    string
+}
+
+func startServer(t *testing.T) *testServer {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := &testServer{addr: pc.LocalAddr().String()}
+	started := make(chan struct{})
+	srv := &dns.Server{
+		PacketConn:        pc,
+		TsigSecret:        map[string]string{testKeyName: testKeySecret},
+		NotifyStartedFunc: func() { close(started) },
+		// 默认的 MsgAcceptFunc 会拒绝 UPDATE 报文
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			if r.IsTsig() == nil || w.TsigStatus() != nil {
+				m.Rcode = dns.RcodeNotAuth
+			} else {
+				ts.mu.Lock()
+				ts.updates = append(ts.updates, r.Ns...)
+				ts.mu.Unlock()
+				m.SetTsig(testKeyName, dns.HmacSHA256, 300, time.Now().Unix())
+			}
+			w.WriteMsg(m)
+		}),
+	}
+	go srv.ActivateAndServe()
+	t.Cleanup(func() { srv.Shutdown() })
+	<-started
+	return ts
+}
+
+func TestSetRecords(t *testing.T) {
+	ts := startServer(t)
+
+	p := &Provider{Server: ts.addr, KeyName: "ddns-key", KeyAlgorithm: "hmac-sha256", KeySecret: testKeySecret}
+	_, err := p.SetRecords(context.Background(), "example.internal.", []libdns.Record{
+		{Type: "A", Name: "www", Value: "192.0.2.1", TTL: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("SetRecords: %v", err)
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if len(ts.updates) != 2 {
+		t.Fatalf("expected 2 update RRs, got %d", len(ts.updates))
+	}
+	if ts.updates[0].Header().Class != dns.ClassANY {
+		t.Errorf("expected RRset removal first, got %s", ts.updates[0])
+	}
+	a, ok := ts.updates[1].(*dns.A)
+	if !ok || a.Hdr.Name != "www.example.internal." || a.A.String() != "192.0.2.1" || a.Hdr.Ttl != 60 {
+		t.Errorf("unexpected inserted record: %s", ts.updates[1])
+	}
+}
+
+func TestSetRecordsBadKey(t *testing.T) {
+	ts := startServer(t)
+
+	p := &Provider{Server: ts.addr, KeyName: "ddns-key", KeySecret: "d3Jvbmc="}
+	if _, err := p.SetRecords(context.Background(), "example.internal", []libdns.Record{
+		{Type: "AAAA", Name: "www", Value: "2001:db8::1"},
+	}); err == nil {
+		t.Fatal("expected update with wrong key to fail")
+	}
+
+	p = &Provider{Server: ts.addr}
+	if _, err := p.SetRecords(context.Background(), "example.internal", []libdns.Record{
+		{Type: "AAAA", Name: "www", Value: "2001:db8::1"},
+	}); err == nil {
+		t.Fatal("expected unsigned update to fail")
+	}
+}
@@ -0,0 +1,200 @@
+// Package labels 实现服务器标签的校验与标签选择器
+//
+// 选择器由逗号分隔的若干条件组成，全部满足时匹配：
+//
+//	env=prod           等于（也可写作 env==prod）
+//	env!=prod          不等于，标签不存在时同样满足
+//	role in (db,cache) 属于集合
+//	role notin (web)   不属于集合，标签不存在时同样满足
+//	gpu                标签存在
+//	!gpu               标签不存在
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	MaxKeyLength   = 63
+	MaxValueLength = 63
+)
+
+var (
+	keyRe   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	valueRe = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+)
+
+// ValidKey 标签键由字母、数字及 . _ / - 组成，首尾必须为字母或数字
+func ValidKey(k string) bool {
+	return len(k) <= MaxKeyLength && keyRe.MatchString(k)
+}
+
+// ValidValue 标签值可以为空，非空时由字母、数字及 . _ - 组成，首尾必须为字母或数字
+func ValidValue(v string) bool {
+	return len(v) <= MaxValueLength && valueRe.MatchString(v)
+}
+
+// Validate 检查一组标签，返回第一个不合法的键或值
+func Validate(set map[string]string) error {
+	for k, v := range set {
+		if !ValidKey(k) {
+			return fmt.Errorf("invalid label key: %q", k)
+		}
+		if !ValidValue(v) {
+			return fmt.Errorf("invalid value of label %s: %q", k, v)
+		}
+	}
+	return nil
+}
+
+type Operator uint8
+
+const (
+	Equals Operator = iota
+	NotEquals
+	In
+	NotIn
+	Exists
+	DoesNotExist
+)
+
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+func (r *Requirement) Matches(set map[string]string) bool {
+	v, ok := set[r.Key]
+	switch r.Operator {
+	case Equals, In:
+		return ok && slices.Contains(r.Values, v)
+	case NotEquals, NotIn:
+		return !ok || !slices.Contains(r.Values, v)
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+// Selector 为各条件的交集，空选择器匹配所有标签集合
+type Selector []Requirement
+
+func (s Selector) Matches(set map[string]string) bool {
+	for i := range s {
+		if !s[i].Matches(set) {
+			return false
+		}
+	}
+	return true
+}
+
+var setRe = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// Parse 解析选择器表达式
+func Parse(expr string) (Selector, error) {
+	var s Selector
+	for _, term := range splitTerms(expr) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			if strings.TrimSpace(expr) == "" {
+				break
+			}
+			return nil, fmt.Errorf("empty term in selector %q", expr)
+		}
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	var r Requirement
+	if m := setRe.FindStringSubmatch(term); m != nil {
+		r.Key = m[1]
+		r.Operator = In
+		if m[2] == "notin" {
+			r.Operator = NotIn
+		}
+		r.Values = strings.Split(m[3], ",")
+	} else if k, v, ok := strings.Cut(term, "!="); ok {
+		r.Key, r.Operator, r.Values = k, NotEquals, []string{v}
+	} else if k, v, ok := strings.Cut(term, "=="); ok {
+		r.Key, r.Operator, r.Values = k, Equals, []string{v}
+	} else if k, v, ok := strings.Cut(term, "="); ok {
+		r.Key, r.Operator, r.Values = k, Equals, []string{v}
+	} else if k, ok := strings.CutPrefix(term, "!"); ok {
+		r.Key, r.Operator = k, DoesNotExist
+	} else {
+		r.Key, r.Operator = term, Exists
+	}
+
+	r.Key = strings.TrimSpace(r.Key)
+	if !ValidKey(r.Key) {
+		return r, fmt.Errorf("invalid label key %q in %q", r.Key, term)
+	}
+	for i, v := range r.Values {
+		r.Values[i] = strings.TrimSpace(v)
+		if !ValidValue(r.Values[i]) {
+			return r, fmt.Errorf("invalid label value %q in %q", r.Values[i], term)
+		}
+	}
+	return r, nil
+}
+
+// splitTerms 按括号外的逗号切分
+func splitTerms(expr string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, expr[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, expr[start:])
+}
+
+const maxCached = 1024
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cached)
+)
+
+type cached struct {
+	selector Selector
+	err      error
+}
+
+// Match 解析（带缓存）并匹配选择器，表达式不合法时不匹配任何标签集合
+func Match(expr string, set map[string]string) bool {
+	cacheMu.Lock()
+	c, ok := cache[expr]
+	if !ok {
+		if len(cache) >= maxCached {
+			clear(cache)
+		}
+		c.selector, c.err = Parse(expr)
+		cache[expr] = c
+	}
+	cacheMu.Unlock()
+
+	return c.err == nil && c.selector.Matches(set)
+}
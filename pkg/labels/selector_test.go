@@ -0,0 +1,58 @@
+package labels
+
+import "testing"
+
+func TestSelector(t *testing.T) {
+	set := map[string]string{"env": "prod", "role": "db", "gpu": ""}
+
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env==prod", true},
+		{"env = staging", false},
+		{"env!=staging", true},
+		{"zone!=cn", true},
+		{"env=prod,role in (db,cache)", true},
+		{"env=prod, role in (web, cache)", false},
+		{"role notin (web)", true},
+		{"zone notin (cn)", true},
+		{"gpu", true},
+		{"!gpu", false},
+		{"!zone,env", true},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.expr, err)
+		}
+		if got := s.Matches(set); got != c.match {
+			t.Errorf("%q matches = %v, want %v", c.expr, got, c.match)
+		}
+		if got := Match(c.expr, set); got != c.match {
+			t.Errorf("Match(%q) = %v, want %v", c.expr, got, c.match)
+		}
+	}
+
+	for _, expr := range []string{"env=prod,", "=prod", "env=a b", "role in (db,-x)", "-env"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+		if Match(expr, set) {
+			t.Errorf("invalid selector %q should not match", expr)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(map[string]string{"env": "prod", "example.com/tier": "", "a": "b_c.d-1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, set := range []map[string]string{{"": "x"}, {"env ": "x"}, {"env": "a,b"}, {"env": "-x"}} {
+		if Validate(set) == nil {
+			t.Errorf("Validate(%v) should fail", set)
+		}
+	}
+}
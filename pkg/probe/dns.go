@@ -0,0 +1,93 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/telexy324/billabong/model"
+)
+
+// DNS 向解析服务器查询指定记录，opt.Resolver 为空时依次使用 servers
+func DNS(ctx context.Context, target string, opt *model.ServiceDNSCheck, servers []string) *Result {
+	if opt == nil {
+		opt = &model.ServiceDNSCheck{}
+	}
+
+	recordType := strings.ToUpper(opt.RecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+	qtype, ok := dns.StringToType[recordType]
+	if !ok {
+		return failure("unsupported record type %s", opt.RecordType)
+	}
+
+	var m dns.Msg
+	m.SetQuestion(dns.Fqdn(target), qtype)
+	c := new(dns.Client)
+
+	// 面板设置的解析服务器不受 PublicOnly 限制
+	if opt.Resolver != "" {
+		servers = []string{opt.Resolver}
+		c.Dialer = dialer(ctx)
+	}
+
+	var (
+		r   *dns.Msg
+		err error
+	)
+	start := time.Now()
+	for _, server := range servers {
+		host, port := splitHostPort(server, "53")
+		r, _, err = c.ExchangeContext(ctx, &m, net.JoinHostPort(host, port))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return failure("%v", err)
+	}
+	if r == nil {
+		return failure("no resolver available")
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return failure("rcode %s", dns.RcodeToString[r.Rcode])
+	}
+
+	var answers []string
+	for _, rr := range r.Answer {
+		if rr.Header().Rrtype != qtype {
+			continue
+		}
+		answers = append(answers, rdata(rr))
+	}
+	if len(answers) == 0 {
+		return failure("no %s record found for %s", recordType, target)
+	}
+
+	data := strings.Join(answers, ", ")
+	for _, expected := range opt.Expected {
+		if !slices.ContainsFunc(answers, func(a string) bool {
+			return normalizeRData(a) == normalizeRData(expected)
+		}) {
+			return failure("expected %s not in answers: %s", expected, data)
+		}
+	}
+	return success(start, data)
+}
+
+func rdata(rr dns.RR) string {
+	if txt, ok := rr.(*dns.TXT); ok {
+		return strings.Join(txt.Txt, "")
+	}
+	return strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
+
+func normalizeRData(s string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
+}
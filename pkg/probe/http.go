@@ -0,0 +1,106 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+const maxHTTPBodySize = 1024 * 1024
+
+// HTTP 发送自定义请求并校验状态码、关键字与 JSON 路径
+func HTTP(ctx context.Context, target string, opt *model.ServiceHTTPCheck) *Result {
+	if opt == nil {
+		opt = &model.ServiceHTTPCheck{}
+	}
+
+	method := utils.IfOr(opt.Method != "", opt.Method, http.MethodGet)
+	var body io.Reader
+	if opt.Body != "" {
+		body = strings.NewReader(opt.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return failure("%v", err)
+	}
+	for k, v := range opt.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	client := utils.HttpClient
+	if publicOnly(ctx) {
+		// 不经过代理直接连接，以便检查目标地址
+		client = &http.Client{
+			Transport: &http.Transport{DialContext: dialer(ctx).DialContext, DisableKeepAlives: true},
+			Timeout:   utils.HttpClient.Timeout,
+		}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return failure("%s%v", model.ServiceTLSErrorPrefix, certErr)
+		}
+		return failure("%v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBodySize))
+	if err != nil {
+		return failure("%v", err)
+	}
+
+	if len(opt.ExpectedStatus) > 0 {
+		if !slices.Contains(opt.ExpectedStatus, resp.StatusCode) {
+			return failure("unexpected status code %d", resp.StatusCode)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode > 399 {
+		return failure("unexpected status code %d", resp.StatusCode)
+	}
+
+	switch opt.KeywordMode {
+	case model.ServiceKeywordRequired:
+		if !strings.Contains(string(respBody), opt.Keyword) {
+			return failure("keyword %q not found", opt.Keyword)
+		}
+	case model.ServiceKeywordForbidden:
+		if strings.Contains(string(respBody), opt.Keyword) {
+			return failure("forbidden keyword %q found", opt.Keyword)
+		}
+	}
+
+	if opt.JSONPath != "" {
+		v := gjson.GetBytes(respBody, opt.JSONPath)
+		if !v.Exists() {
+			return failure("json path %q not found", opt.JSONPath)
+		}
+		if opt.JSONValue != "" && v.String() != opt.JSONValue {
+			// 不回显响应内容，避免监控记录与通知泄露被探测服务的数据
+			return failure("json path %q does not match the expected value", opt.JSONPath)
+		}
+	}
+
+	var data string
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		data = certInfo(cert.Issuer.CommonName, cert.NotAfter)
+	}
+	return success(start, data)
+}
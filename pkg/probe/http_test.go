@@ -0,0 +1,44 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/telexy324/billabong/model"
+)
+
+func TestHTTP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"status":"ok","data":{"version":"1.0"}}`))
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		path string
+		opt  *model.ServiceHTTPCheck
+		ok   bool
+	}{
+		{"/", nil, true},
+		{"/missing", nil, false},
+		{"/missing", &model.ServiceHTTPCheck{ExpectedStatus: []int{404}}, true},
+		{"/", &model.ServiceHTTPCheck{KeywordMode: model.ServiceKeywordRequired, Keyword: "ok"}, true},
+		{"/", &model.ServiceHTTPCheck{KeywordMode: model.ServiceKeywordRequired, Keyword: "error"}, false},
+		{"/", &model.ServiceHTTPCheck{KeywordMode: model.ServiceKeywordForbidden, Keyword: "ok"}, false},
+		{"/", &model.ServiceHTTPCheck{JSONPath: "data.version", JSONValue: "1.0"}, true},
+		{"/", &model.ServiceHTTPCheck{JSONPath: "data.version", JSONValue: "2.0"}, false},
+		{"/", &model.ServiceHTTPCheck{JSONPath: "data.build"}, false},
+	}
+
+	for _, c := range cases {
+		r := HTTP(context.Background(), ts.URL+c.path, c.opt)
+		if r.Successful != c.ok {
+			t.Fatalf("%s %+v: expected %v, but got %v (%s)", c.path, c.opt, c.ok, r.Successful, r.Data)
+		}
+	}
+}
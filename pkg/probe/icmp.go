@@ -0,0 +1,131 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	icmpCount    = 3
+	icmpProtoV4  = 1
+	icmpProtoV6  = 58
+	icmpDataSize = 32
+)
+
+var icmpSeq atomic.Uint32
+
+// ICMP 向目标发送若干 ICMP Echo 请求，任意一次收到回复即视为成功，延迟取平均值
+func ICMP(ctx context.Context, target string) *Result {
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, target)
+	if err != nil {
+		return failure("%v", err)
+	}
+	if len(ips) == 0 {
+		return failure("no address found for %s", target)
+	}
+	ip := ips[0].IP
+	if publicOnly(ctx) && !isPublicIP(ip) {
+		return failure("%s: %v", ip, errPrivateTarget)
+	}
+
+	conn, dst, err := listenICMP(ip)
+	if err != nil {
+		return failure("%v", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	var total time.Duration
+	var received int
+	for range icmpCount {
+		rtt, err := pingOnce(conn, dst, ip.To4() != nil)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				break
+			}
+			continue
+		}
+		total += rtt
+		received++
+	}
+
+	if received == 0 {
+		return failure("no ICMP reply from %s", ip)
+	}
+	return &Result{
+		Successful: true,
+		Delay:      float32((total / time.Duration(received)).Microseconds()) / 1000,
+	}
+}
+
+// listenICMP 优先使用无需特权的 UDP ICMP 套接字，失败时回退到原始套接字
+func listenICMP(ip net.IP) (*icmp.PacketConn, net.Addr, error) {
+	network, rawNetwork, address := "udp4", "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		network, rawNetwork, address = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	if conn, err := icmp.ListenPacket(network, address); err == nil {
+		return conn, &net.UDPAddr{IP: ip}, nil
+	}
+	conn, err := icmp.ListenPacket(rawNetwork, address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, &net.IPAddr{IP: ip}, nil
+}
+
+func pingOnce(conn *icmp.PacketConn, dst net.Addr, v4 bool) (time.Duration, error) {
+	var reqType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	proto := icmpProtoV4
+	if !v4 {
+		reqType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		proto = icmpProtoV6
+	}
+
+	seq := int(icmpSeq.Add(1) & 0xffff)
+	msg := icmp.Message{
+		Type: reqType,
+		Body: &icmp.Echo{
+			ID:   os.Getpid() & 0xffff,
+			Seq:  seq,
+			Data: make([]byte, icmpDataSize),
+		},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(b, dst); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		reply, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || reply.Type != replyType {
+			continue
+		}
+		// 无特权套接字下内核会改写 ID，因此仅比较序号
+		if echo, ok := reply.Body.(*icmp.Echo); ok && echo.Seq == seq {
+			return time.Since(start), nil
+		}
+	}
+}
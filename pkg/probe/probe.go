@@ -0,0 +1,80 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+var errPrivateTarget = errors.New("target is not a public address")
+
+type publicOnlyKey struct{}
+
+// PublicOnly 返回只允许连接公网地址的上下文，用于执行非管理员的监控，避免借面板探测所在的内网
+func PublicOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, publicOnlyKey{}, true)
+}
+
+func publicOnly(ctx context.Context) bool {
+	v, _ := ctx.Value(publicOnlyKey{}).(bool)
+	return v
+}
+
+// dialer 返回探测使用的拨号器，PublicOnly 时在连接前检查解析得到的地址，重定向与 DNS 重绑定同样受限
+func dialer(ctx context.Context) *net.Dialer {
+	d := &net.Dialer{}
+	if publicOnly(ctx) {
+		d.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errPrivateTarget
+			}
+			return nil
+		}
+	}
+	return d
+}
+
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !cgnat.Contains(ip)
+}
+
+// Result 一次探测的结果，字段与 agent 上报的 TaskResult 保持一致
+type Result struct {
+	Successful bool
+	Delay      float32 // 毫秒
+	Data       string
+}
+
+func success(start time.Time, data string) *Result {
+	return &Result{
+		Successful: true,
+		Delay:      float32(time.Since(start).Microseconds()) / 1000,
+		Data:       data,
+	}
+}
+
+func failure(format string, args ...any) *Result {
+	return &Result{
+		Data: fmt.Sprintf(format, args...),
+	}
+}
+
+// splitHostPort 拆分 host[:port]，未指定端口时使用默认端口
+func splitHostPort(target, defaultPort string) (string, string) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return target, defaultPort
+	}
+	return host, port
+}
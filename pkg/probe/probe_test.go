@@ -0,0 +1,48 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPublicOnly(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	if r := HTTP(context.Background(), ts.URL, nil); !r.Successful {
+		t.Fatalf("Expected success, but got %s", r.Data)
+	}
+	if r := HTTP(PublicOnly(context.Background()), ts.URL, nil); r.Successful || !strings.Contains(r.Data, errPrivateTarget.Error()) {
+		t.Fatalf("Expected loopback target to be rejected, but got %v (%s)", r.Successful, r.Data)
+	}
+
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	if r := TCP(PublicOnly(context.Background()), addr); r.Successful {
+		t.Fatal("Expected loopback target to be rejected")
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	cases := map[string]bool{
+		"1.1.1.1":     true,
+		"2606:4700::": true,
+		"127.0.0.1":   false,
+		"10.1.2.3":    false,
+		"192.168.1.1": false,
+		"169.254.0.1": false,
+		"100.64.0.1":  false,
+		"::1":         false,
+		"fd00::1":     false,
+		"fe80::1":     false,
+		"0.0.0.0":     false,
+	}
+	for s, want := range cases {
+		if got := isPublicIP(net.ParseIP(s)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package probe
+
+import (
+	"context"
+	"time"
+)
+
+// TCP 建立 TCP 连接并统计耗时，target 格式为 host:port
+func TCP(ctx context.Context, target string) *Result {
+	start := time.Now()
+	conn, err := dialer(ctx).DialContext(ctx, "tcp", target)
+	if err != nil {
+		return failure("%v", err)
+	}
+	r := success(start, "")
+	conn.Close()
+	return r
+}
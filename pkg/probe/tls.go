@@ -0,0 +1,47 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+)
+
+const certTimeLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// TLS 仅进行 TLS 握手并检查证书剩余有效期，target 格式为 host[:port]
+func TLS(ctx context.Context, target string, opt *model.ServiceTLSCheck) *Result {
+	host, port := splitHostPort(target, "443")
+
+	start := time.Now()
+	d := &tls.Dialer{NetDialer: dialer(ctx), Config: &tls.Config{ServerName: host}}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return failure("%s%v", model.ServiceTLSErrorPrefix, err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return failure("%sno peer certificate", model.ServiceTLSErrorPrefix)
+	}
+
+	expiryDays := uint32(7)
+	if opt != nil && opt.ExpiryDays > 0 {
+		expiryDays = opt.ExpiryDays
+	}
+
+	r := success(start, certInfo(certs[0].Issuer.CommonName, certs[0].NotAfter))
+	if time.Until(certs[0].NotAfter) < time.Duration(expiryDays)*24*time.Hour {
+		r.Successful = false
+	}
+	return r
+}
+
+// certInfo 按照 agent 的格式输出证书信息：签发者|过期时间
+func certInfo(issuer string, notAfter time.Time) string {
+	return fmt.Sprintf("%s|%s", issuer, notAfter.Format(certTimeLayout))
+}
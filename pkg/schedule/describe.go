@@ -0,0 +1,140 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	monthNames = []string{"", "January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+	weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+	descriptors = map[string]string{
+		"@yearly":   "At 00:00:00 on January 1",
+		"@annually": "At 00:00:00 on January 1",
+		"@monthly":  "At 00:00:00 on day 1 of the month",
+		"@weekly":   "At 00:00:00 on Sunday",
+		"@daily":    "At 00:00:00",
+		"@midnight": "At 00:00:00",
+		"@hourly":   "At minute 0 of every hour",
+	}
+)
+
+// Describe 将带秒字段的 cron 表达式转换为便于阅读的英文描述，表达式需已通过解析校验
+func Describe(spec string) string {
+	spec = strings.TrimSpace(spec)
+	var tz string
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		i := strings.Index(spec, " ")
+		if i < 0 {
+			return spec
+		}
+		tz = spec[strings.Index(spec, "=")+1 : i]
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	desc := describe(spec)
+	if tz != "" {
+		desc += " (" + tz + ")"
+	}
+	return desc
+}
+
+func describe(spec string) string {
+	if d, ok := descriptors[spec]; ok {
+		return d
+	}
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		if d, err := time.ParseDuration(every); err == nil {
+			return "Every " + d.String()
+		}
+		return spec
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 6 {
+		return spec
+	}
+	sec, min, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+
+	var parts []string
+	if isNumber(sec) && isNumber(min) && isNumber(hour) {
+		parts = append(parts, fmt.Sprintf("At %02s:%02s:%02s", hour, min, sec))
+	} else {
+		for _, p := range []string{
+			describeField(sec, "second", nil),
+			describeField(min, "minute", nil),
+			describeField(hour, "hour", nil),
+		} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "every second")
+		}
+		parts[0] = strings.ToUpper(parts[0][:1]) + parts[0][1:]
+	}
+
+	if p := describeField(dom, "day", nil); p != "" {
+		parts = append(parts, "on "+p+" of the month")
+	}
+	if p := describeField(month, "month", monthNames); p != "" {
+		parts = append(parts, "in "+p)
+	}
+	if p := describeField(dow, "weekday", weekdayNames); p != "" {
+		parts = append(parts, "on "+p)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeField 描述单个字段，每个值都匹配时返回空字符串
+func describeField(field, unit string, names []string) string {
+	if field == "*" || field == "?" {
+		return ""
+	}
+
+	var items []string
+	for _, item := range strings.Split(field, ",") {
+		rng, step, hasStep := strings.Cut(item, "/")
+		switch {
+		case rng == "*" && hasStep:
+			items = append(items, fmt.Sprintf("every %s %ss", step, unit))
+		case hasStep:
+			from, to, _ := strings.Cut(rng, "-")
+			if to == "" {
+				items = append(items, fmt.Sprintf("every %s %ss starting at %s", step, unit, name(from, names)))
+			} else {
+				items = append(items, fmt.Sprintf("every %s %ss from %s through %s", step, unit, name(from, names), name(to, names)))
+			}
+		case strings.Contains(rng, "-"):
+			from, to, _ := strings.Cut(rng, "-")
+			items = append(items, fmt.Sprintf("%s through %s", name(from, names), name(to, names)))
+		default:
+			items = append(items, name(rng, names))
+		}
+	}
+
+	desc := strings.Join(items, ", ")
+	if names == nil && !strings.HasPrefix(desc, "every") {
+		desc = unit + " " + desc
+	}
+	return desc
+}
+
+func name(v string, names []string) string {
+	n, err := strconv.Atoi(v)
+	if err != nil || names == nil || n < 0 {
+		return v
+	}
+	// 星期字段中 7 同样表示星期日
+	return names[n%len(names)]
+}
+
+func isNumber(v string) bool {
+	_, err := strconv.Atoi(v)
+	return err == nil
+}
@@ -0,0 +1,25 @@
+package schedule
+
+import "testing"
+
+func TestDescribe(t *testing.T) {
+	cases := []struct {
+		spec string
+		want string
+	}{
+		{"0 30 8 * * *", "At 08:30:00"},
+		{"*/5 * * * * *", "Every 5 seconds"},
+		{"0 */10 * * * *", "Second 0, every 10 minutes"},
+		{"0 0 3 1 * *", "At 03:00:00, on day 1 of the month"},
+		{"0 0 9 * * 1-5", "At 09:00:00, on Monday through Friday"},
+		{"0 0 0 1 1,7 *", "At 00:00:00, on day 1 of the month, in January, July"},
+		{"@daily", "At 00:00:00"},
+		{"@every 1h30m", "Every 1h30m0s"},
+		{"CRON_TZ=Asia/Tokyo 0 0 12 * * *", "At 12:00:00 (Asia/Tokyo)"},
+	}
+	for _, c := range cases {
+		if got := Describe(c.spec); got != c.want {
+			t.Errorf("Describe(%q) = %q, want %q", c.spec, got, c.want)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"bytes"
 	"errors"
 	"io"
 	"net"
@@ -12,7 +11,7 @@ var _ io.ReadWriteCloser = (*RequestWrapper)(nil)
 
 type RequestWrapper struct {
 	req    *http.Request
-	reader *bytes.Buffer
+	reader *io.PipeReader
 	writer net.Conn
 }
 
@@ -25,13 +24,14 @@ func NewRequestWrapper(req *http.Request, writer http.ResponseWriter) (*RequestW
 	if err != nil {
 		return nil, err
 	}
-	buf := bytes.NewBuffer(nil)
-	if err = req.Write(buf); err != nil {
-		return nil, err
-	}
+	// 边读取请求体边转发，不在内存中缓存整个请求
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(req.Write(pw))
+	}()
 	return &RequestWrapper{
 		req:    req,
-		reader: buf,
+		reader: pr,
 		writer: conn,
 	}, nil
 }
@@ -53,6 +53,7 @@ func (rw *RequestWrapper) Write(p []byte) (int, error) {
 }
 
 func (rw *RequestWrapper) Close() error {
+	rw.reader.Close()
 	rw.req.Body.Close()
 	rw.writer.Close()
 	return nil
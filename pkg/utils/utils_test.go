@@ -180,3 +180,20 @@ func TestUnique(t *testing.T) {
 		}
 	}
 }
+
+func TestEncrypt(t *testing.T) {
+	ciphertext, err := Encrypt("passphrase", []byte("p@ssw0rd"))
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	plaintext, err := Decrypt("passphrase", ciphertext)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if string(plaintext) != "p@ssw0rd" {
+		t.Fatalf("Expected p@ssw0rd, but got %s", plaintext)
+	}
+	if _, err := Decrypt("another", ciphertext); err == nil {
+		t.Fatalf("Expected error when decrypting with another passphrase")
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/telexy324/billabong/service/singleton"
 )
 
+// errAgentPending 开启 agent 审核时，未批准的 agent 的所有请求都返回该错误
+var errAgentPending = status.Error(codes.PermissionDenied, "客户端等待审核")
+
 type authHandler struct {
 	ClientSecret string
 	ClientUUID   string
@@ -38,12 +41,17 @@ func (a *authHandler) Check(ctx context.Context) (uint64, error) {
 
 	singleton.UserLock.RLock()
 	userId, ok := singleton.AgentSecretToUserId[clientSecret]
+	singleton.UserLock.RUnlock()
+
+	// 不是用户的 AgentSecret 时尝试作为注册令牌
+	var token *model.EnrollmentToken
+	if !ok {
+		token, ok = singleton.EnrollmentTokenShared.Lookup(clientSecret)
+	}
 	if !ok {
-		singleton.UserLock.RUnlock()
 		model.BlockIP(singleton.DB, ip, model.WAFBlockReasonTypeAgentAuthFail, model.BlockIDgRPC)
 		return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
 	}
-	singleton.UserLock.RUnlock()
 
 	model.UnblockIP(singleton.DB, ip, model.BlockIDgRPC)
 
@@ -57,7 +65,14 @@ func (a *authHandler) Check(ctx context.Context) (uint64, error) {
 	}
 
 	clientID, hasID := singleton.ServerShared.UUIDToID(clientUUID)
+	if token != nil {
+		return a.checkEnrollment(ctx, token, clientUUID, clientID, hasID)
+	}
 	if !hasID {
+		if singleton.Conf.RequireAgentApproval {
+			return 0, a.queuePending(ctx, clientUUID, userId)
+		}
+
 		s := model.Server{UUID: clientUUID, Name: petname.Generate(2, "-"), Common: model.Common{
 			UserID: userId,
 		}}
@@ -73,3 +88,45 @@ func (a *authHandler) Check(ctx context.Context) (uint64, error) {
 
 	return clientID, nil
 }
+
+// checkEnrollment 使用注册令牌认证：已注册的服务器只能使用注册时的令牌，未知的 UUID 消耗一次令牌注册为新服务器。
+// 令牌同时是已注册服务器的连接凭据，吊销或过期只阻止新的注册，删除令牌才会让这些服务器无法连接
+func (a *authHandler) checkEnrollment(ctx context.Context, token *model.EnrollmentToken, clientUUID string, clientID uint64, hasID bool) (uint64, error) {
+	if hasID {
+		if s, ok := singleton.ServerShared.Get(clientID); !ok || s.EnrollmentTokenID != token.ID {
+			return 0, status.Error(codes.Unauthenticated, "客户端认证失败")
+		}
+		return clientID, nil
+	}
+
+	ip, _ := ctx.Value(model.CtxKeyRealIP{}).(string)
+	if ip == "" {
+		ip, _ = ctx.Value(model.CtxKeyConnectingIP{}).(string)
+	}
+	s, err := singleton.EnrollmentTokenShared.Enroll(token, clientUUID, ip)
+	if err != nil {
+		return 0, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return s.ID, nil
+}
+
+// queuePending 将未知的 agent 加入待审核列表
+func (a *authHandler) queuePending(ctx context.Context, clientUUID string, userId uint64) error {
+	ip, _ := ctx.Value(model.CtxKeyConnectingIP{}).(string)
+	rejected, err := singleton.PendingAgentShared.Record(clientUUID, userId, ip)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if rejected {
+		return status.Error(codes.PermissionDenied, "客户端已被拒绝")
+	}
+	return errAgentPending
+}
+
+func clientUUIDFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if value := md["client_uuid"]; len(value) > 0 {
+		return value[0]
+	}
+	return ""
+}
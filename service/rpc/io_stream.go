@@ -17,6 +17,10 @@ type ioStreamContext struct {
 	agentIoConnectCh chan struct{}
 	userIoChOnce     sync.Once
 	agentIoChOnce    sync.Once
+
+	viewersMu  sync.Mutex
+	viewers    map[string]*streamViewer // 只读观看者
+	scrollback scrollbackBuffer         // 最近的输出，发送给新加入的观看者
 }
 
 type bp struct {
@@ -38,6 +42,7 @@ func (s *NezhaHandler) CreateStream(streamId string) {
 	s.ioStreams[streamId] = &ioStreamContext{
 		userIoConnectCh:  make(chan struct{}),
 		agentIoConnectCh: make(chan struct{}),
+		viewers:          make(map[string]*streamViewer),
 	}
 }
 
@@ -63,6 +68,7 @@ func (s *NezhaHandler) CloseStream(streamId string) error {
 		if ctx.agentIo != nil {
 			ctx.agentIo.Close()
 		}
+		ctx.closeViewers()
 		delete(s.ioStreams, streamId)
 	}
 
@@ -140,7 +146,8 @@ LOOP:
 	go func() {
 		bp := bufPool.Get().(*bp)
 		defer bufPool.Put(bp)
-		_, innerErr := io.CopyBuffer(stream.userIo, stream.agentIo, bp.buf)
+		// agent 的输出同时分发给只读观看者
+		_, innerErr := io.CopyBuffer(&streamOutput{stream}, stream.agentIo, bp.buf)
 		if innerErr != nil {
 			err = innerErr
 		}
@@ -0,0 +1,183 @@
+package rpc
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/telexy324/billabong/model"
+)
+
+const (
+	viewerBufferSize = 256       // 观看者待发送的消息数，超出后断开连接
+	scrollbackSize   = 64 * 1024 // 新加入的观看者可以看到的最近输出
+)
+
+type streamViewer struct {
+	info *model.TerminalViewer
+	conn io.WriteCloser
+	ch   chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func (v *streamViewer) close() {
+	v.once.Do(func() {
+		close(v.done)
+		v.conn.Close()
+	})
+}
+
+func (v *streamViewer) run() {
+	for {
+		select {
+		case data := <-v.ch:
+			if _, err := v.conn.Write(data); err != nil {
+				v.close()
+				return
+			}
+		case <-v.done:
+			return
+		}
+	}
+}
+
+// streamOutput 将 agent 的输出写入用户连接，并分发给观看者
+type streamOutput struct {
+	stream *ioStreamContext
+}
+
+func (o *streamOutput) Write(p []byte) (int, error) {
+	n, err := o.stream.userIo.Write(p)
+	if n > 0 {
+		o.stream.broadcast(p[:n])
+	}
+	return n, err
+}
+
+// scrollbackBuffer 保存最近 scrollbackSize 字节输出的环形缓冲区
+type scrollbackBuffer struct {
+	buf  []byte
+	pos  int // 下一次写入的位置
+	full bool
+}
+
+func (b *scrollbackBuffer) write(p []byte) {
+	if b.buf == nil {
+		b.buf = make([]byte, scrollbackSize)
+	}
+	if len(p) >= len(b.buf) {
+		copy(b.buf, p[len(p)-len(b.buf):])
+		b.pos, b.full = 0, true
+		return
+	}
+	n := copy(b.buf[b.pos:], p)
+	copy(b.buf, p[n:])
+	if b.pos+len(p) >= len(b.buf) {
+		b.full = true
+	}
+	b.pos = (b.pos + len(p)) % len(b.buf)
+}
+
+// bytes 按写入顺序返回缓冲区内容的副本
+func (b *scrollbackBuffer) bytes() []byte {
+	if !b.full {
+		return append([]byte(nil), b.buf[:b.pos]...)
+	}
+	return append(append(make([]byte, 0, len(b.buf)), b.buf[b.pos:]...), b.buf[:b.pos]...)
+}
+
+func (ctx *ioStreamContext) broadcast(p []byte) {
+	ctx.viewersMu.Lock()
+	defer ctx.viewersMu.Unlock()
+
+	ctx.scrollback.write(p)
+
+	if len(ctx.viewers) == 0 {
+		return
+	}
+	data := append([]byte(nil), p...)
+	for id, v := range ctx.viewers {
+		select {
+		case v.ch <- data:
+		default:
+			// 观看者网络过慢，断开连接以免影响会话
+			v.close()
+			delete(ctx.viewers, id)
+		}
+	}
+}
+
+func (ctx *ioStreamContext) closeViewers() {
+	ctx.viewersMu.Lock()
+	defer ctx.viewersMu.Unlock()
+
+	for id, v := range ctx.viewers {
+		v.close()
+		delete(ctx.viewers, id)
+	}
+}
+
+// AddViewer 以只读方式加入会话，返回的 channel 在观看者断开或被移除时关闭
+func (s *NezhaHandler) AddViewer(streamId string, info *model.TerminalViewer, conn io.WriteCloser) (<-chan struct{}, error) {
+	stream, err := s.GetStream(streamId)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &streamViewer{
+		info: info,
+		conn: conn,
+		ch:   make(chan []byte, viewerBufferSize),
+		done: make(chan struct{}),
+	}
+
+	stream.viewersMu.Lock()
+	if _, ok := stream.viewers[info.ID]; ok {
+		stream.viewersMu.Unlock()
+		return nil, errors.New("viewer already exists")
+	}
+	if data := stream.scrollback.bytes(); len(data) > 0 {
+		v.ch <- data
+	}
+	stream.viewers[info.ID] = v
+	stream.viewersMu.Unlock()
+
+	go v.run()
+	return v.done, nil
+}
+
+// RemoveViewer 断开观看者
+func (s *NezhaHandler) RemoveViewer(streamId, viewerId string) bool {
+	stream, err := s.GetStream(streamId)
+	if err != nil {
+		return false
+	}
+
+	stream.viewersMu.Lock()
+	defer stream.viewersMu.Unlock()
+
+	v, ok := stream.viewers[viewerId]
+	if ok {
+		v.close()
+		delete(stream.viewers, viewerId)
+	}
+	return ok
+}
+
+// ListViewers 返回会话当前的观看者
+func (s *NezhaHandler) ListViewers(streamId string) []*model.TerminalViewer {
+	stream, err := s.GetStream(streamId)
+	if err != nil {
+		return nil
+	}
+
+	stream.viewersMu.Lock()
+	defer stream.viewersMu.Unlock()
+
+	viewers := make([]*model.TerminalViewer, 0, len(stream.viewers))
+	for _, v := range stream.viewers {
+		viewers = append(viewers, v.info)
+	}
+	return viewers
+}
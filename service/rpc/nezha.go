@@ -5,14 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/jinzhu/copier"
 	"github.com/telexy324/billabong/pkg/ddns"
 	geoipx "github.com/telexy324/billabong/pkg/geoip"
 	"github.com/telexy324/billabong/pkg/grpcx"
+	"github.com/telexy324/billabong/pkg/labels"
 
 	"github.com/telexy324/billabong/model"
 	pb "github.com/telexy324/billabong/proto"
@@ -55,8 +58,15 @@ func (s *NezhaHandler) RequestTask(stream pb.NezhaService_RequestTaskServer) err
 		}
 		switch result.GetType() {
 		case model.TaskTypeCommand:
-			// 处理上报的计划任务
-			cr, _ := singleton.CronShared.Get(result.GetId())
+			// 处理上报的计划任务，输出中的密钥值不应出现在通知与执行记录中
+			result.Data = singleton.SecretShared.Redact(result.GetData())
+			if result.GetId()&model.CommandJobTaskFlag != 0 {
+				// 批量命令的执行结果
+				singleton.CommandJobShared.FinishResult(clientID, result)
+				continue
+			}
+			cronID, executionID := singleton.CronShared.CronTaskID(result.GetId())
+			cr, _ := singleton.CronShared.Get(cronID)
 			if cr != nil {
 				// 保存当前服务器状态信息
 				var curServer model.Server
@@ -73,6 +83,7 @@ func (s *NezhaHandler) RequestTask(stream pb.NezhaService_RequestTaskServer) err
 					LastExecutedAt: time.Now().Add(time.Second * -1 * time.Duration(result.GetDelay())),
 					LastResult:     result.GetSuccessful(),
 				})
+				singleton.CronShared.FinishCronExecution(cr.ID, executionID, clientID, result)
 			}
 		case model.TaskTypeReportConfig:
 			if len(server.ConfigCache) < 1 {
@@ -131,6 +142,13 @@ func (s *NezhaHandler) onReportSystemInfo(c context.Context, r *pb.Host) error {
 	var clientID uint64
 	var err error
 	if clientID, err = s.Auth.Check(c); err != nil {
+		// 待审核的 agent 只记录主机信息，供管理员审核时参考
+		if err == errAgentPending {
+			host := model.PB2Host(r)
+			if err := singleton.PendingAgentShared.ReportHost(clientUUIDFromContext(c), &host); err != nil {
+				log.Printf("NEZHA>> failed to save host of pending agent: %v", err)
+			}
+		}
 		return err
 	}
 	host := model.PB2Host(r)
@@ -151,9 +169,35 @@ func (s *NezhaHandler) onReportSystemInfo(c context.Context, r *pb.Host) error {
 	}
 
 	server.Host = &host
+	updateAgentLabels(server, host.Labels)
+	singleton.RecordHostSnapshot(server, &host)
+	singleton.AgentRolloutShared.OnHostReport(server)
 	return nil
 }
 
+// updateAgentLabels 保存 agent 上报的标签，不合法的标签被忽略，未变化时不写库
+func updateAgentLabels(server *model.Server, reported map[string]string) {
+	set := make(map[string]string, len(reported))
+	for k, v := range reported {
+		if labels.ValidKey(k) && labels.ValidValue(v) {
+			set[k] = v
+		}
+	}
+	if maps.Equal(set, server.AgentLabels) {
+		return
+	}
+
+	raw, err := json.Marshal(set)
+	if err != nil {
+		return
+	}
+	if err := singleton.DB.Model(&model.Server{}).Where("id = ?", server.ID).Update("agent_labels_raw", string(raw)).Error; err != nil {
+		log.Printf("NEZHA>> failed to save agent labels of server %d: %v", server.ID, err)
+		return
+	}
+	server.AgentLabels = set
+}
+
 func (s *NezhaHandler) ReportSystemInfo(c context.Context, r *pb.Host) (*pb.Receipt, error) {
 	if err := s.onReportSystemInfo(c, r); err != nil {
 		return nil, err
@@ -230,16 +274,22 @@ func (s *NezhaHandler) ReportGeoIP(c context.Context, r *pb.GeoIP) (*pb.GeoIP, e
 		return nil, fmt.Errorf("server not found")
 	}
 
+	ipChanged := server.GeoIP == nil || server.GeoIP.IP != geoip.IP
+
 	// 检查并更新DDNS
-	if server.EnableDDNS && joinedIP != "" &&
-		(server.GeoIP == nil || server.GeoIP.IP != geoip.IP) {
+	if profiles := singleton.DDNSShared.ServerProfiles(server); len(profiles) > 0 && joinedIP != "" && ipChanged {
 		ipv4 := geoip.IP.IPv4Addr
 		ipv6 := geoip.IP.IPv6Addr
 
-		providers, err := singleton.DDNSShared.GetDDNSProvidersFromProfiles(server.DDNSProfiles, &model.IP{IPv4Addr: ipv4, IPv6Addr: ipv6})
+		providers, err := singleton.DDNSShared.GetDDNSProvidersFromProfiles(profiles, &model.IP{IPv4Addr: ipv4, IPv6Addr: ipv6})
 		if err == nil {
 			for _, provider := range providers {
+				// 分组模式的记录由 RefreshGroupRecords 维护
+				if provider.DDNSProfile.ServerGroupID != 0 {
+					continue
+				}
 				domains := server.OverrideDDNSDomains[provider.GetProfileID()]
+				provider.ServerID = server.ID
 				go func(provider *ddns.Provider) {
 					provider.UpdateDomain(context.Background(), domains...)
 				}(provider)
@@ -285,5 +335,9 @@ func (s *NezhaHandler) ReportGeoIP(c context.Context, r *pb.GeoIP) (*pb.GeoIP, e
 	// 将地区码写入到 Host
 	server.GeoIP = &geoip
 
+	if ipChanged && joinedIP != "" {
+		go singleton.DDNSShared.RefreshGroupRecords()
+	}
+
 	return &pb.GeoIP{Ip: nil, CountryCode: location, DashboardBootTime: singleton.DashboardBootTime}, nil
 }
@@ -0,0 +1,382 @@
+package singleton
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	pb "github.com/telexy324/billabong/proto"
+)
+
+type AgentRolloutClass struct {
+	mu sync.Mutex // 保护升级任务与升级记录的状态变更
+
+	sentMu sync.RWMutex
+	sent   map[uint64]uint64 // 已下发升级的服务器 -> 升级记录
+}
+
+// agentUpgrade 一台待下发升级任务的服务器
+type agentUpgrade struct {
+	rolloutID uint64
+	targetID  uint64
+	stream    pb.NezhaService_RequestTaskServer
+}
+
+func NewAgentRolloutClass() *AgentRolloutClass {
+	c := &AgentRolloutClass{sent: make(map[uint64]uint64)}
+
+	var targets []model.AgentRolloutTarget
+	DB.Where("status = ?", model.AgentUpgradeSent).Find(&targets)
+	for _, t := range targets {
+		c.sent[t.ServerID] = t.ID
+	}
+	return c
+}
+
+// Start 按批次规划保存升级任务，第一批在下一次检查时下发。
+// 一台服务器同时只能属于一个未结束的升级任务，否则各任务的结果会相互覆盖
+func (c *AgentRolloutClass) Start(r *model.AgentRollout, servers []*model.Server) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]uint64, 0, len(servers))
+	for _, s := range servers {
+		ids = append(ids, s.ID)
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var busy model.AgentRolloutTarget
+		err := tx.Where("server_id IN (?) AND status IN (?) AND rollout_id IN (?)", ids,
+			[]uint8{model.AgentUpgradePending, model.AgentUpgradeSent},
+			tx.Model(&model.AgentRollout{}).Select("id").Where("status IN (?)", []uint8{model.AgentRolloutRunning, model.AgentRolloutPaused}),
+		).Limit(1).Find(&busy).Error
+		if err != nil {
+			return err
+		}
+		if busy.ID != 0 {
+			return Localizer.ErrorT("server %s is already in unfinished rollout %d", busy.ServerName, busy.RolloutID)
+		}
+
+		r.Status = model.AgentRolloutRunning
+		if err := tx.Create(r).Error; err != nil {
+			return err
+		}
+
+		waves := r.Waves(len(servers))
+		targets := make([]*model.AgentRolloutTarget, 0, len(servers))
+		for i, s := range servers {
+			targets = append(targets, &model.AgentRolloutTarget{
+				RolloutID:  r.ID,
+				ServerID:   s.ID,
+				ServerName: s.Name,
+				Wave:       waves[i],
+			})
+		}
+		return tx.Create(targets).Error
+	})
+}
+
+// Pause 暂停下发后续批次，已下发的服务器仍会继续检查结果
+func (c *AgentRolloutClass) Pause(r *model.AgentRollout, reason string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 重新读取，避免覆盖检查过程中的修改
+	if err := DB.First(r, r.ID).Error; err != nil {
+		return err
+	}
+
+	if r.Status != model.AgentRolloutRunning {
+		return Localizer.ErrorT("rollout is not running")
+	}
+	r.Status = model.AgentRolloutPaused
+	r.PauseReason = reason
+	return DB.Save(r).Error
+}
+
+// Resume 恢复暂停的升级，失败计数重新开始
+func (c *AgentRolloutClass) Resume(r *model.AgentRollout) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 重新读取，避免覆盖检查过程中的修改
+	if err := DB.First(r, r.ID).Error; err != nil {
+		return err
+	}
+
+	if r.Status != model.AgentRolloutPaused {
+		return Localizer.ErrorT("rollout is not paused")
+	}
+	r.Status = model.AgentRolloutRunning
+	r.PauseReason = ""
+	r.Failures = 0
+	return DB.Save(r).Error
+}
+
+// Cancel 取消升级，尚未下发的服务器标记为跳过
+func (c *AgentRolloutClass) Cancel(r *model.AgentRollout) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 重新读取，避免覆盖检查过程中的修改
+	if err := DB.First(r, r.ID).Error; err != nil {
+		return err
+	}
+
+	if r.Status != model.AgentRolloutRunning && r.Status != model.AgentRolloutPaused {
+		return Localizer.ErrorT("rollout has already finished")
+	}
+	now := time.Now()
+	if err := DB.Model(&model.AgentRolloutTarget{}).
+		Where("rollout_id = ? AND status = ?", r.ID, model.AgentUpgradePending).
+		Updates(map[string]any{"status": model.AgentUpgradeSkipped, "finished_at": &now, "error": "cancelled"}).Error; err != nil {
+		return err
+	}
+	r.Status = model.AgentRolloutCancelled
+	r.FinishedAt = &now
+	return DB.Save(r).Error
+}
+
+// Delete 删除升级任务及各服务器的升级记录
+func (c *AgentRolloutClass) Delete(ids []uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := DB.Unscoped().Delete(&model.AgentRollout{}, "id in (?)", ids).Error; err != nil {
+		return err
+	}
+	if err := DB.Unscoped().Delete(&model.AgentRolloutTarget{}, "rollout_id in (?)", ids).Error; err != nil {
+		return err
+	}
+	c.reloadSent()
+	return nil
+}
+
+func (c *AgentRolloutClass) reloadSent() {
+	var targets []model.AgentRolloutTarget
+	DB.Where("status = ?", model.AgentUpgradeSent).Find(&targets)
+
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	clear(c.sent)
+	for _, t := range targets {
+		c.sent[t.ServerID] = t.ID
+	}
+}
+
+// OnHostReport 服务器上报主机信息时检查其升级是否已生效，在 RPC 流程中调用，不阻塞等待数据库
+func (c *AgentRolloutClass) OnHostReport(server *model.Server) {
+	c.sentMu.RLock()
+	id, ok := c.sent[server.ID]
+	c.sentMu.RUnlock()
+	if !ok || server.Host == nil {
+		return
+	}
+
+	go c.checkReport(server.ID, id, server.Host.Version)
+}
+
+func (c *AgentRolloutClass) checkReport(serverID, targetID uint64, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var t model.AgentRolloutTarget
+	if err := DB.First(&t, targetID).Error; err != nil || t.Status != model.AgentUpgradeSent {
+		c.forget(serverID, targetID)
+		return
+	}
+	var r model.AgentRollout
+	if err := DB.First(&r, t.RolloutID).Error; err != nil {
+		c.forget(serverID, targetID)
+		return
+	}
+
+	if r.VersionReached(version) {
+		c.finishTarget(&r, &t, model.AgentUpgradeSucceeded, version, "")
+	}
+}
+
+func (c *AgentRolloutClass) forget(serverID, targetID uint64) {
+	c.sentMu.Lock()
+	defer c.sentMu.Unlock()
+	if c.sent[serverID] == targetID {
+		delete(c.sent, serverID)
+	}
+}
+
+// Tick 检查已下发的服务器是否超时，并在上一批结束且间隔已到时下发下一批，升级任务在释放锁后发送
+func (c *AgentRolloutClass) Tick() {
+	c.mu.Lock()
+	var rollouts []*model.AgentRollout
+	if err := DB.Where("status IN (?)", []uint8{model.AgentRolloutRunning, model.AgentRolloutPaused}).Find(&rollouts).Error; err != nil {
+		c.mu.Unlock()
+		log.Printf("NEZHA>> Failed to load agent rollouts: %v", err)
+		return
+	}
+
+	var upgrades []agentUpgrade
+	for _, r := range rollouts {
+		u, err := c.tick(r)
+		if err != nil {
+			log.Printf("NEZHA>> Agent rollout %d: %v", r.ID, err)
+		}
+		upgrades = append(upgrades, u...)
+	}
+	c.mu.Unlock()
+
+	for _, u := range upgrades {
+		if err := u.stream.Send(&pb.Task{Type: model.TaskTypeUpgrade}); err != nil {
+			c.sendFailed(u, err)
+		}
+	}
+}
+
+// sendFailed 升级任务发送失败时立即将该服务器记为失败
+func (c *AgentRolloutClass) sendFailed(u agentUpgrade, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var t model.AgentRolloutTarget
+	if DB.First(&t, u.targetID).Error != nil || t.Status != model.AgentUpgradeSent {
+		return
+	}
+	var r model.AgentRollout
+	if DB.First(&r, u.rolloutID).Error != nil {
+		return
+	}
+	c.finishTarget(&r, &t, model.AgentUpgradeFailed, "", err.Error())
+}
+
+// tick 处理一个升级任务，返回本次需要下发的服务器，调用时需持有 mu
+func (c *AgentRolloutClass) tick(r *model.AgentRollout) ([]agentUpgrade, error) {
+	var sent []*model.AgentRolloutTarget
+	if err := DB.Where("rollout_id = ? AND status = ?", r.ID, model.AgentUpgradeSent).Find(&sent).Error; err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(r.ReconnectTimeout) * time.Second
+	inFlight := 0
+	for _, t := range sent {
+		server, ok := ServerShared.Get(t.ServerID)
+		switch {
+		case !ok:
+			c.finishTarget(r, t, model.AgentUpgradeSkipped, "", "server deleted")
+		case server.Host != nil && r.VersionReached(server.Host.Version):
+			c.finishTarget(r, t, model.AgentUpgradeSucceeded, server.Host.Version, "")
+		case t.SentAt != nil && time.Since(*t.SentAt) > timeout:
+			var reported string
+			if server.Host != nil {
+				reported = server.Host.Version
+			}
+			c.finishTarget(r, t, model.AgentUpgradeFailed, reported,
+				fmt.Sprintf("did not reconnect with the new version within %d seconds", r.ReconnectTimeout))
+		default:
+			inFlight++
+		}
+	}
+
+	if r.Status != model.AgentRolloutRunning || inFlight > 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if r.DispatchedWaves > 0 {
+		if r.WaveFinishedAt == nil {
+			r.WaveFinishedAt = &now
+			return nil, DB.Save(r).Error
+		}
+		if now.Before(r.WaveFinishedAt.Add(time.Duration(r.BatchDelay) * time.Second)) {
+			return nil, nil
+		}
+	}
+
+	var pending []*model.AgentRolloutTarget
+	if err := DB.Where("rollout_id = ? AND status = ?", r.ID, model.AgentUpgradePending).
+		Order("wave, id").Find(&pending).Error; err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		r.Status = model.AgentRolloutCompleted
+		r.FinishedAt = &now
+		return nil, DB.Save(r).Error
+	}
+
+	var upgrades []agentUpgrade
+	wave := pending[0].Wave
+	for _, t := range pending {
+		if t.Wave != wave {
+			break
+		}
+		if stream := c.dispatch(r, t); stream != nil {
+			upgrades = append(upgrades, agentUpgrade{rolloutID: r.ID, targetID: t.ID, stream: stream})
+		}
+	}
+	r.DispatchedWaves = wave + 1
+	r.WaveFinishedAt = nil
+	return upgrades, DB.Save(r).Error
+}
+
+// dispatch 将一台服务器标记为已下发，返回用于发送升级任务的连接，无需下发时返回 nil
+func (c *AgentRolloutClass) dispatch(r *model.AgentRollout, t *model.AgentRolloutTarget) pb.NezhaService_RequestTaskServer {
+	server, ok := ServerShared.Get(t.ServerID)
+	if !ok {
+		c.finishTarget(r, t, model.AgentUpgradeSkipped, "", "server deleted")
+		return nil
+	}
+	var version string
+	if server.Host != nil {
+		version = server.Host.Version
+	}
+	t.FromVersion = version
+	if r.VersionReached(version) {
+		c.finishTarget(r, t, model.AgentUpgradeSkipped, version, "already at the latest version")
+		return nil
+	}
+	stream := server.TaskStream
+	if stream == nil {
+		c.finishTarget(r, t, model.AgentUpgradeSkipped, "", "server offline")
+		return nil
+	}
+
+	now := time.Now()
+	t.Status = model.AgentUpgradeSent
+	t.SentAt = &now
+	if err := DB.Save(t).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save agent rollout target: %v", err)
+		return nil
+	}
+
+	c.sentMu.Lock()
+	c.sent[t.ServerID] = t.ID
+	c.sentMu.Unlock()
+	return stream
+}
+
+// finishTarget 保存一台服务器的升级结果，失败数量超出限制时暂停升级
+func (c *AgentRolloutClass) finishTarget(r *model.AgentRollout, t *model.AgentRolloutTarget, status uint8, version, reason string) {
+	now := time.Now()
+	t.Status = status
+	t.ToVersion = version
+	t.Error = reason
+	t.FinishedAt = &now
+	if err := DB.Save(t).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save agent rollout target: %v", err)
+	}
+	c.forget(t.ServerID, t.ID)
+
+	if status != model.AgentUpgradeFailed {
+		return
+	}
+	r.Failures++
+	if r.Status == model.AgentRolloutRunning && r.Failures > r.MaxFailures {
+		r.Status = model.AgentRolloutPaused
+		r.PauseReason = fmt.Sprintf("%d server(s) failed to upgrade, last: %s (%s)", r.Failures, t.ServerName, reason)
+	}
+	if err := DB.Save(r).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save agent rollout: %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package singleton
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+	pb "github.com/telexy324/billabong/proto"
+)
+
+type CommandJobClass struct {
+	mu      sync.Mutex
+	jobs    map[uint64]*commandJobState // 正在执行的批量命令
+	results map[uint64]uint64           // 等待结果的执行记录 -> 批量命令
+}
+
+// commandJobState 批量命令执行过程中的状态
+type commandJobState struct {
+	job         *model.CommandJob
+	results     map[uint64]*model.CommandJobResult
+	pending     int
+	timer       *time.Timer
+	subscribers map[chan model.CommandJobResult]struct{}
+}
+
+func NewCommandJobClass() *CommandJobClass {
+	// 面板重启后无法继续接收未完成的结果
+	now := time.Now()
+	DB.Model(&model.CommandJobResult{}).Where("status = ?", model.CronExecutionDispatched).
+		Updates(map[string]any{"status": model.CronExecutionTimeout, "finished_at": &now})
+	DB.Model(&model.CommandJob{}).Where("status = ?", model.CommandJobRunning).
+		Updates(map[string]any{"status": model.CommandJobFinished, "finished_at": &now})
+
+	return &CommandJobClass{
+		jobs:    make(map[uint64]*commandJobState),
+		results: make(map[uint64]uint64),
+	}
+}
+
+// Start 保存批量命令并下发到各服务器，任务在释放锁后逐台发送
+func (c *CommandJobClass) Start(job *model.CommandJob, servers []*model.Server) error {
+	job.Status = model.CommandJobRunning
+	if err := DB.Create(job).Error; err != nil {
+		return err
+	}
+
+	state := &commandJobState{
+		job:         job,
+		results:     make(map[uint64]*model.CommandJobResult, len(servers)),
+		subscribers: make(map[chan model.CommandJobResult]struct{}),
+	}
+
+	streams := make(map[uint64]pb.NezhaService_RequestTaskServer, len(servers))
+	for _, s := range servers {
+		now := time.Now()
+		result := &model.CommandJobResult{
+			JobID:      job.ID,
+			ServerID:   s.ID,
+			ServerName: s.Name,
+			StartedAt:  &now,
+		}
+		stream := s.TaskStream
+		if stream == nil {
+			result.Status = model.CronExecutionOffline
+			result.FinishedAt = &now
+		}
+		if err := DB.Create(result).Error; err != nil {
+			log.Printf("NEZHA>> Failed to save command job result: %v", err)
+			continue
+		}
+		state.results[result.ID] = result
+		if stream != nil {
+			streams[result.ID] = stream
+		}
+	}
+
+	c.mu.Lock()
+	c.jobs[job.ID] = state
+	for id := range streams {
+		c.results[id] = job.ID
+		state.pending++
+	}
+	if state.pending == 0 {
+		c.finish(state)
+		c.mu.Unlock()
+		return nil
+	}
+	state.timer = time.AfterFunc(time.Duration(job.Timeout)*time.Second, func() {
+		c.timeout(job.ID)
+	})
+	c.mu.Unlock()
+
+	for id, stream := range streams {
+		if err := stream.Send(&pb.Task{
+			Id:   model.CommandJobTaskFlag | id,
+			Data: job.Command,
+			Type: model.TaskTypeCommand,
+		}); err != nil {
+			log.Printf("NEZHA>> Failed to dispatch command job %d: %v", job.ID, err)
+			c.sendFailed(job.ID, id, err)
+		}
+	}
+	return nil
+}
+
+// sendFailed 下发失败时立即将该服务器标记为失败
+func (c *CommandJobClass) sendFailed(jobID, resultID uint64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.jobs[jobID]
+	if state == nil {
+		return
+	}
+	result := state.results[resultID]
+	if result == nil || result.Finished() {
+		return
+	}
+
+	now := time.Now()
+	result.Status = model.CronExecutionFailed
+	result.FinishedAt = &now
+	result.SetOutput(err.Error())
+	if err := DB.Save(result).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save command job result: %v", err)
+	}
+
+	delete(c.results, resultID)
+	state.pending--
+	state.broadcast(result)
+	if state.pending == 0 {
+		c.finish(state)
+	}
+}
+
+// FinishResult 根据 agent 上报的结果更新对应服务器的执行记录
+func (c *CommandJobClass) FinishResult(serverID uint64, result *pb.TaskResult) {
+	now := time.Now()
+	startedAt := now.Add(-time.Duration(float64(result.GetDelay()) * float64(time.Second)))
+	resultID := result.GetId() &^ model.CommandJobTaskFlag
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var state *commandJobState
+	if jobID, ok := c.results[resultID]; ok {
+		state = c.jobs[jobID]
+	}
+
+	var jobResult *model.CommandJobResult
+	if state != nil {
+		jobResult = state.results[resultID]
+	} else {
+		// 已超时后收到的结果仍保存到记录中
+		var r model.CommandJobResult
+		if err := DB.First(&r, resultID).Error; err != nil {
+			return
+		}
+		jobResult = &r
+	}
+	if jobResult == nil || jobResult.ServerID != serverID {
+		return
+	}
+
+	jobResult.Status = utils.IfOr[uint8](result.GetSuccessful(), model.CronExecutionSucceeded, model.CronExecutionFailed)
+	jobResult.StartedAt = &startedAt
+	jobResult.FinishedAt = &now
+	jobResult.SetOutput(result.GetData())
+	if err := DB.Save(jobResult).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save command job result: %v", err)
+	}
+
+	if state == nil {
+		return
+	}
+	delete(c.results, resultID)
+	state.pending--
+	state.broadcast(jobResult)
+	if state.pending == 0 {
+		c.finish(state)
+	}
+}
+
+// timeout 等待结果超时，将未返回结果的服务器标记为超时
+func (c *CommandJobClass) timeout(jobID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.jobs[jobID]
+	if state == nil {
+		return
+	}
+
+	now := time.Now()
+	for id, result := range state.results {
+		if result.Finished() {
+			continue
+		}
+		result.Status = model.CronExecutionTimeout
+		result.FinishedAt = &now
+		if err := DB.Save(result).Error; err != nil {
+			log.Printf("NEZHA>> Failed to save command job result: %v", err)
+		}
+		delete(c.results, id)
+		state.broadcast(result)
+	}
+	c.finish(state)
+}
+
+// finish 结束批量命令并关闭订阅，调用时需持有 mu
+func (c *CommandJobClass) finish(state *commandJobState) {
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+
+	now := time.Now()
+	state.job.Status = model.CommandJobFinished
+	state.job.FinishedAt = &now
+	if err := DB.Save(state.job).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save command job: %v", err)
+	}
+
+	for ch := range state.subscribers {
+		close(ch)
+		delete(state.subscribers, ch)
+	}
+	delete(c.jobs, state.job.ID)
+}
+
+// Subscribe 订阅正在执行的批量命令的结果，返回当前已有的结果
+// 批量命令已结束时 running 为 false，此时应从数据库读取结果
+func (c *CommandJobClass) Subscribe(jobID uint64) (results []model.CommandJobResult, ch <-chan model.CommandJobResult, cancel func(), running bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.jobs[jobID]
+	if state == nil {
+		return nil, nil, nil, false
+	}
+
+	for _, r := range state.results {
+		results = append(results, *r)
+	}
+	// 每台服务器的结果只会推送一次，缓冲区足够时推送不会阻塞
+	sub := make(chan model.CommandJobResult, len(state.results))
+	state.subscribers[sub] = struct{}{}
+
+	return results, sub, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, ok := state.subscribers[sub]; ok {
+			delete(state.subscribers, sub)
+			close(sub)
+		}
+	}, true
+}
+
+// broadcast 推送结果给订阅者，调用时需持有 mu
+func (s *commandJobState) broadcast(result *model.CommandJobResult) {
+	for ch := range s.subscribers {
+		select {
+		case ch <- *result:
+		default:
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package singleton
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/telexy324/billabong/model"
+)
+
+var errCronCommandValue = errors.New("is not defined")
+
+type cronCommandServer struct {
+	ID   uint64
+	Name string
+	IPv4 string
+	IPv6 string
+}
+
+// cronCommandData 命令模板中可使用的服务器变量，如 {{.Server.Name}}
+type cronCommandData struct {
+	Server cronCommandServer
+}
+
+// renderCronCommand 在下发时替换命令中的参数、服务器变量与密钥
+func renderCronCommand(cr *model.Cron, s *model.Server, params map[string]string) (string, error) {
+	if !strings.Contains(cr.Command, "{{") {
+		return cr.Command, nil
+	}
+
+	tmpl, err := template.New("command").Funcs(template.FuncMap{
+		"param": func(name string) (string, error) {
+			if v, ok := cr.Parameter(name, params); ok {
+				return v, nil
+			}
+			return "", fmt.Errorf("parameter %q %w", name, errCronCommandValue)
+		},
+		"secret": func(name string) (string, error) {
+			// 密钥名称可能在渲染时才拼接出来，只能在这里检查归属
+			if secret, ok := SecretShared.GetByName(name); ok && cronCanUseSecret(cr, secret) {
+				if v, ok := SecretShared.Value(name); ok {
+					return v, nil
+				}
+			}
+			return "", fmt.Errorf("secret %q %w", name, errCronCommandValue)
+		},
+	}).Parse(cr.Command)
+	// 解析失败或未使用参数、服务器变量与密钥的命令（如 docker --format '{{.}}'）按原样下发
+	if err != nil || !usesCronCommandValues(tmpl) {
+		return cr.Command, nil
+	}
+
+	data := cronCommandData{Server: cronCommandServer{ID: s.ID, Name: s.Name}}
+	if s.GeoIP != nil {
+		data.Server.IPv4 = s.GeoIP.IP.IPv4Addr
+		data.Server.IPv6 = s.GeoIP.IP.IPv6Addr
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		if errors.Is(err, errCronCommandValue) {
+			return "", err
+		}
+		return cr.Command, nil
+	}
+	return b.String(), nil
+}
+
+// cronCanUseSecret 任务只能使用其所有者的密钥，管理员的任务可使用全部密钥
+func cronCanUseSecret(cr *model.Cron, secret *model.Secret) bool {
+	return secret.UserID == cr.UserID || IsAdminUser(cr.UserID)
+}
+
+// usesCronCommandValues 判断模板中是否调用了 param、secret 或引用了 .Server
+func usesCronCommandValues(tmpl *template.Template) bool {
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && usesCronCommandNode(t.Tree.Root) {
+			return true
+		}
+	}
+	return false
+}
+
+func usesCronCommandNode(node parse.Node) bool {
+	switch n := node.(type) {
+	case *parse.IdentifierNode:
+		return n.Ident == "param" || n.Ident == "secret"
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == "Server"
+	case *parse.ChainNode:
+		return usesCronCommandNode(n.Node)
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Nodes {
+			if usesCronCommandNode(c) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return usesCronCommandNode(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Cmds {
+			if usesCronCommandNode(c) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if usesCronCommandNode(arg) {
+				return true
+			}
+		}
+	case *parse.IfNode:
+		return usesCronCommandBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return usesCronCommandBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return usesCronCommandBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		return usesCronCommandNode(n.Pipe)
+	}
+	return false
+}
+
+func usesCronCommandBranch(n *parse.BranchNode) bool {
+	return usesCronCommandNode(n.Pipe) || usesCronCommandNode(n.List) || usesCronCommandNode(n.ElseList)
+}
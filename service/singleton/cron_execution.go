@@ -0,0 +1,323 @@
+package singleton
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/jinzhu/copier"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+	pb "github.com/telexy324/billabong/proto"
+)
+
+type cronRunKey struct {
+	cronID   uint64
+	serverID uint64
+}
+
+// cronRunState 某个计划任务在单台服务器上的执行状态
+type cronRunState struct {
+	running []*cronRun             // 已下发等待结果的执行，按下发顺序排列
+	queue   []*model.CronExecution // 排队等待执行
+}
+
+type cronRun struct {
+	executionID uint64
+	timer       *time.Timer
+}
+
+// dispatch 按照并发策略向服务器下发计划任务并记录执行情况。
+// runsMu 只保护执行状态，写库、下发与通知均在锁外进行，以免阻塞 agent 上报结果
+func (c *CronClass) dispatch(cr *model.Cron, s *model.Server, source uint8, workflowRunID uint64, params map[string]string) *model.CronExecution {
+	execution := &model.CronExecution{
+		CronID:        cr.ID,
+		ServerID:      s.ID,
+		Trigger:       source,
+		WorkflowRunID: workflowRunID,
+		DispatchedAt:  time.Now(),
+		Parameters:    params,
+	}
+
+	if s.TaskStream == nil {
+		execution.Status = model.CronExecutionOffline
+		saveCronExecution(execution)
+		// 保存当前服务器状态信息
+		curServer := model.Server{}
+		copier.Copy(&curServer, s)
+		NotificationShared.SendNotification(cr.NotificationGroupID, Localizer.Tf("[Task failed] %s: server %s is offline and cannot execute the task", cr.Name, s.Name), "", &curServer)
+		return execution
+	}
+
+	// 先保存记录以获得执行 ID，排队与下发都依赖它
+	execution.Status = model.CronExecutionQueued
+	if !saveCronExecution(execution) {
+		return execution
+	}
+
+	key := cronRunKey{cr.ID, s.ID}
+
+	c.runsMu.Lock()
+	state := c.runs[key]
+	if state == nil {
+		state = &cronRunState{}
+		c.runs[key] = state
+	}
+
+	if len(state.running) > 0 {
+		switch cr.ConcurrencyPolicy {
+		case model.CronConcurrencySkip:
+			c.runsMu.Unlock()
+			execution.Status = model.CronExecutionSkipped
+			saveCronExecution(execution)
+			return execution
+		case model.CronConcurrencyQueue:
+			state.queue = append(state.queue, execution)
+			c.runsMu.Unlock()
+			return execution
+		}
+	}
+
+	run := &cronRun{executionID: execution.ID}
+	state.running = append(state.running, run)
+	c.runsMu.Unlock()
+
+	c.send(cr, s, key, run, execution)
+	return execution
+}
+
+// send 下发已登记的执行，失败时释放该执行并继续处理排队中的任务。调用时不能持有 runsMu
+func (c *CronClass) send(cr *model.Cron, s *model.Server, key cronRunKey, run *cronRun, execution *model.CronExecution) {
+	execution.DispatchedAt = time.Now()
+
+	// 密钥仅在此处替换，不会保存到执行记录中
+	command, err := renderCronCommand(cr, s, execution.Parameters)
+	if err != nil {
+		c.fail(key, run, execution, err.Error())
+		return
+	}
+
+	execution.Status = model.CronExecutionDispatched
+	if !saveCronExecution(execution) {
+		c.release(key, run)
+		return
+	}
+
+	if timeout := executionTimeout(cr, execution.WorkflowRunID); timeout > 0 {
+		c.runsMu.Lock()
+		run.timer = time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+			c.timeout(key, execution.ID)
+		})
+		c.runsMu.Unlock()
+	}
+
+	if err := s.TaskStream.Send(&pb.Task{
+		Id:   model.CronExecutionTaskFlag | execution.ID,
+		Data: command,
+		Type: model.TaskTypeCommand,
+	}); err != nil {
+		log.Printf("NEZHA>> Failed to dispatch cron task %d to server %d: %v", cr.ID, s.ID, err)
+		c.fail(key, run, execution, err.Error())
+	}
+}
+
+// fail 将未能下发的执行标记为失败并释放
+func (c *CronClass) fail(key cronRunKey, run *cronRun, execution *model.CronExecution, output string) {
+	if !c.remove(key, run) {
+		return
+	}
+	now := time.Now()
+	execution.Status = model.CronExecutionFailed
+	execution.FinishedAt = &now
+	execution.SetOutput(output)
+	saveCronExecution(execution)
+	c.next(key)
+}
+
+// release 释放执行并继续处理排队中的任务
+func (c *CronClass) release(key cronRunKey, run *cronRun) {
+	if c.remove(key, run) {
+		c.next(key)
+	}
+}
+
+// remove 从执行状态中移除，返回是否移除成功（结果、超时与下发失败只会有一个生效）
+func (c *CronClass) remove(key cronRunKey, run *cronRun) bool {
+	c.runsMu.Lock()
+	defer c.runsMu.Unlock()
+
+	state := c.runs[key]
+	if state == nil {
+		return false
+	}
+	index := slices.Index(state.running, run)
+	if index < 0 {
+		return false
+	}
+	state.running = slices.Delete(state.running, index, index+1)
+	if run.timer != nil {
+		run.timer.Stop()
+	}
+	return true
+}
+
+// timeout 执行超时，标记失败并通知，然后执行排队中的任务
+func (c *CronClass) timeout(key cronRunKey, executionID uint64) {
+	c.runsMu.Lock()
+	var run *cronRun
+	if state := c.runs[key]; state != nil {
+		index := slices.IndexFunc(state.running, func(r *cronRun) bool {
+			return r.executionID == executionID
+		})
+		if index >= 0 {
+			run = state.running[index]
+		}
+	}
+	c.runsMu.Unlock()
+
+	if run == nil || !c.remove(key, run) {
+		return
+	}
+
+	var execution model.CronExecution
+	if err := DB.First(&execution, executionID).Error; err == nil && execution.Status == model.CronExecutionDispatched {
+		execution.Status = model.CronExecutionTimeout
+		saveCronExecution(&execution)
+	}
+
+	if cr, ok := c.Get(key.cronID); ok {
+		server, _ := ServerShared.Get(key.serverID)
+		var curServer model.Server
+		var serverName string
+		if server != nil {
+			copier.Copy(&curServer, server)
+			serverName = server.Name
+		}
+		NotificationShared.SendNotification(cr.NotificationGroupID, Localizer.Tf("[Task timed out] %s: server %s did not report a result within %d seconds", cr.Name, serverName, executionTimeout(cr, execution.WorkflowRunID)), "", &curServer)
+	}
+
+	c.next(key)
+}
+
+// executionTimeout 返回等待执行结果的秒数，工作流步骤使用的任务未设置超时时使用默认值，避免工作流一直等待
+func executionTimeout(cr *model.Cron, workflowRunID uint64) uint64 {
+	if cr.Timeout == 0 && workflowRunID != 0 {
+		return workflowStepTimeout
+	}
+	return cr.Timeout
+}
+
+// next 在没有执行中的任务时下发排队中的下一个任务
+func (c *CronClass) next(key cronRunKey) {
+	for {
+		c.runsMu.Lock()
+		state := c.runs[key]
+		if state == nil || len(state.running) > 0 {
+			c.runsMu.Unlock()
+			return
+		}
+		if len(state.queue) == 0 {
+			delete(c.runs, key)
+			c.runsMu.Unlock()
+			return
+		}
+		execution := state.queue[0]
+		state.queue = state.queue[1:]
+		run := &cronRun{executionID: execution.ID}
+		state.running = append(state.running, run)
+		c.runsMu.Unlock()
+
+		cr, ok := c.Get(key.cronID)
+		server, _ := ServerShared.Get(key.serverID)
+		if ok && server != nil && server.TaskStream != nil {
+			c.send(cr, server, key, run, execution)
+			return
+		}
+
+		c.remove(key, run)
+		execution.Status = model.CronExecutionOffline
+		saveCronExecution(execution)
+	}
+}
+
+// CronTaskID 解析 agent 返回的任务 ID，得到计划任务 ID 与执行记录 ID。
+// 旧版本下发的任务 ID 即为计划任务 ID，此时执行记录 ID 为 0
+func (c *CronClass) CronTaskID(taskID uint64) (cronID, executionID uint64) {
+	if taskID&model.CronExecutionTaskFlag == 0 {
+		return taskID, 0
+	}
+	executionID = taskID &^ model.CronExecutionTaskFlag
+	var execution model.CronExecution
+	if err := DB.Select("id", "cron_id").First(&execution, executionID).Error; err != nil {
+		return 0, 0
+	}
+	return execution.CronID, executionID
+}
+
+// FinishCronExecution 根据 agent 上报的结果更新对应的执行记录
+func (c *CronClass) FinishCronExecution(cronID, executionID, serverID uint64, result *pb.TaskResult) {
+	now := time.Now()
+	startedAt := now.Add(-time.Duration(float64(result.GetDelay()) * float64(time.Second)))
+	key := cronRunKey{cronID, serverID}
+
+	c.runsMu.Lock()
+	var run *cronRun
+	if state := c.runs[key]; state != nil && len(state.running) > 0 {
+		index := 0
+		if executionID != 0 {
+			index = slices.IndexFunc(state.running, func(r *cronRun) bool {
+				return r.executionID == executionID
+			})
+		}
+		if index >= 0 {
+			run = state.running[index]
+		}
+	}
+	c.runsMu.Unlock()
+
+	found := run != nil && c.remove(key, run)
+
+	var execution model.CronExecution
+	switch {
+	case found:
+		DB.First(&execution, run.executionID)
+	case executionID != 0:
+		// 已超时或面板重启后收到的结果，只更新这次执行的记录
+		DB.Where("id = ? AND cron_id = ? AND server_id = ?", executionID, cronID, serverID).First(&execution)
+	default:
+		DB.Where("cron_id = ? AND server_id = ? AND status IN (?) AND finished_at IS NULL", cronID, serverID,
+			[]uint8{model.CronExecutionDispatched, model.CronExecutionTimeout}).Order("id").First(&execution)
+	}
+
+	if execution.ID == 0 {
+		// 找不到下发记录时仍保存结果
+		execution = model.CronExecution{
+			CronID:       cronID,
+			ServerID:     serverID,
+			DispatchedAt: startedAt,
+		}
+	}
+
+	execution.Status = utils.IfOr[uint8](result.GetSuccessful(), model.CronExecutionSucceeded, model.CronExecutionFailed)
+	execution.StartedAt = &startedAt
+	execution.FinishedAt = &now
+	execution.SetOutput(result.GetData())
+	saveCronExecution(&execution)
+
+	if found {
+		c.next(key)
+	}
+}
+
+func saveCronExecution(execution *model.CronExecution) bool {
+	if err := DB.Save(execution).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save cron execution: %v", err)
+		return false
+	}
+	// 通知工作流该步骤的执行结果，异步调用以免阻塞下发
+	if execution.WorkflowRunID != 0 && execution.Finished() {
+		go WorkflowShared.finishExecution(execution.WorkflowRunID, execution.ID, execution.Status)
+	}
+	return true
+}
@@ -3,21 +3,27 @@ package singleton
 import (
 	"cmp"
 	"fmt"
+	"math/rand/v2"
 	"slices"
 	"strings"
-
-	"github.com/jinzhu/copier"
+	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 
 	"github.com/telexy324/billabong/model"
 	"github.com/telexy324/billabong/pkg/utils"
-	pb "github.com/telexy324/billabong/proto"
 )
 
+// cronParser 与 CronShared 使用相同的解析规则
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
 type CronClass struct {
 	class[uint64, *model.Cron]
 	*cron.Cron
+
+	runsMu sync.Mutex
+	runs   map[cronRunKey]*cronRunState // 各服务器上正在执行的任务
 }
 
 func NewCronClass() *CronClass {
@@ -38,7 +44,7 @@ func NewCronClass() *CronClass {
 			continue
 		}
 		// 注册计划任务
-		cron.CronJobID, err = cronx.AddFunc(cron.Scheduler, CronTrigger(cron))
+		cron.CronJobID, err = cronx.AddFunc(cron.Scheduler, CronTrigger(cron, model.CronTriggerSchedule))
 		if err == nil {
 			list[cron.ID] = cron
 		} else {
@@ -59,12 +65,16 @@ func NewCronClass() *CronClass {
 	}
 	cronx.Start()
 
+	// 面板重启后排队中的任务不会再执行
+	DB.Model(&model.CronExecution{}).Where("status = ?", model.CronExecutionQueued).Update("status", model.CronExecutionSkipped)
+
 	return &CronClass{
 		class: class[uint64, *model.Cron]{
 			list:       list,
 			sortedList: sortedList,
 		},
 		Cron: cronx,
+		runs: make(map[cronRunKey]*cronRunState),
 	}
 }
 
@@ -110,7 +120,26 @@ func (c *CronClass) sortList() {
 	c.sortedList = sortedList
 }
 
-func (c *CronClass) SendTriggerTasks(taskIDs []uint64, triggerServer uint64) {
+// ParseCronSchedule 解析计划任务的执行时间表达式
+func ParseCronSchedule(spec string) (cron.Schedule, error) {
+	return cronParser.Parse(spec)
+}
+
+// CronNextTimes 返回 from 之后、to 之前（to 为零值时不限制）最多 n 次执行时间
+func CronNextTimes(sched cron.Schedule, from, to time.Time, n int) []time.Time {
+	var times []time.Time
+	t := from.In(Loc)
+	for len(times) < n {
+		t = sched.Next(t)
+		if t.IsZero() || (!to.IsZero() && t.After(to)) {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+func (c *CronClass) SendTriggerTasks(taskIDs []uint64, source uint8, triggerServer uint64) {
 	c.listMu.RLock()
 	var cronLists []*model.Cron
 	for _, taskID := range taskIDs {
@@ -122,60 +151,69 @@ func (c *CronClass) SendTriggerTasks(taskIDs []uint64, triggerServer uint64) {
 
 	// 依次调用CronTrigger发送任务
 	for _, c := range cronLists {
-		go CronTrigger(c, triggerServer)()
+		go CronTrigger(c, source, triggerServer)()
 	}
 }
 
-func ManualTrigger(cr *model.Cron) {
-	CronTrigger(cr)()
+func ManualTrigger(cr *model.Cron, params map[string]string) {
+	triggerCron(cr, model.CronTriggerManual, params)
+}
+
+func CronTrigger(cr *model.Cron, source uint8, triggerServer ...uint64) func() {
+	return func() {
+		triggerCron(cr, source, nil, triggerServer...)
+	}
+}
+
+func triggerCron(cr *model.Cron, source uint8, params map[string]string, triggerServer ...uint64) {
+	for _, s := range cronTargets(cr, nil, nil, triggerServer...) {
+		if cr.Jitter > 0 && cr.Cover != model.CronCoverAlertTrigger {
+			// 随机延迟下发，错开大量服务器同时执行
+			go func() {
+				time.Sleep(rand.N(time.Duration(cr.Jitter) * time.Second))
+				CronShared.dispatch(cr, s, source, 0, params)
+			}()
+			continue
+		}
+		CronShared.dispatch(cr, s, source, 0, params)
+	}
 }
 
-func CronTrigger(cr *model.Cron, triggerServer ...uint64) func() {
+// cronTargets 返回计划任务需要下发的服务器，指定了 servers 或 groups 时忽略任务本身的覆盖范围
+func cronTargets(cr *model.Cron, servers, groups []uint64, triggerServer ...uint64) []*model.Server {
+	var targets []*model.Server
+	if len(servers) > 0 || len(groups) > 0 {
+		for _, id := range utils.Unique(append(slices.Clone(servers), ServerGroupShared.Members(groups...)...)) {
+			if s, ok := ServerShared.Get(id); ok {
+				targets = append(targets, s)
+			}
+		}
+		return targets
+	}
+
+	if cr.Cover == model.CronCoverAlertTrigger {
+		if len(triggerServer) == 0 || triggerServer[0] == 0 {
+			return nil
+		}
+		if s, ok := ServerShared.Get(triggerServer[0]); ok {
+			targets = append(targets, s)
+		}
+		return targets
+	}
+
 	crIgnoreMap := make(map[uint64]bool)
 	for j := 0; j < len(cr.Servers); j++ {
 		crIgnoreMap[cr.Servers[j]] = true
 	}
-	return func() {
-		if cr.Cover == model.CronCoverAlertTrigger {
-			if len(triggerServer) == 0 {
-				return
-			}
-			if s, ok := ServerShared.Get(triggerServer[0]); ok {
-				if s.TaskStream != nil {
-					s.TaskStream.Send(&pb.Task{
-						Id:   cr.ID,
-						Data: cr.Command,
-						Type: model.TaskTypeCommand,
-					})
-				} else {
-					// 保存当前服务器状态信息
-					curServer := model.Server{}
-					copier.Copy(&curServer, s)
-					NotificationShared.SendNotification(cr.NotificationGroupID, Localizer.Tf("[Task failed] %s: server %s is offline and cannot execute the task", cr.Name, s.Name), "", &curServer)
-				}
-			}
-			return
+	for _, s := range ServerShared.Range {
+		listed := crIgnoreMap[s.ID] || ServerGroupShared.Contains(cr.ServerGroups, s.ID) || s.MatchesSelector(cr.ServerSelector)
+		if cr.Cover == model.CronCoverAll && listed {
+			continue
 		}
-
-		for _, s := range ServerShared.Range {
-			if cr.Cover == model.CronCoverAll && crIgnoreMap[s.ID] {
-				continue
-			}
-			if cr.Cover == model.CronCoverIgnoreAll && !crIgnoreMap[s.ID] {
-				continue
-			}
-			if s.TaskStream != nil {
-				s.TaskStream.Send(&pb.Task{
-					Id:   cr.ID,
-					Data: cr.Command,
-					Type: model.TaskTypeCommand,
-				})
-			} else {
-				// 保存当前服务器状态信息
-				curServer := model.Server{}
-				copier.Copy(&curServer, s)
-				NotificationShared.SendNotification(cr.NotificationGroupID, Localizer.Tf("[Task failed] %s: server %s is offline and cannot execute the task", cr.Name, s.Name), "", &curServer)
-			}
+		if cr.Cover == model.CronCoverIgnoreAll && !listed {
+			continue
 		}
+		targets = append(targets, s)
 	}
+	return targets
 }
@@ -3,20 +3,25 @@ package singleton
 import (
 	"cmp"
 	"fmt"
+	"log"
 	"slices"
-
-	"github.com/libdns/cloudflare"
-	tencentcloud "github.com/nezhahq/libdns-tencentcloud"
+	"sync"
 
 	"github.com/telexy324/billabong/model"
 	ddns2 "github.com/telexy324/billabong/pkg/ddns"
-	"github.com/telexy324/billabong/pkg/ddns/dummy"
-	"github.com/telexy324/billabong/pkg/ddns/webhook"
+	_ "github.com/telexy324/billabong/pkg/ddns/providers"
 	"github.com/telexy324/billabong/pkg/utils"
 )
 
 type DDNSClass struct {
 	class[uint64, *model.DDNSProfile]
+
+	verifyMu sync.Mutex
+	checksMu sync.RWMutex
+	checks   map[string]*model.DDNSDriftCheck // 最近一次解析校验的结果
+
+	groupMu        sync.Mutex
+	groupPublished map[uint64]string // 分组模式配置最近一次成功发布的内容
 }
 
 func NewDDNSClass() *DDNSClass {
@@ -25,6 +30,9 @@ func NewDDNSClass() *DDNSClass {
 	DB.Find(&sortedList)
 	list := make(map[uint64]*model.DDNSProfile, len(sortedList))
 	for _, profile := range sortedList {
+		if err := profile.DecryptConfig(Conf.SecretEncryptionKey); err != nil {
+			log.Printf("NEZHA>> Failed to decrypt config of DDNS profile %d: %v", profile.ID, err)
+		}
 		list[profile.ID] = profile
 	}
 
@@ -33,6 +41,7 @@ func NewDDNSClass() *DDNSClass {
 			list:       list,
 			sortedList: sortedList,
 		},
+		checks: make(map[string]*model.DDNSDriftCheck),
 	}
 
 	OnNameserverUpdate()
@@ -54,6 +63,14 @@ func (c *DDNSClass) Delete(idList []uint64) {
 	}
 	c.listMu.Unlock()
 
+	c.checksMu.Lock()
+	for k, r := range c.checks {
+		if slices.Contains(idList, r.ProfileID) {
+			delete(c.checks, k)
+		}
+	}
+	c.checksMu.Unlock()
+
 	c.sortList()
 }
 
@@ -73,27 +90,31 @@ func (c *DDNSClass) GetDDNSProvidersFromProfiles(profileId []uint64, ip *model.I
 
 	providers := make([]*ddns2.Provider, 0, len(profiles))
 	for _, profile := range profiles {
-		provider := &ddns2.Provider{DDNSProfile: profile, IPAddrs: ip}
-		switch profile.Provider {
-		case model.ProviderDummy:
-			provider.Setter = &dummy.Provider{}
-			providers = append(providers, provider)
-		case model.ProviderWebHook:
-			provider.Setter = &webhook.Provider{DDNSProfile: profile}
-			providers = append(providers, provider)
-		case model.ProviderCloudflare:
-			provider.Setter = &cloudflare.Provider{APIToken: profile.AccessSecret}
-			providers = append(providers, provider)
-		case model.ProviderTencentCloud:
-			provider.Setter = &tencentcloud.Provider{SecretId: profile.AccessID, SecretKey: profile.AccessSecret}
-			providers = append(providers, provider)
-		default:
-			return nil, fmt.Errorf("无法找到配置的DDNS提供者 %s", profile.Provider)
+		provider := &ddns2.Provider{DDNSProfile: profile, IPAddrs: ip, OnUpdate: saveDDNSUpdateLog}
+		setter, err := ddns2.NewSetter(profile)
+		if err != nil {
+			return nil, err
 		}
+		provider.Setter = setter
+		providers = append(providers, provider)
 	}
 	return providers, nil
 }
 
+// ServerProfiles 返回对服务器生效的 DDNS 配置：启用 DDNS 时服务器上配置的，以及分配给其所在分组的
+func (c *DDNSClass) ServerProfiles(server *model.Server) []uint64 {
+	var ids []uint64
+	if server.EnableDDNS {
+		ids = append(ids, server.DDNSProfiles...)
+	}
+	for _, p := range c.GetSortedList() {
+		if p.ServerGroupID == 0 && ServerGroupShared.Contains(p.ServerGroups, server.ID) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return utils.Unique(ids)
+}
+
 func (c *DDNSClass) sortList() {
 	c.listMu.RLock()
 	defer c.listMu.RUnlock()
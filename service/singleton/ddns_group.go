@@ -0,0 +1,87 @@
+package singleton
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+)
+
+// RefreshGroupRecords 以轮询记录集发布分组模式配置下所有在线服务器的 IP，
+// 成员 IP 变动、上线或离线后重新推送
+func (c *DDNSClass) RefreshGroupRecords() {
+	c.groupMu.Lock()
+	defer c.groupMu.Unlock()
+
+	published := make(map[uint64]string)
+	for _, profile := range c.GetSortedList() {
+		if profile.ServerGroupID == 0 {
+			continue
+		}
+
+		members := onlineGroupMembers(profile.ServerGroupID)
+		// 全部离线时保留已发布的记录
+		if len(members) == 0 {
+			published[profile.ID] = c.groupPublished[profile.ID]
+			continue
+		}
+
+		signature := groupSignature(profile, members)
+		if c.groupPublished[profile.ID] == signature {
+			published[profile.ID] = signature
+			continue
+		}
+
+		providers, err := c.GetDDNSProvidersFromProfiles([]uint64{profile.ID}, nil)
+		if err != nil {
+			log.Printf("NEZHA>> Failed to retrieve DDNS configuration: %v", err)
+			continue
+		}
+
+		failed := false
+		for _, provider := range providers {
+			provider.Members = members
+			provider.Trigger = model.DDNSTriggerGroupChange
+			provider.OnUpdate = func(l *model.DDNSUpdateLog) {
+				saveDDNSUpdateLog(l)
+				if !l.Success {
+					failed = true
+				}
+			}
+			provider.UpdateDomain(context.Background())
+		}
+		// 失败时不记录，下一轮重新推送
+		if !failed {
+			published[profile.ID] = signature
+		}
+	}
+
+	c.groupPublished = published
+}
+
+func onlineGroupMembers(groupID uint64) []*model.IP {
+	var members []*model.IP
+	for _, id := range ServerGroupShared.Members(groupID) {
+		server, ok := ServerShared.Get(id)
+		if !ok || server.GeoIP == nil || time.Since(server.LastActive) >= serverOnlineTimeout {
+			continue
+		}
+		ip := server.GeoIP.IP
+		members = append(members, &ip)
+	}
+	return members
+}
+
+// groupSignature 标识一次发布的内容，配置修改或成员 IP 变化时改变
+func groupSignature(profile *model.DDNSProfile, members []*model.IP) string {
+	ips := make([]string, 0, len(members))
+	for _, ip := range members {
+		ips = append(ips, ip.Join())
+	}
+	slices.Sort(ips)
+	return fmt.Sprintf("%d|%s", profile.UpdatedAt.UnixNano(), strings.Join(ips, ";"))
+}
@@ -0,0 +1,145 @@
+package singleton
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+	ddns2 "github.com/telexy324/billabong/pkg/ddns"
+)
+
+func saveDDNSUpdateLog(l *model.DDNSUpdateLog) {
+	if err := DB.Create(l).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save DDNS update log: %v", err)
+	}
+}
+
+func driftCheckKey(serverID, profileID uint64, domain, recordType string) string {
+	return fmt.Sprintf("%d/%d/%s/%s", serverID, profileID, domain, recordType)
+}
+
+// VerifyRecords 通过配置的 DNS 服务器解析各服务器管理的域名，
+// 发布的记录与服务器当前 IP 不一致时标记漂移，并按配置重新推送
+func (c *DDNSClass) VerifyRecords() {
+	if !c.verifyMu.TryLock() {
+		return
+	}
+	defer c.verifyMu.Unlock()
+
+	checks := make(map[string]*model.DDNSDriftCheck)
+	for _, server := range ServerShared.GetSortedList() {
+		if server.GeoIP == nil {
+			continue
+		}
+		ip := server.GeoIP.IP
+
+		for _, profileID := range c.ServerProfiles(server) {
+			profile, ok := c.Get(profileID)
+			// 分组模式的记录由 RefreshGroupRecords 维护，代理记录无法通过解析校验
+			if !ok || profile.ServerGroupID != 0 || profile.Proxied() {
+				continue
+			}
+
+			domains := server.OverrideDDNSDomains[profileID]
+			if len(domains) == 0 {
+				domains = profile.Domains
+			}
+
+			var drifted []*model.DDNSDriftCheck
+			var driftedDomains []string
+			for _, domain := range domains {
+				var results []*model.DDNSDriftCheck
+				if *profile.EnableIPv4 && ip.IPv4Addr != "" {
+					results = append(results, verifyRecord(server, profileID, domain, "A", ip.IPv4Addr))
+				}
+				if *profile.EnableIPv6 && ip.IPv6Addr != "" {
+					results = append(results, verifyRecord(server, profileID, domain, "AAAA", ip.IPv6Addr))
+				}
+
+				domainDrifted := false
+				for _, r := range results {
+					checks[driftCheckKey(r.ServerID, r.ProfileID, r.Domain, r.RecordType)] = r
+					if r.Drift {
+						drifted = append(drifted, r)
+						domainDrifted = true
+					}
+				}
+				if domainDrifted {
+					driftedDomains = append(driftedDomains, domain)
+				}
+			}
+
+			if len(driftedDomains) == 0 || !profile.RepushOnDrift {
+				continue
+			}
+			providers, err := c.GetDDNSProvidersFromProfiles([]uint64{profileID}, &model.IP{IPv4Addr: ip.IPv4Addr, IPv6Addr: ip.IPv6Addr})
+			if err != nil {
+				log.Printf("NEZHA>> Failed to retrieve DDNS configuration: %v", err)
+				continue
+			}
+			for _, provider := range providers {
+				provider.ServerID = server.ID
+				provider.Trigger = model.DDNSTriggerDrift
+				provider.UpdateDomain(context.Background(), driftedDomains...)
+			}
+			for _, r := range drifted {
+				r.Repushed = true
+			}
+		}
+	}
+
+	c.checksMu.Lock()
+	c.checks = checks
+	c.checksMu.Unlock()
+}
+
+func verifyRecord(server *model.Server, profileID uint64, domain, recordType, expected string) *model.DDNSDriftCheck {
+	r := &model.DDNSDriftCheck{
+		ServerID:   server.ID,
+		ProfileID:  profileID,
+		Domain:     domain,
+		RecordType: recordType,
+		Expected:   expected,
+		CheckedAt:  time.Now(),
+	}
+
+	published, err := ddns2.Resolve(domain, recordType)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Published = published
+	r.Drift = !slices.Contains(published, expected)
+	if r.Drift {
+		log.Printf("NEZHA>> DDNS record %s %s of server %s drifted: published %v, expected %s",
+			recordType, domain, server.Name, published, expected)
+	}
+	return r
+}
+
+// DriftChecks 返回最近一次校验中满足条件的结果
+func (c *DDNSClass) DriftChecks(filter func(*model.DDNSDriftCheck) bool) []*model.DDNSDriftCheck {
+	c.checksMu.RLock()
+	defer c.checksMu.RUnlock()
+
+	list := make([]*model.DDNSDriftCheck, 0)
+	for _, r := range c.checks {
+		if filter(r) {
+			list = append(list, r)
+		}
+	}
+	slices.SortFunc(list, func(a, b *model.DDNSDriftCheck) int {
+		return cmp.Or(
+			cmp.Compare(a.ServerID, b.ServerID),
+			cmp.Compare(a.ProfileID, b.ProfileID),
+			strings.Compare(a.Domain, b.Domain),
+			strings.Compare(a.RecordType, b.RecordType),
+		)
+	})
+	return list
+}
@@ -0,0 +1,173 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+type EnrollmentTokenClass struct {
+	class[uint64, *model.EnrollmentToken]
+
+	byHash   map[string]uint64 // 令牌哈希 -> ID，与 list 共用 listMu
+	enrollMu sync.Mutex
+}
+
+func NewEnrollmentTokenClass() *EnrollmentTokenClass {
+	var sortedList []*model.EnrollmentToken
+	DB.Order("id").Find(&sortedList)
+
+	list := make(map[uint64]*model.EnrollmentToken, len(sortedList))
+	byHash := make(map[string]uint64, len(sortedList))
+	for _, t := range sortedList {
+		list[t.ID] = t
+		byHash[t.TokenHash] = t.ID
+	}
+
+	return &EnrollmentTokenClass{
+		class: class[uint64, *model.EnrollmentToken]{
+			list:       list,
+			sortedList: sortedList,
+		},
+		byHash: byHash,
+	}
+}
+
+func (c *EnrollmentTokenClass) Update(t *model.EnrollmentToken) {
+	c.listMu.Lock()
+	c.list[t.ID] = t
+	c.byHash[t.TokenHash] = t.ID
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *EnrollmentTokenClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+	for _, id := range idList {
+		if t, ok := c.list[id]; ok {
+			delete(c.byHash, t.TokenHash)
+			delete(c.list, id)
+		}
+	}
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *EnrollmentTokenClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.EnrollmentToken) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
+
+// Lookup 根据 agent 提交的 client_secret 查找注册令牌
+func (c *EnrollmentTokenClass) Lookup(secret string) (*model.EnrollmentToken, bool) {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	id, ok := c.byHash[model.HashEnrollmentToken(secret)]
+	if !ok {
+		return nil, false
+	}
+	return c.list[id], true
+}
+
+// Enroll 使用令牌注册一台新服务器，按令牌的配置命名、加入分组并设置标签
+func (c *EnrollmentTokenClass) Enroll(t *model.EnrollmentToken, uuid, ip string) (*model.Server, error) {
+	c.enrollMu.Lock()
+	defer c.enrollMu.Unlock()
+
+	// 同一 agent 的并发请求只注册一次
+	if id, ok := ServerShared.UUIDToID(uuid); ok {
+		if s, ok := ServerShared.Get(id); ok && s.EnrollmentTokenID == t.ID {
+			return s, nil
+		}
+		return nil, Localizer.ErrorT("permission denied")
+	}
+
+	// 令牌对象可能已被更新，以缓存中的为准
+	t, ok := c.Get(t.ID)
+	if !ok || !t.Usable(time.Now()) {
+		return nil, Localizer.ErrorT("enrollment token is expired, revoked or used up")
+	}
+
+	s := model.Server{
+		UUID:              uuid,
+		Name:              t.ServerName(petname.Generate(2, "-"), uuid, t.Uses+1),
+		Labels:            t.Labels,
+		EnrollmentTokenID: t.ID,
+		Common: model.Common{
+			UserID: t.UserID,
+		},
+	}
+	if raw, err := json.Marshal(s.Labels); err == nil {
+		s.LabelsRaw = string(raw)
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&s).Error; err != nil {
+			return err
+		}
+		if err := addServerToGroups(tx, &s, t.ServerGroups); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.EnrollmentToken{}).Where("id = ?", t.ID).
+			UpdateColumn("uses", gorm.Expr("uses + ?", 1)).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.AgentEnrollment{
+			TokenID:    t.ID,
+			TokenName:  t.Name,
+			ServerID:   s.ID,
+			ServerUUID: uuid,
+			ServerName: s.Name,
+			IP:         ip,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *t
+	updated.Uses++
+	c.Update(&updated)
+
+	model.InitServer(&s)
+	ServerShared.Update(&s, uuid)
+	ServerGroupShared.ReloadMembers()
+	return &s, nil
+}
+
+// addServerToGroups 将新建的服务器加入分组，已删除的分组被忽略
+func addServerToGroups(tx *gorm.DB, s *model.Server, groups []uint64) error {
+	for _, id := range groups {
+		if _, ok := ServerGroupShared.Get(id); !ok {
+			continue
+		}
+		if err := tx.Create(&model.ServerGroupServer{
+			Common:        model.Common{UserID: s.UserID},
+			ServerGroupId: id,
+			ServerId:      s.ID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
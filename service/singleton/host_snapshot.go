@@ -0,0 +1,71 @@
+package singleton
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+)
+
+var (
+	hostSnapshotMu   sync.Mutex
+	lastHostSnapshot = make(map[uint64]*model.HostSnapshot)
+)
+
+// RecordHostSnapshot 与服务器最近一次记录的主机信息比较，变化时写入新的快照并按配置发送通知
+func RecordHostSnapshot(server *model.Server, host *model.Host) {
+	hostSnapshotMu.Lock()
+	defer hostSnapshotMu.Unlock()
+
+	prev, ok := lastHostSnapshot[server.ID]
+	if !ok {
+		var last model.HostSnapshot
+		err := DB.Where("server_id = ?", server.ID).Order("id DESC").First(&last).Error
+		if err == nil {
+			prev = &last
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("NEZHA>> Failed to load host snapshot of server %d: %v", server.ID, err)
+			return
+		}
+	}
+
+	next := model.NewHostSnapshot(server.ID, host)
+	if prev != nil {
+		next.Changes = prev.Diff(next)
+		if len(next.Changes) == 0 {
+			lastHostSnapshot[server.ID] = prev
+			return
+		}
+	}
+
+	if err := DB.Create(next).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save host snapshot of server %d: %v", server.ID, err)
+		return
+	}
+	lastHostSnapshot[server.ID] = next
+
+	if len(next.Changes) > 0 && Conf.EnableHostChangeNotification {
+		changes := make([]string, 0, len(next.Changes))
+		for _, c := range next.Changes {
+			changes = append(changes, c.String())
+		}
+		NotificationShared.SendNotification(Conf.HostChangeNotificationGroupID,
+			fmt.Sprintf("[%s] %s, %s", Localizer.T("Host Changed"), server.Name, strings.Join(changes, "; ")), "")
+	}
+}
+
+// DeleteHostSnapshots 删除服务器的主机信息历史
+func DeleteHostSnapshots(serverIDs []uint64) {
+	hostSnapshotMu.Lock()
+	defer hostSnapshotMu.Unlock()
+
+	for _, id := range serverIDs {
+		delete(lastHostSnapshot, id)
+	}
+	DB.Unscoped().Delete(&model.HostSnapshot{}, "server_id in (?)", serverIDs)
+}
@@ -10,6 +10,7 @@ import (
 
 type NATClass struct {
 	class[string, *model.NAT]
+	natStats
 
 	idToDomain map[uint64]string
 }
@@ -20,7 +21,7 @@ func NewNATClass() *NATClass {
 	DB.Find(&sortedList)
 	list := make(map[string]*model.NAT, len(sortedList))
 	idToDomain := make(map[uint64]string, len(sortedList))
-	for _, profile := range list {
+	for _, profile := range sortedList {
 		list[profile.Domain] = profile
 		idToDomain[profile.ID] = profile.Domain
 	}
@@ -30,6 +31,7 @@ func NewNATClass() *NATClass {
 			list:       list,
 			sortedList: sortedList,
 		},
+		natStats:   newNATStats(),
 		idToDomain: idToDomain,
 	}
 }
@@ -59,6 +61,13 @@ func (c *NATClass) Delete(idList []uint64) {
 	}
 
 	c.listMu.Unlock()
+
+	c.countersMu.Lock()
+	for _, id := range idList {
+		delete(c.counters, id)
+	}
+	c.countersMu.Unlock()
+
 	c.sortList()
 }
 
@@ -0,0 +1,198 @@
+package singleton
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+)
+
+// natCounter 内网穿透的流量计数，hourly 在每小时打点后清零
+type natCounter struct {
+	total         natTraffic
+	hourly        natTraffic
+	activeStreams atomic.Int64
+}
+
+type natTraffic struct {
+	requests atomic.Uint64
+	in       atomic.Uint64
+	out      atomic.Uint64
+	errors   atomic.Uint64
+	limited  atomic.Uint64
+}
+
+// natLimiterIdle 限速状态空闲超过该时长后清理，此时令牌桶早已回满
+const natLimiterIdle = time.Minute
+
+// natLimiter 按客户端 IP 限制请求速率的令牌桶
+type natLimiter struct {
+	tokens float64
+	last   time.Time
+}
+
+type natLimiterKey struct {
+	natID uint64
+	ip    string
+}
+
+type natStats struct {
+	countersMu sync.RWMutex
+	counters   map[uint64]*natCounter
+
+	limitersMu     sync.Mutex
+	limiters       map[natLimiterKey]*natLimiter
+	limitersPruned time.Time
+}
+
+func newNATStats() natStats {
+	return natStats{
+		counters: make(map[uint64]*natCounter),
+		limiters: make(map[natLimiterKey]*natLimiter),
+	}
+}
+
+func (c *NATClass) counter(id uint64) *natCounter {
+	c.countersMu.RLock()
+	counter := c.counters[id]
+	c.countersMu.RUnlock()
+	if counter != nil {
+		return counter
+	}
+
+	c.countersMu.Lock()
+	defer c.countersMu.Unlock()
+	if counter = c.counters[id]; counter == nil {
+		counter = &natCounter{}
+		c.counters[id] = counter
+	}
+	return counter
+}
+
+func (t *natTraffic) add(requests, in, out, errors, limited uint64) {
+	t.requests.Add(requests)
+	t.in.Add(in)
+	t.out.Add(out)
+	t.errors.Add(errors)
+	t.limited.Add(limited)
+}
+
+// AddRequest 记录一次请求
+func (c *NATClass) AddRequest(id uint64) {
+	counter := c.counter(id)
+	counter.total.add(1, 0, 0, 0, 0)
+	counter.hourly.add(1, 0, 0, 0, 0)
+}
+
+// AddTraffic 记录转发的字节数
+func (c *NATClass) AddTraffic(id, in, out uint64) {
+	counter := c.counter(id)
+	counter.total.add(0, in, out, 0, 0)
+	counter.hourly.add(0, in, out, 0, 0)
+}
+
+// AddError 记录一次转发失败
+func (c *NATClass) AddError(id uint64) {
+	counter := c.counter(id)
+	counter.total.add(0, 0, 0, 1, 0)
+	counter.hourly.add(0, 0, 0, 1, 0)
+}
+
+// AddLimited 记录一次超出限制被拒绝的请求
+func (c *NATClass) AddLimited(id uint64) {
+	counter := c.counter(id)
+	counter.total.add(0, 0, 0, 0, 1)
+	counter.hourly.add(0, 0, 0, 0, 1)
+}
+
+// AcquireStream 占用一个并发连接，超出限制时返回 false
+func (c *NATClass) AcquireStream(n *model.NAT) (release func(), ok bool) {
+	counter := c.counter(n.ID)
+	if active := counter.activeStreams.Add(1); n.MaxStreams > 0 && active > n.MaxStreams {
+		counter.activeStreams.Add(-1)
+		return nil, false
+	}
+	return func() { counter.activeStreams.Add(-1) }, true
+}
+
+// AllowRequest 检查客户端 IP 的请求速率
+func (c *NATClass) AllowRequest(n *model.NAT, ip string) bool {
+	if n.RateLimit == 0 {
+		return true
+	}
+
+	c.limitersMu.Lock()
+	defer c.limitersMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.limitersPruned) > natLimiterIdle {
+		// 清理空闲的限速状态，避免大量来源 IP 占用内存
+		for key, limiter := range c.limiters {
+			if now.Sub(limiter.last) > natLimiterIdle {
+				delete(c.limiters, key)
+			}
+		}
+		c.limitersPruned = now
+	}
+
+	rate := float64(n.RateLimit)
+	key := natLimiterKey{n.ID, ip}
+	limiter := c.limiters[key]
+	if limiter == nil {
+		limiter = &natLimiter{tokens: rate, last: now}
+		c.limiters[key] = limiter
+	}
+
+	limiter.tokens = min(rate, limiter.tokens+now.Sub(limiter.last).Seconds()*rate)
+	limiter.last = now
+	if limiter.tokens < 1 {
+		return false
+	}
+	limiter.tokens--
+	return true
+}
+
+// Stats 返回内网穿透的流量统计
+func (c *NATClass) Stats(id uint64) *model.NATStats {
+	counter := c.counter(id)
+	return &model.NATStats{
+		Requests:      counter.total.requests.Load(),
+		In:            counter.total.in.Load(),
+		Out:           counter.total.out.Load(),
+		ActiveStreams: counter.activeStreams.Load(),
+		Errors:        counter.total.errors.Load(),
+		Limited:       counter.total.limited.Load(),
+	}
+}
+
+// RecordNATHourlyUsage 保存内网穿透每小时的流量记录
+func RecordNATHourlyUsage() {
+	now := time.Now()
+	nowTrimSeconds := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+
+	NATShared.countersMu.RLock()
+	var txs []model.NATTransfer
+	for id, counter := range NATShared.counters {
+		tx := model.NATTransfer{
+			CreatedAt: nowTrimSeconds,
+			NATID:     id,
+			Requests:  counter.hourly.requests.Swap(0),
+			In:        counter.hourly.in.Swap(0),
+			Out:       counter.hourly.out.Swap(0),
+			Errors:    counter.hourly.errors.Swap(0),
+			Limited:   counter.hourly.limited.Swap(0),
+		}
+		if tx.Requests == 0 && tx.In == 0 && tx.Out == 0 {
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	NATShared.countersMu.RUnlock()
+
+	if len(txs) == 0 {
+		return
+	}
+	log.Printf("NEZHA>> Saved NAT traffic metrics to database. Affected %d row(s), Error: %v", len(txs), DB.Create(txs).Error)
+}
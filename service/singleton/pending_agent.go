@@ -0,0 +1,233 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+// 待审核 agent 会不断重连，最后连接时间最多每分钟写一次库
+const pendingAgentSeenInterval = time.Minute
+
+type PendingAgentClass struct {
+	class[uint64, *model.PendingAgent]
+
+	byUUID map[string]uint64 // UUID -> ID，与 list 共用 listMu
+	// 串行化所有写操作，缓存中的对象不原地修改
+	mu sync.Mutex
+}
+
+func NewPendingAgentClass() *PendingAgentClass {
+	var sortedList []*model.PendingAgent
+	DB.Order("id").Find(&sortedList)
+
+	list := make(map[uint64]*model.PendingAgent, len(sortedList))
+	byUUID := make(map[string]uint64, len(sortedList))
+	for _, p := range sortedList {
+		list[p.ID] = p
+		byUUID[p.UUID] = p.ID
+	}
+
+	return &PendingAgentClass{
+		class: class[uint64, *model.PendingAgent]{
+			list:       list,
+			sortedList: sortedList,
+		},
+		byUUID: byUUID,
+	}
+}
+
+func (c *PendingAgentClass) update(p *model.PendingAgent) {
+	c.listMu.Lock()
+	c.list[p.ID] = p
+	c.byUUID[p.UUID] = p.ID
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *PendingAgentClass) remove(idList []uint64) {
+	c.listMu.Lock()
+	for _, id := range idList {
+		if p, ok := c.list[id]; ok {
+			delete(c.byUUID, p.UUID)
+			delete(c.list, id)
+		}
+	}
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *PendingAgentClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.PendingAgent) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
+
+func (c *PendingAgentClass) getByUUID(uuid string) (*model.PendingAgent, bool) {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	id, ok := c.byUUID[uuid]
+	if !ok {
+		return nil, false
+	}
+	return c.list[id], true
+}
+
+// Record 记录一次未知 agent 的连接，返回该 agent 是否已被拒绝
+func (c *PendingAgentClass) Record(uuid string, userID uint64, ip string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	old, ok := c.getByUUID(uuid)
+	if ok && old.Rejected() {
+		return true, nil
+	}
+	if ok && old.IP == ip && old.UserID == userID && now.Sub(old.LastSeenAt) < pendingAgentSeenInterval {
+		return false, nil
+	}
+
+	var p model.PendingAgent
+	if ok {
+		p = *old
+	} else {
+		p.UUID = uuid
+	}
+	p.UserID = userID
+	p.IP = ip
+	p.LastSeenAt = now
+
+	if err := DB.Save(&p).Error; err != nil {
+		return false, err
+	}
+	c.update(&p)
+	return false, nil
+}
+
+// ReportHost 保存待审核 agent 上报的主机信息
+func (c *PendingAgentClass) ReportHost(uuid string, host *model.Host) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old, ok := c.getByUUID(uuid)
+	if !ok || old.Rejected() {
+		return nil
+	}
+	if old.Host != nil {
+		prev, _ := json.Marshal(old.Host)
+		next, _ := json.Marshal(host)
+		if string(prev) == string(next) {
+			return nil
+		}
+	}
+
+	p := *old
+	p.Host = host
+	if err := DB.Save(&p).Error; err != nil {
+		return err
+	}
+	c.update(&p)
+	return nil
+}
+
+// Approve 批准待审核的 agent，创建服务器并加入分组，服务器归属于 agent 所用密钥的用户
+func (c *PendingAgentClass) Approve(id uint64, name string, groups []uint64, labels map[string]string) (*model.Server, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.Get(id)
+	if !ok {
+		return nil, Localizer.ErrorT("pending agent id %d does not exist", id)
+	}
+	if _, ok := ServerShared.UUIDToID(p.UUID); ok {
+		return nil, Localizer.ErrorT("server with uuid %s already exists", p.UUID)
+	}
+
+	if name == "" {
+		name = petname.Generate(2, "-")
+	}
+	s := model.Server{
+		UUID:   p.UUID,
+		Name:   name,
+		Labels: labels,
+		Common: model.Common{
+			UserID: p.UserID,
+		},
+	}
+	if raw, err := json.Marshal(s.Labels); err == nil {
+		s.LabelsRaw = string(raw)
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&s).Error; err != nil {
+			return err
+		}
+		if err := addServerToGroups(tx, &s, groups); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&model.PendingAgent{}, p.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.remove([]uint64{p.ID})
+
+	model.InitServer(&s)
+	if p.Host != nil {
+		host := *p.Host
+		s.Host = &host
+	}
+	ServerShared.Update(&s, p.UUID)
+	ServerGroupShared.ReloadMembers()
+	return &s, nil
+}
+
+// Reject 拒绝待审核的 agent，删除该记录后 agent 才能重新进入待审核列表
+func (c *PendingAgentClass) Reject(id uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old, ok := c.Get(id)
+	if !ok {
+		return Localizer.ErrorT("pending agent id %d does not exist", id)
+	}
+	if old.Rejected() {
+		return nil
+	}
+
+	p := *old
+	now := time.Now()
+	p.RejectedAt = &now
+	if err := DB.Save(&p).Error; err != nil {
+		return err
+	}
+	c.update(&p)
+	return nil
+}
+
+// Delete 从缓存中移除，数据库记录由调用方删除
+func (c *PendingAgentClass) Delete(idList []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(idList)
+}
@@ -0,0 +1,94 @@
+package singleton
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/probe"
+	"github.com/telexy324/billabong/pkg/utils"
+	pb "github.com/telexy324/billabong/proto"
+)
+
+const probeTimeout = 10 * time.Second
+
+// ProbeClass 面板本地的服务监控执行器，结果以 Reporter 0 交给 ServiceSentinel 处理
+type ProbeClass struct {
+	queue chan *model.Service
+}
+
+func NewProbeClass(workers int) *ProbeClass {
+	p := &ProbeClass{
+		queue: make(chan *model.Service, workers*4),
+	}
+	for range workers {
+		go p.worker()
+	}
+	return p
+}
+
+// Submit 将监控任务放入队列，队列已满时跳过本次检查，不改变服务状态，避免阻塞任务调度
+func (p *ProbeClass) Submit(s *model.Service) {
+	select {
+	case p.queue <- s:
+	default:
+		log.Printf("NEZHA>> Probe queue is full, skipping service %d, consider raising probe_workers", s.ID)
+	}
+}
+
+func (p *ProbeClass) worker() {
+	for s := range p.queue {
+		r := runProbe(s)
+		if r == nil {
+			continue
+		}
+		ServiceSentinelShared.Dispatch(ReportData{
+			Data: &pb.TaskResult{
+				Id:         s.ID,
+				Type:       uint64(s.Type),
+				Delay:      r.Delay,
+				Data:       r.Data,
+				Successful: r.Successful,
+			},
+		})
+	}
+}
+
+func runProbe(s *model.Service) *probe.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	if !IsAdminUser(s.UserID) {
+		ctx = probe.PublicOnly(ctx)
+	}
+
+	var opts model.ServiceCheckOptions
+	if s.CheckOptions != nil {
+		opts = *s.CheckOptions
+	}
+
+	switch s.Type {
+	case model.TaskTypeHTTPGet:
+		return probe.HTTP(ctx, s.Target, nil)
+	case model.TaskTypeICMPPing:
+		return probe.ICMP(ctx, s.Target)
+	case model.TaskTypeTCPPing:
+		return probe.TCP(ctx, s.Target)
+	case model.TaskTypeDNS:
+		return probe.DNS(ctx, s.Target, opts.DNS, dnsServers())
+	case model.TaskTypeTLS:
+		return probe.TLS(ctx, s.Target, opts.TLS)
+	case model.TaskTypeHTTP:
+		return probe.HTTP(ctx, s.Target, opts.HTTP)
+	default:
+		return nil
+	}
+}
+
+func dnsServers() []string {
+	if Conf.DNSServers != "" {
+		return strings.Split(Conf.DNSServers, ",")
+	}
+	return utils.DNSServers
+}
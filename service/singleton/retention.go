@@ -0,0 +1,237 @@
+package singleton
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+const (
+	retentionChunkSize     = 5000
+	retentionChunkInterval = 100 * time.Millisecond
+)
+
+// retentionTable 按保留天数清理的数据
+type retentionTable struct {
+	name  string
+	model any
+	days  func() int
+	cond  string // 附加的筛选条件
+}
+
+var retentionTables = []retentionTable{
+	// server_id = 0 的数据会用于/service页面与状态页的可用性展示
+	{"service_history", &model.ServiceHistory{}, func() int { return Conf.Retention.ServiceHistoryDays }, "server_id = 0"},
+	// 网络监控记录的数据较多，并且前端仅使用了 1 天的数据
+	{"ping_history", &model.ServiceHistory{}, func() int { return Conf.Retention.PingHistoryDays }, "server_id != 0"},
+	{"message", &model.Message{}, func() int { return Conf.Retention.MessageDays }, ""},
+	{"cron_execution", &model.CronExecution{}, func() int { return Conf.Retention.CronExecutionDays }, ""},
+	{"workflow_run", &model.WorkflowRun{}, func() int { return Conf.Retention.CronExecutionDays }, ""},
+	{"command_job", &model.CommandJob{}, func() int { return Conf.Retention.CronExecutionDays }, ""},
+	{"command_job_result", &model.CommandJobResult{}, func() int { return Conf.Retention.CronExecutionDays }, ""},
+	{"fm_audit_log", &model.FMAuditLog{}, func() int { return Conf.Retention.AuditLogDays }, ""},
+	{"ddns_update_log", &model.DDNSUpdateLog{}, func() int { return Conf.Retention.AuditLogDays }, ""},
+	{"agent_enrollment", &model.AgentEnrollment{}, func() int { return Conf.Retention.AuditLogDays }, ""},
+	{"nat_transfer", &model.NATTransfer{}, func() int { return Conf.Retention.NATTransferDays }, ""},
+}
+
+// CleanServiceHistory 清理无效或过时的监控记录、流量记录等数据
+func CleanServiceHistory() {
+	// 清理已被删除的服务与服务器的记录
+	deleteInChunks(&model.ServiceHistory{}, "service_id NOT IN (SELECT `id` FROM services)")
+	// 每日汇总长期保留，仅清理已删除服务的记录
+	deleteInChunks(&model.ServiceDailyStat{}, "service_id NOT IN (SELECT `id` FROM services)")
+	deleteInChunks(&model.Transfer{}, "server_id NOT IN (SELECT `id` FROM servers)")
+	deleteInChunks(&model.CronExecution{}, "cron_id NOT IN (SELECT `id` FROM crons)")
+	deleteInChunks(&model.WorkflowRun{}, "workflow_id NOT IN (SELECT `id` FROM workflows)")
+	deleteInChunks(&model.NATTransfer{}, "nat_id NOT IN (SELECT `id` FROM nats)")
+	deleteInChunks(&model.DDNSUpdateLog{}, "profile_id NOT IN (SELECT `id` FROM ddns)")
+	deleteInChunks(&model.HostSnapshot{}, "server_id NOT IN (SELECT `id` FROM servers)")
+	deleteInChunks(&model.AgentRolloutTarget{}, "rollout_id NOT IN (SELECT `id` FROM agent_rollouts)")
+
+	for _, t := range retentionTables {
+		days := t.days()
+		if days <= 0 || !DB.Migrator().HasTable(t.model) {
+			continue
+		}
+		query := "created_at < ?"
+		if t.cond != "" {
+			query += " AND " + t.cond
+		}
+		if n := deleteInChunks(t.model, query, time.Now().AddDate(0, 0, -days)); n > 0 {
+			log.Printf("NEZHA>> Cleaned %d expired row(s) of %s", n, t.name)
+		}
+	}
+
+	cleanTransfer()
+	cleanTerminalRecordings()
+}
+
+// cleanTransfer 根据报警规则与保留配置计算可清理流量记录的时长
+func cleanTransfer() {
+	var minKeep time.Time
+	if Conf.Retention.TransferDays > 0 {
+		minKeep = time.Now().AddDate(0, 0, -Conf.Retention.TransferDays).UTC()
+	}
+	earlier := func(a, b time.Time) time.Time {
+		if a.IsZero() || (!b.IsZero() && a.After(b)) {
+			return b
+		}
+		return a
+	}
+
+	var allServerKeep time.Time
+	specialServerKeep := make(map[uint64]time.Time)
+	var alerts []model.AlertRule
+	DB.Find(&alerts)
+	for _, alert := range alerts {
+		for _, rule := range alert.Rules {
+			// 是不是流量记录规则
+			if !rule.IsTransferDurationRule() {
+				continue
+			}
+			dataCouldRemoveBefore := rule.GetTransferDurationStart().UTC()
+			// 判断规则影响的机器范围
+			if rule.Cover == model.RuleCoverAll {
+				// 更新全局可以清理的数据点
+				allServerKeep = earlier(allServerKeep, dataCouldRemoveBefore)
+			} else {
+				// 更新特定机器可以清理数据点
+				ids := append(utils.MapKeysToSlice(rule.Ignore), ServerGroupShared.Members(rule.IgnoreGroups...)...)
+				for _, id := range append(ids, ServerShared.Select(rule.IgnoreSelector)...) {
+					specialServerKeep[id] = earlier(specialServerKeep[id], dataCouldRemoveBefore)
+				}
+			}
+		}
+	}
+
+	specialServerIDs := make([]uint64, 0, len(specialServerKeep))
+	for id, couldRemove := range specialServerKeep {
+		specialServerIDs = append(specialServerIDs, id)
+		deleteInChunks(&model.Transfer{}, "server_id = ? AND created_at < ?", id, earlier(couldRemove, minKeep))
+	}
+
+	// 没有报警规则需要且未配置保留天数时，其余服务器的流量记录全部清理
+	query, args := "1 = 1", []any{}
+	if keep := earlier(allServerKeep, minKeep); !keep.IsZero() {
+		query, args = "created_at < ?", append(args, keep)
+	}
+	if len(specialServerIDs) > 0 {
+		query, args = query+" AND server_id NOT IN (?)", append(args, specialServerIDs)
+	}
+	deleteInChunks(&model.Transfer{}, query, args...)
+}
+
+// cleanTerminalRecordings 清理超过保留天数的终端录像文件与记录
+func cleanTerminalRecordings() {
+	days := Conf.Retention.TerminalRecordingDays
+	if days <= 0 {
+		return
+	}
+
+	for {
+		var recordings []*model.TerminalRecording
+		if err := DB.Where("created_at < ?", time.Now().AddDate(0, 0, -days)).
+			Order("id").Limit(500).Find(&recordings).Error; err != nil || len(recordings) == 0 {
+			return
+		}
+		if err := DeleteTerminalRecordings(recordings); err != nil {
+			log.Printf("NEZHA>> Failed to clean terminal recordings: %v", err)
+			return
+		}
+		log.Printf("NEZHA>> Cleaned %d expired terminal recording(s)", len(recordings))
+	}
+}
+
+// DeleteTerminalRecordings 删除终端录像文件及其记录
+func DeleteTerminalRecordings(recordings []*model.TerminalRecording) error {
+	if len(recordings) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(recordings))
+	for _, r := range recordings {
+		if err := os.Remove(TerminalRecordingFile(r.Key)); err != nil && !os.IsNotExist(err) {
+			log.Printf("NEZHA>> Failed to delete terminal recording file %s: %v", r.Key, err)
+		}
+		ids = append(ids, r.ID)
+	}
+	return DB.Unscoped().Delete(&model.TerminalRecording{}, "id in (?)", ids).Error
+}
+
+// TerminalRecordingFile 返回录像文件在 TerminalRecordingPath 中的路径
+func TerminalRecordingFile(key string) string {
+	return filepath.Join(Conf.TerminalRecordingPath, filepath.Base(key))
+}
+
+// deleteInChunks 分批删除数据，避免长时间锁表
+func deleteInChunks(m any, query string, args ...any) int64 {
+	var total int64
+	for {
+		result := DB.Unscoped().Where(query, args...).Limit(retentionChunkSize).Delete(m)
+		if result.Error != nil {
+			log.Printf("NEZHA>> Failed to clean expired data: %v", result.Error)
+			return total
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < retentionChunkSize {
+			return total
+		}
+		time.Sleep(retentionChunkInterval)
+	}
+}
+
+// RetentionStats 统计各类数据的行数与估算占用空间
+func RetentionStats() ([]model.RetentionTableStat, error) {
+	tables := slices.Concat(retentionTables, []retentionTable{
+		{"transfer", &model.Transfer{}, func() int { return Conf.Retention.TransferDays }, ""},
+		{"service_daily_stat", &model.ServiceDailyStat{}, func() int { return 0 }, ""},
+		// 录像文件保存在 TerminalRecordingPath，由 cleanTerminalRecordings 连同文件一起清理
+		{"terminal_recording", &model.TerminalRecording{}, func() int { return Conf.Retention.TerminalRecordingDays }, ""},
+	})
+
+	stats := make([]model.RetentionTableStat, 0, len(tables))
+	for _, t := range tables {
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(t.model); err != nil {
+			return nil, err
+		}
+		stat := model.RetentionTableStat{
+			Name:          t.name,
+			Table:         stmt.Schema.Table,
+			RetentionDays: t.days(),
+		}
+		if !DB.Migrator().HasTable(t.model) {
+			stats = append(stats, stat)
+			continue
+		}
+
+		tx := DB.Model(t.model)
+		if t.cond != "" {
+			tx = tx.Where(t.cond)
+		}
+		if err := tx.Count(&stat.Rows).Error; err != nil {
+			return nil, err
+		}
+
+		// 按行数占比估算共用数据表的空间
+		var tableRows, tableSize int64
+		if err := DB.Raw("SELECT table_rows, data_length + index_length FROM information_schema.TABLES WHERE table_schema = DATABASE() AND table_name = ?", stat.Table).
+			Row().Scan(&tableRows, &tableSize); err == nil {
+			if t.cond == "" || tableRows == 0 {
+				stat.Size = tableSize
+			} else {
+				stat.Size = int64(float64(tableSize) * min(float64(stat.Rows)/float64(tableRows), 1))
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
@@ -0,0 +1,133 @@
+package singleton
+
+import (
+	"cmp"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+// secretRedactMinLength 过短的值不做脱敏，避免误替换正常输出
+const secretRedactMinLength = 4
+
+type SecretClass struct {
+	class[uint64, *model.Secret]
+
+	valuesMu sync.RWMutex
+	values   map[string]string // 名称 -> 解密后的值
+}
+
+func NewSecretClass() *SecretClass {
+	var sortedList []*model.Secret
+	DB.Find(&sortedList)
+
+	list := make(map[uint64]*model.Secret, len(sortedList))
+	values := make(map[string]string, len(sortedList))
+	for _, secret := range sortedList {
+		list[secret.ID] = secret
+		value, err := utils.Decrypt(Conf.SecretEncryptionKey, secret.Ciphertext)
+		if err != nil {
+			log.Printf("NEZHA>> Failed to decrypt secret %s: %v", secret.Name, err)
+			continue
+		}
+		values[secret.Name] = string(value)
+	}
+
+	return &SecretClass{
+		class: class[uint64, *model.Secret]{
+			list:       list,
+			sortedList: sortedList,
+		},
+		values: values,
+	}
+}
+
+// Update 更新密钥，value 为空时保留原值
+func (c *SecretClass) Update(s *model.Secret, value string) {
+	c.listMu.Lock()
+	old := c.list[s.ID]
+	c.list[s.ID] = s
+	c.listMu.Unlock()
+
+	c.valuesMu.Lock()
+	if old != nil && old.Name != s.Name {
+		if v, ok := c.values[old.Name]; ok && value == "" {
+			value = v
+		}
+		delete(c.values, old.Name)
+	}
+	if value != "" {
+		c.values[s.Name] = value
+	}
+	c.valuesMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *SecretClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+	c.valuesMu.Lock()
+	for _, id := range idList {
+		if s, ok := c.list[id]; ok {
+			delete(c.values, s.Name)
+			delete(c.list, id)
+		}
+	}
+	c.valuesMu.Unlock()
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *SecretClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.Secret) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
+
+// GetByName 根据名称查找密钥
+func (c *SecretClass) GetByName(name string) (*model.Secret, bool) {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	for _, s := range c.list {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Value 返回解密后的值
+func (c *SecretClass) Value(name string) (string, bool) {
+	c.valuesMu.RLock()
+	defer c.valuesMu.RUnlock()
+
+	v, ok := c.values[name]
+	return v, ok
+}
+
+// Redact 将文本中出现的密钥值替换为 ******
+func (c *SecretClass) Redact(s string) string {
+	c.valuesMu.RLock()
+	defer c.valuesMu.RUnlock()
+
+	for _, v := range c.values {
+		if len(v) >= secretRedactMinLength {
+			s = strings.ReplaceAll(s, v, "******")
+		}
+	}
+	return s
+}
@@ -71,6 +71,24 @@ func (c *ServerClass) GetSortedListForGuest() []*model.Server {
 	return slices.Clone(c.sortedListForGuest)
 }
 
+// Select 返回标签满足选择器的服务器 ID，选择器为空时返回 nil
+func (c *ServerClass) Select(selector string) []uint64 {
+	if selector == "" {
+		return nil
+	}
+
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	var ids []uint64
+	for id, s := range c.list {
+		if s.MatchesSelector(selector) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 func (c *ServerClass) UUIDToID(uuid string) (id uint64, ok bool) {
 	c.listMu.RLock()
 	defer c.listMu.RUnlock()
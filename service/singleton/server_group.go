@@ -0,0 +1,112 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+// ServerGroupClass 缓存服务器分组及其成员，供各处按分组动态解析目标服务器
+type ServerGroupClass struct {
+	class[uint64, *model.ServerGroup]
+
+	membersMu sync.RWMutex
+	members   map[uint64][]uint64
+}
+
+func NewServerGroupClass() *ServerGroupClass {
+	sgc := &ServerGroupClass{
+		class: class[uint64, *model.ServerGroup]{
+			list: make(map[uint64]*model.ServerGroup),
+		},
+	}
+
+	var groups []*model.ServerGroup
+	DB.Find(&groups)
+	for _, g := range groups {
+		sgc.list[g.ID] = g
+	}
+	sgc.sortList()
+	sgc.ReloadMembers()
+
+	return sgc
+}
+
+func (c *ServerGroupClass) Update(g *model.ServerGroup) {
+	c.listMu.Lock()
+	c.list[g.ID] = g
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *ServerGroupClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+	for _, id := range idList {
+		delete(c.list, id)
+	}
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+// ReloadMembers 在分组成员变动后从数据库重新加载
+func (c *ServerGroupClass) ReloadMembers() {
+	var sgs []model.ServerGroupServer
+	DB.Find(&sgs)
+
+	members := make(map[uint64][]uint64)
+	for _, s := range sgs {
+		members[s.ServerGroupId] = append(members[s.ServerGroupId], s.ServerId)
+	}
+
+	c.membersMu.Lock()
+	c.members = members
+	c.membersMu.Unlock()
+}
+
+// Members 返回若干分组内的全部服务器 ID
+func (c *ServerGroupClass) Members(groupIDs ...uint64) []uint64 {
+	c.membersMu.RLock()
+	defer c.membersMu.RUnlock()
+
+	var ids []uint64
+	for _, id := range groupIDs {
+		ids = append(ids, c.members[id]...)
+	}
+	return utils.Unique(ids)
+}
+
+// Contains 判断服务器是否属于任一分组
+func (c *ServerGroupClass) Contains(groupIDs []uint64, serverID uint64) bool {
+	if len(groupIDs) == 0 {
+		return false
+	}
+
+	c.membersMu.RLock()
+	defer c.membersMu.RUnlock()
+
+	for _, id := range groupIDs {
+		if slices.Contains(c.members[id], serverID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ServerGroupClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.ServerGroup) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 	"golang.org/x/exp/constraints"
+	"gorm.io/gorm/clause"
 
 	"github.com/telexy324/billabong/model"
 	"github.com/telexy324/billabong/pkg/utils"
@@ -40,6 +41,8 @@ type _TodayStatsOfService struct {
 	Up    uint64  // 今日在线计数
 	Down  uint64  // 今日离线计数
 	Delay float32 // 今日平均延迟
+
+	Incidents uint64 // 今日故障次数
 }
 
 type serviceResponseData = _TodayStatsOfService
@@ -135,6 +138,18 @@ func NewServiceSentinel(serviceSentinelDispatchBus chan<- *model.Service, sc *Se
 		ss.serviceStatusToday[id].Delay = delay / float32(totalDelayCount[id])
 	}
 
+	// 加载当日故障次数
+	var dailyStats []model.ServiceDailyStat
+	DB.Where("date = ?", today.Format(time.DateOnly)).Find(&dailyStats)
+	for _, ds := range dailyStats {
+		if st, ok := ss.serviceStatusToday[ds.ServiceID]; ok {
+			st.Incidents = ds.Incidents
+		}
+	}
+
+	// 补全已有监控记录中的每日汇总
+	ss.backfillDailyStats(today)
+
 	// 启动服务监控器
 	go ss.worker()
 
@@ -144,10 +159,18 @@ func NewServiceSentinel(serviceSentinelDispatchBus chan<- *model.Service, sc *Se
 		return nil, err
 	}
 
+	// 定时保存当日汇总，避开零点防止与换日冲突
+	_, err = crc.AddFunc("0 5-55/10 * * * *", ss.SaveDailyStats)
+	if err != nil {
+		return nil, err
+	}
+
 	return ss, nil
 }
 
 func (ss *ServiceSentinel) refreshMonthlyServiceStatus() {
+	// 保存前一天的汇总
+	ss.saveDailyStats(time.Now().In(Loc).AddDate(0, 0, -1))
 	// 刷新数据防止无人访问
 	ss.LoadStats()
 	// 将数据往前刷一天
@@ -172,6 +195,67 @@ func (ss *ServiceSentinel) refreshMonthlyServiceStatus() {
 		ss.serviceStatusToday[k].Delay = 0
 		ss.serviceStatusToday[k].Up = 0
 		ss.serviceStatusToday[k].Down = 0
+		ss.serviceStatusToday[k].Incidents = 0
+	}
+}
+
+// SaveDailyStats 将当日统计写入每日汇总表
+func (ss *ServiceSentinel) SaveDailyStats() {
+	ss.saveDailyStats(time.Now().In(Loc))
+}
+
+func (ss *ServiceSentinel) saveDailyStats(day time.Time) {
+	ss.serviceResponseDataStoreLock.RLock()
+	stats := make([]model.ServiceDailyStat, 0, len(ss.serviceStatusToday))
+	for id, st := range ss.serviceStatusToday {
+		if st.Up+st.Down == 0 && st.Incidents == 0 {
+			continue
+		}
+		stats = append(stats, model.ServiceDailyStat{
+			ServiceID: id,
+			Date:      day.Format(time.DateOnly),
+			Up:        st.Up,
+			Down:      st.Down,
+			AvgDelay:  st.Delay,
+			Incidents: st.Incidents,
+		})
+	}
+	ss.serviceResponseDataStoreLock.RUnlock()
+
+	if len(stats) == 0 {
+		return
+	}
+	if err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "service_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "up", "down", "avg_delay", "incidents"}),
+	}).Create(&stats).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save service daily stats: %v", err)
+	}
+}
+
+// backfillDailyStats 根据内存中的 30 天数据补全缺失的每日汇总，已存在的记录不会被覆盖
+func (ss *ServiceSentinel) backfillDailyStats(today time.Time) {
+	var stats []model.ServiceDailyStat
+	for id, ms := range ss.monthlyStatus {
+		for i := range 29 {
+			if ms.Up[i]+ms.Down[i] == 0 {
+				continue
+			}
+			stats = append(stats, model.ServiceDailyStat{
+				ServiceID: id,
+				Date:      today.AddDate(0, 0, i-29).Format(time.DateOnly),
+				Up:        ms.Up[i],
+				Down:      ms.Down[i],
+				AvgDelay:  ms.Delay[i],
+			})
+		}
+	}
+
+	if len(stats) == 0 {
+		return
+	}
+	if err := DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&stats, 200).Error; err != nil {
+		log.Printf("NEZHA>> Failed to backfill service daily stats: %v", err)
 	}
 }
 
@@ -366,6 +450,29 @@ func (ss *ServiceSentinel) CopyStats() map[uint64]model.ServiceResponseItem {
 	return sri
 }
 
+// CurrentStatusCode 返回服务最近 30 次监控结果对应的状态码
+func (ss *ServiceSentinel) CurrentStatusCode(id uint64) uint8 {
+	ss.serviceResponseDataStoreLock.RLock()
+	defer ss.serviceResponseDataStoreLock.RUnlock()
+
+	rd := ss.serviceResponseDataStore[id]
+	if rd.Up+rd.Down == 0 {
+		return StatusNoData
+	}
+	return GetStatusCode(rd.Up * 100 / (rd.Up + rd.Down))
+}
+
+// TodayStats 返回服务当日尚未写入每日汇总的最新统计
+func (ss *ServiceSentinel) TodayStats(id uint64) (up, down uint64, delay float32) {
+	ss.serviceResponseDataStoreLock.RLock()
+	defer ss.serviceResponseDataStoreLock.RUnlock()
+
+	if st, ok := ss.serviceStatusToday[id]; ok {
+		return st.Up, st.Down, st.Delay
+	}
+	return 0, 0, 0
+}
+
 func (ss *ServiceSentinel) Get(id uint64) (s *model.Service, ok bool) {
 	ss.servicesLock.RLock()
 	defer ss.servicesLock.RUnlock()
@@ -414,7 +521,8 @@ func (ss *ServiceSentinel) worker() {
 		css = nil
 
 		mh := r.Data
-		if mh.Type == model.TaskTypeTCPPing || mh.Type == model.TaskTypeICMPPing {
+		// 面板执行的监控没有对应服务器，不记录单服务器延迟，避免与汇总记录（server_id = 0）混淆
+		if r.Reporter > 0 && (mh.Type == model.TaskTypeTCPPing || mh.Type == model.TaskTypeICMPPing) {
 			serviceTcpMap, ok := ss.serviceResponsePing[mh.GetId()]
 			if !ok {
 				serviceTcpMap = make(map[uint64]*pingStore)
@@ -521,14 +629,22 @@ func (ss *ServiceSentinel) worker() {
 			lastStatus := ss.serviceCurrentStatusData[mh.GetId()].lastStatus
 			// 存储新的状态值
 			ss.serviceCurrentStatusData[mh.GetId()].lastStatus = stateCode
+			// 记录故障次数
+			if stateCode == StatusDown && lastStatus != StatusDown {
+				ss.serviceStatusToday[mh.GetId()].Incidents++
+			}
 
 			notifyCheck(&r, ss.notificationc, ss.crc, m, cs, mh, lastStatus, stateCode)
 		}
 		ss.serviceResponseDataStoreLock.Unlock()
 
+		if !model.IsCertReportingTask(mh.Type) {
+			continue
+		}
+
 		// TLS 证书报警
 		var errMsg string
-		if strings.HasPrefix(mh.Data, "SSL证书错误：") {
+		if strings.HasPrefix(mh.Data, model.ServiceTLSErrorPrefix) {
 			// i/o timeout、connection timeout、EOF 错误
 			if !strings.HasSuffix(mh.Data, "timeout") &&
 				!strings.HasSuffix(mh.Data, "EOF") &&
@@ -569,12 +685,20 @@ func (ss *ServiceSentinel) worker() {
 				// 需要发送提醒
 				if enableNotify {
 					// 证书过期提醒
-					if expiresNew.Before(time.Now().AddDate(0, 0, 7)) {
+					expiryDays := cs.TLSExpiryDays()
+					if expiresNew.Before(time.Now().AddDate(0, 0, expiryDays)) {
 						expiresTimeStr := expiresNew.Format("2006-01-02 15:04:05")
-						errMsg = Localizer.Tf(
-							"The TLS certificate will expire within seven days. Expiration time: %s",
-							expiresTimeStr,
-						)
+						if expiryDays == 7 {
+							errMsg = Localizer.Tf(
+								"The TLS certificate will expire within seven days. Expiration time: %s",
+								expiresTimeStr,
+							)
+						} else {
+							errMsg = Localizer.Tf(
+								"The TLS certificate will expire within %d days. Expiration time: %s",
+								expiryDays, expiresTimeStr,
+							)
+						}
 
 						// 静音规则： 服务id+证书过期时间
 						// 用于避免多个监测点对相同证书同时报警
@@ -607,13 +731,11 @@ func delayCheck(r *ReportData, nc *NotificationClass, m map[uint64]*model.Server
 	maxMuteLabel := NotificationMuteLabel.ServiceLatencyMax(mh.GetId())
 	if mh.Delay > ss.MaxLatency {
 		// 延迟超过最大值
-		reporterServer := m[r.Reporter]
-		msg := Localizer.Tf("[Latency] %s %2f > %2f, Reporter: %s", ss.Name, mh.Delay, ss.MaxLatency, reporterServer.Name)
+		msg := Localizer.Tf("[Latency] %s %2f > %2f, Reporter: %s", ss.Name, mh.Delay, ss.MaxLatency, reporterName(m, r.Reporter))
 		go nc.SendNotification(notificationGroupID, msg, minMuteLabel)
 	} else if mh.Delay < ss.MinLatency {
 		// 延迟低于最小值
-		reporterServer := m[r.Reporter]
-		msg := Localizer.Tf("[Latency] %s %2f < %2f, Reporter: %s", ss.Name, mh.Delay, ss.MinLatency, reporterServer.Name)
+		msg := Localizer.Tf("[Latency] %s %2f < %2f, Reporter: %s", ss.Name, mh.Delay, ss.MinLatency, reporterName(m, r.Reporter))
 		go nc.SendNotification(notificationGroupID, msg, maxMuteLabel)
 	} else {
 		// 正常延迟， 清除静音缓存
@@ -627,9 +749,8 @@ func notifyCheck(r *ReportData, nc *NotificationClass, crc *CronClass, m map[uin
 	// 判断是否需要发送通知
 	isNeedSendNotification := ss.Notify && (lastStatus != 0 || stateCode == StatusDown)
 	if isNeedSendNotification {
-		reporterServer := m[r.Reporter]
 		notificationGroupID := ss.NotificationGroupID
-		notificationMsg := Localizer.Tf("[%s] %s Reporter: %s, Error: %s", StatusCodeToString(stateCode), ss.Name, reporterServer.Name, mh.Data)
+		notificationMsg := Localizer.Tf("[%s] %s Reporter: %s, Error: %s", StatusCodeToString(stateCode), ss.Name, reporterName(m, r.Reporter), mh.Data)
 		muteLabel := NotificationMuteLabel.ServiceStateChanged(mh.GetId())
 
 		// 状态变更时，清除静音缓存
@@ -643,17 +764,24 @@ func notifyCheck(r *ReportData, nc *NotificationClass, crc *CronClass, m map[uin
 	// 判断是否需要触发任务
 	isNeedTriggerTask := ss.EnableTriggerTask && lastStatus != 0
 	if isNeedTriggerTask {
-		reporterServer := m[r.Reporter]
 		if stateCode == StatusGood && lastStatus != stateCode {
 			// 当前状态正常 前序状态非正常时 触发恢复任务
-			go crc.SendTriggerTasks(ss.RecoverTriggerTasks, reporterServer.ID)
+			go crc.SendTriggerTasks(ss.RecoverTriggerTasks, model.CronTriggerService, r.Reporter)
 		} else if lastStatus == StatusGood && lastStatus != stateCode {
 			// 前序状态正常 当前状态非正常时 触发失败任务
-			go crc.SendTriggerTasks(ss.FailTriggerTasks, reporterServer.ID)
+			go crc.SendTriggerTasks(ss.FailTriggerTasks, model.CronTriggerService, r.Reporter)
 		}
 	}
 }
 
+// reporterName 返回上报服务器的名称，Reporter 为 0 时表示由面板执行
+func reporterName(m map[uint64]*model.Server, reporter uint64) string {
+	if s, ok := m[reporter]; ok && s != nil {
+		return s.Name
+	}
+	return Localizer.T("Dashboard")
+}
+
 const (
 	_ = iota
 	StatusNoData
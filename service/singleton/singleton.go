@@ -29,11 +29,20 @@ var (
 	DashboardBootTime = uint64(time.Now().Unix())
 
 	ServerShared          *ServerClass
+	ServerGroupShared     *ServerGroupClass
 	ServiceSentinelShared *ServiceSentinel
 	DDNSShared            *DDNSClass
 	NotificationShared    *NotificationClass
 	NATShared             *NATClass
 	CronShared            *CronClass
+	ProbeShared           *ProbeClass
+	StatusPageShared      *StatusPageClass
+	WorkflowShared        *WorkflowClass
+	SecretShared          *SecretClass
+	CommandJobShared      *CommandJobClass
+	AgentRolloutShared    *AgentRolloutClass
+	EnrollmentTokenShared *EnrollmentTokenClass
+	PendingAgentShared    *PendingAgentClass
 )
 
 //go:embed frontend-templates.yaml
@@ -55,9 +64,18 @@ func LoadSingleton() {
 	initI18n()                                  // 加载本地化服务
 	NotificationShared = NewNotificationClass() // 加载通知服务
 	ServerShared = NewServerClass()             // 加载服务器列表
+	ServerGroupShared = NewServerGroupClass()   // 加载服务器分组
+	SecretShared = NewSecretClass()             // 加载密钥库
 	CronShared = NewCronClass()                 // 加载定时任务
 	NATShared = NewNATClass()
 	DDNSShared = NewDDNSClass()
+	ProbeShared = NewProbeClass(Conf.ProbeWorkers) // 加载面板监控执行器
+	StatusPageShared = NewStatusPageClass()        // 加载状态页
+	WorkflowShared = NewWorkflowClass()            // 加载工作流
+	CommandJobShared = NewCommandJobClass()
+	AgentRolloutShared = NewAgentRolloutClass()       // 加载 agent 分批升级
+	EnrollmentTokenShared = NewEnrollmentTokenClass() // 加载 agent 注册令牌
+	PendingAgentShared = NewPendingAgentClass()       // 加载待审核 agent
 }
 
 // InitFrontendTemplates 从内置文件中加载FrontendTemplates
@@ -110,7 +128,11 @@ func InitDBFromPath(path string) {
 		model.NAT{}, model.DDNSProfile{}, model.NotificationGroupNotification{},
 		model.WAF{}, model.Oauth2Bind{}, model.Tool{}, model.ToolGroup{}, model.ToolGroupTool{}, model.Upload{},
 		model.Topic{}, model.TopicGroup{}, model.TopicGroupTopic{}, model.Favorite{}, model.UserLike{}, model.Comment{},
-		model.UserAdditionalInfo{})
+		model.UserAdditionalInfo{}, model.StatusPage{}, model.StatusPageIncident{},
+		model.ServiceDailyStat{}, model.CronExecution{}, model.Workflow{}, model.WorkflowRun{}, model.Secret{}, model.TerminalRecording{},
+		model.CommandJob{}, model.CommandJobResult{}, model.FMAuditLog{}, model.NATTransfer{}, model.DDNSUpdateLog{},
+		model.HostSnapshot{}, model.AgentRollout{}, model.AgentRolloutTarget{},
+		model.EnrollmentToken{}, model.AgentEnrollment{}, model.PendingAgent{})
 	if err != nil {
 		panic(err)
 	}
@@ -149,55 +171,6 @@ func RecordTransferHourlyUsage() {
 	log.Printf("NEZHA>> Saved traffic metrics to database. Affected %d row(s), Error: %v", len(txs), DB.Create(txs).Error)
 }
 
-// CleanServiceHistory 清理无效或过时的 监控记录 和 流量记录
-func CleanServiceHistory() {
-	// 清理已被删除的服务器的监控记录与流量记录
-	DB.Unscoped().Delete(&model.ServiceHistory{}, "created_at < ? OR service_id NOT IN (SELECT `id` FROM services)", time.Now().AddDate(0, 0, -30))
-	// 由于网络监控记录的数据较多，并且前端仅使用了 1 天的数据
-	// 考虑到 sqlite 数据量问题，仅保留一天数据，
-	// server_id = 0 的数据会用于/service页面的可用性展示
-	DB.Unscoped().Delete(&model.ServiceHistory{}, "(created_at < ? AND server_id != 0) OR service_id NOT IN (SELECT `id` FROM services)", time.Now().AddDate(0, 0, -1))
-	DB.Unscoped().Delete(&model.Transfer{}, "server_id NOT IN (SELECT `id` FROM servers)")
-	// 计算可清理流量记录的时长
-	var allServerKeep time.Time
-	specialServerKeep := make(map[uint64]time.Time)
-	var specialServerIDs []uint64
-	var alerts []model.AlertRule
-	DB.Find(&alerts)
-	for _, alert := range alerts {
-		for _, rule := range alert.Rules {
-			// 是不是流量记录规则
-			if !rule.IsTransferDurationRule() {
-				continue
-			}
-			dataCouldRemoveBefore := rule.GetTransferDurationStart().UTC()
-			// 判断规则影响的机器范围
-			if rule.Cover == model.RuleCoverAll {
-				// 更新全局可以清理的数据点
-				if allServerKeep.IsZero() || allServerKeep.After(dataCouldRemoveBefore) {
-					allServerKeep = dataCouldRemoveBefore
-				}
-			} else {
-				// 更新特定机器可以清理数据点
-				for id := range rule.Ignore {
-					if specialServerKeep[id].IsZero() || specialServerKeep[id].After(dataCouldRemoveBefore) {
-						specialServerKeep[id] = dataCouldRemoveBefore
-						specialServerIDs = append(specialServerIDs, id)
-					}
-				}
-			}
-		}
-	}
-	for id, couldRemove := range specialServerKeep {
-		DB.Unscoped().Delete(&model.Transfer{}, "server_id = ? AND datetime(`created_at`) < datetime(?)", id, couldRemove)
-	}
-	if allServerKeep.IsZero() {
-		DB.Unscoped().Delete(&model.Transfer{}, "server_id NOT IN (?)", specialServerIDs)
-	} else {
-		DB.Unscoped().Delete(&model.Transfer{}, "server_id NOT IN (?) AND datetime(`created_at`) < datetime(?)", specialServerIDs, allServerKeep)
-	}
-}
-
 // IPDesensitize 根据设置选择是否对IP进行打码处理 返回处理后的IP(关闭打码则返回原IP)
 func IPDesensitize(ip string) string {
 	if Conf.EnablePlainIPInNotification {
@@ -0,0 +1,70 @@
+package singleton
+
+import (
+	"time"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+// ServiceSLAReports 根据每日汇总生成 [from, to] 期间的 SLA 报表，日期按面板时区计算
+func ServiceSLAReports(services []*model.Service, from, to time.Time) ([]*model.ServiceSLAReport, error) {
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	fromStr, toStr := from.Format(time.DateOnly), to.Format(time.DateOnly)
+
+	// 报表包含当天时先保存最新数据
+	now := time.Now().In(Loc)
+	today := now.Format(time.DateOnly)
+	if fromStr <= today && today <= toStr {
+		ServiceSentinelShared.SaveDailyStats()
+	}
+	year, month, day := now.Date()
+	todayMinutes := now.Sub(time.Date(year, month, day, 0, 0, 0, 0, Loc)).Minutes()
+
+	ids := make([]uint64, 0, len(services))
+	reports := make(map[uint64]*model.ServiceSLAReport, len(services))
+	for _, s := range services {
+		if _, ok := reports[s.ID]; ok {
+			continue
+		}
+		ids = append(ids, s.ID)
+		reports[s.ID] = &model.ServiceSLAReport{
+			ServiceID:   s.ID,
+			ServiceName: s.Name,
+			From:        fromStr,
+			To:          toStr,
+		}
+	}
+
+	var stats []model.ServiceDailyStat
+	if err := DB.Where("service_id IN (?) AND date >= ? AND date <= ?", ids, fromStr, toStr).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	totalDelay := make(map[uint64]float64)
+	for _, ds := range stats {
+		r := reports[ds.ServiceID]
+		r.Up += ds.Up
+		r.Down += ds.Down
+		r.Incidents += ds.Incidents
+		// 当天尚未结束，只按已经过的时间估算
+		r.DowntimeMinutes += ds.DowntimeMinutes(utils.IfOr(ds.Date == today, todayMinutes, 24*60))
+		totalDelay[ds.ServiceID] += float64(ds.AvgDelay) * float64(ds.Up)
+	}
+
+	res := make([]*model.ServiceSLAReport, 0, len(services))
+	for _, id := range ids {
+		r := reports[id]
+		if r.Up+r.Down > 0 {
+			r.Availability = float64(r.Up) / float64(r.Up+r.Down) * 100
+		}
+		if r.Up > 0 {
+			r.MeanLatency = float32(totalDelay[id] / float64(r.Up))
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
@@ -0,0 +1,254 @@
+package singleton
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+const (
+	statusPageUptimeDays = 90
+	statusPageCacheTTL   = time.Minute
+	serverOnlineTimeout  = time.Minute
+)
+
+type StatusPageClass struct {
+	class[uint64, *model.StatusPage]
+
+	slugToID   map[string]uint64
+	domainToID map[string]uint64
+}
+
+func NewStatusPageClass() *StatusPageClass {
+	var sortedList []*model.StatusPage
+
+	DB.Find(&sortedList)
+	list := make(map[uint64]*model.StatusPage, len(sortedList))
+	slugToID := make(map[string]uint64, len(sortedList))
+	domainToID := make(map[string]uint64, len(sortedList))
+	for _, page := range sortedList {
+		list[page.ID] = page
+		slugToID[page.Slug] = page.ID
+		if page.Domain != "" {
+			domainToID[page.Domain] = page.ID
+		}
+	}
+
+	return &StatusPageClass{
+		class: class[uint64, *model.StatusPage]{
+			list:       list,
+			sortedList: sortedList,
+		},
+		slugToID:   slugToID,
+		domainToID: domainToID,
+	}
+}
+
+func (c *StatusPageClass) Update(p *model.StatusPage) {
+	c.listMu.Lock()
+
+	if old, ok := c.list[p.ID]; ok {
+		delete(c.slugToID, old.Slug)
+		delete(c.domainToID, old.Domain)
+		Cache.Delete(model.CacheKeyStatusPage + old.Slug)
+	}
+
+	c.list[p.ID] = p
+	c.slugToID[p.Slug] = p.ID
+	if p.Domain != "" {
+		c.domainToID[p.Domain] = p.ID
+	}
+
+	c.listMu.Unlock()
+	c.sortList()
+	Cache.Delete(model.CacheKeyStatusPage + p.Slug)
+}
+
+func (c *StatusPageClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+
+	for _, id := range idList {
+		if p, ok := c.list[id]; ok {
+			delete(c.slugToID, p.Slug)
+			delete(c.domainToID, p.Domain)
+			delete(c.list, id)
+			Cache.Delete(model.CacheKeyStatusPage + p.Slug)
+		}
+	}
+
+	c.listMu.Unlock()
+	c.sortList()
+}
+
+func (c *StatusPageClass) GetBySlug(slug string) *model.StatusPage {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	return c.list[c.slugToID[slug]]
+}
+
+func (c *StatusPageClass) GetByDomain(domain string) *model.StatusPage {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	id, ok := c.domainToID[domain]
+	if !ok {
+		return nil
+	}
+	return c.list[id]
+}
+
+// Render 生成公开状态页数据，结果会缓存一分钟
+func (c *StatusPageClass) Render(p *model.StatusPage) (*model.StatusPageResponse, error) {
+	cacheKey := model.CacheKeyStatusPage + p.Slug
+	if v, ok := Cache.Get(cacheKey); ok {
+		return v.(*model.StatusPageResponse), nil
+	}
+
+	var serviceIDs []uint64
+	for _, section := range p.Sections {
+		serviceIDs = append(serviceIDs, section.Services...)
+	}
+	days, err := serviceDailyStats(serviceIDs, statusPageUptimeDays)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.StatusPageResponse{
+		Title:       p.Title,
+		Description: p.Description,
+		UpdatedAt:   time.Now(),
+	}
+
+	if p.LogoID > 0 {
+		var logo model.Upload
+		if err := DB.Select("url").First(&logo, p.LogoID).Error; err == nil {
+			res.Logo = logo.Url
+		}
+	}
+
+	for _, section := range p.Sections {
+		sr := model.StatusPageSectionResponse{Name: section.Name}
+		for _, id := range section.Services {
+			service, ok := ServiceSentinelShared.Get(id)
+			if !ok {
+				continue
+			}
+			item := model.StatusPageServiceItem{
+				ID:     id,
+				Name:   service.Name,
+				Status: StatusCodeToString(ServiceSentinelShared.CurrentStatusCode(id)),
+				Days:   days[id],
+			}
+			var up, down uint64
+			for _, d := range item.Days {
+				up += d.Up
+				down += d.Down
+			}
+			if up+down > 0 {
+				item.Uptime = float32(up) / float32(up+down) * 100
+			}
+			sr.Services = append(sr.Services, item)
+		}
+		for _, id := range section.Servers {
+			server, ok := ServerShared.Get(id)
+			if !ok {
+				continue
+			}
+			sr.Servers = append(sr.Servers, model.StatusPageServerResponse{
+				ID:     id,
+				Name:   server.Name,
+				Online: time.Since(server.LastActive) < serverOnlineTimeout,
+			})
+		}
+		res.Sections = append(res.Sections, sr)
+	}
+
+	var incidents []model.StatusPageIncident
+	if err := DB.Where("status_page_id = ? AND (resolved_at IS NULL OR resolved_at > ?)", p.ID, time.Now().AddDate(0, 0, -7)).
+		Order("created_at DESC").Limit(20).Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	for _, incident := range incidents {
+		res.Incidents = append(res.Incidents, model.StatusPageIncidentResponse{
+			Title:      incident.Title,
+			Content:    incident.Content,
+			Status:     incident.Status,
+			CreatedAt:  incident.CreatedAt,
+			ResolvedAt: incident.ResolvedAt,
+		})
+	}
+
+	Cache.Set(cacheKey, res, statusPageCacheTTL)
+	return res, nil
+}
+
+// serviceDailyStats 从每日汇总中读取服务最近 days 天的数据，按从旧到新排列，当天使用内存中的最新统计
+func serviceDailyStats(serviceIDs []uint64, days int) (map[uint64][]model.StatusPageDayItem, error) {
+	serviceIDs = utils.Unique(serviceIDs)
+	res := make(map[uint64][]model.StatusPageDayItem, len(serviceIDs))
+	if len(serviceIDs) == 0 {
+		return res, nil
+	}
+
+	year, month, day := time.Now().In(Loc).Date()
+	start := time.Date(year, month, day-days+1, 0, 0, 0, 0, Loc)
+
+	dateIndex := make(map[string]int, days)
+	for i := range days {
+		dateIndex[start.AddDate(0, 0, i).Format(time.DateOnly)] = i
+	}
+
+	var stats []model.ServiceDailyStat
+	if err := DB.Where("service_id IN (?) AND date >= ?", serviceIDs, start.Format(time.DateOnly)).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range serviceIDs {
+		items := make([]model.StatusPageDayItem, days)
+		for date, i := range dateIndex {
+			items[i].Date = date
+		}
+		res[id] = items
+	}
+
+	for _, ds := range stats {
+		items, ok := res[ds.ServiceID]
+		if !ok {
+			continue
+		}
+		i, ok := dateIndex[ds.Date]
+		if !ok {
+			continue
+		}
+		items[i].Up = ds.Up
+		items[i].Down = ds.Down
+		items[i].Delay = ds.AvgDelay
+	}
+
+	for id, items := range res {
+		if up, down, delay := ServiceSentinelShared.TodayStats(id); up+down > 0 {
+			today := &items[days-1]
+			today.Up, today.Down, today.Delay = up, down, delay
+		}
+	}
+
+	return res, nil
+}
+
+func (c *StatusPageClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.StatusPage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
@@ -37,6 +37,13 @@ func initUser() {
 	}
 }
 
+// IsAdminUser 判断用户是否为管理员
+func IsAdminUser(uid uint64) bool {
+	UserLock.RLock()
+	defer UserLock.RUnlock()
+	return UserInfoMap[uid].Role == model.RoleAdmin
+}
+
 func OnUserUpdate(u *model.User) {
 	UserLock.Lock()
 	defer UserLock.Unlock()
@@ -63,10 +70,13 @@ func OnUserDelete(id []uint64, errorFunc func(string, ...any) error) error {
 	var (
 		cron, server   bool
 		crons, servers []uint64
+		tokens, agents []uint64
 	)
 
 	slist := ServerShared.GetSortedList()
 	clist := CronShared.GetSortedList()
+	tlist := EnrollmentTokenShared.GetSortedList()
+	plist := PendingAgentShared.GetSortedList()
 	for _, uid := range id {
 		err := DB.Transaction(func(tx *gorm.DB) error {
 			crons = model.FindByUserID(clist, uid)
@@ -92,6 +102,21 @@ func OnUserDelete(id []uint64, errorFunc func(string, ...any) error) error {
 				return err
 			}
 
+			// 用户签发的注册令牌随用户删除
+			tokens = model.FindByUserID(tlist, uid)
+			if len(tokens) > 0 {
+				if err := tx.Unscoped().Delete(&model.EnrollmentToken{}, "id in (?)", tokens).Error; err != nil {
+					return err
+				}
+			}
+
+			agents = model.FindByUserID(plist, uid)
+			if len(agents) > 0 {
+				if err := tx.Unscoped().Delete(&model.PendingAgent{}, "id in (?)", agents).Error; err != nil {
+					return err
+				}
+			}
+
 			if err := tx.Where("id IN (?)", id).Delete(&model.User{}).Error; err != nil {
 				return err
 			}
@@ -105,8 +130,11 @@ func OnUserDelete(id []uint64, errorFunc func(string, ...any) error) error {
 		if cron {
 			CronShared.Delete(crons)
 		}
+		EnrollmentTokenShared.Delete(tokens)
+		PendingAgentShared.Delete(agents)
 
 		if server {
+			ServerGroupShared.ReloadMembers()
 			AlertsLock.Lock()
 			for _, sid := range servers {
 				for _, alert := range Alerts {
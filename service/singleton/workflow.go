@@ -0,0 +1,380 @@
+package singleton
+
+import (
+	"cmp"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/telexy324/billabong/model"
+	"github.com/telexy324/billabong/pkg/utils"
+)
+
+// workflowStepTimeout 步骤使用的计划任务未设置超时时，等待每台服务器结果的秒数
+const workflowStepTimeout = 3600
+
+type WorkflowClass struct {
+	class[uint64, *model.Workflow]
+
+	runsMu sync.Mutex
+	runs   map[uint64]*workflowRunState // 正在执行的工作流
+}
+
+// workflowRunState 工作流一次执行过程中的状态
+type workflowRunState struct {
+	workflow *model.Workflow // 开始执行时的工作流副本，执行过程中修改工作流不影响本次执行
+	run      *model.WorkflowRun
+
+	triggerServer uint64
+	pending       map[uint64]int   // 等待结果的执行记录 -> 步骤序号
+	dispatching   map[int]int      // 步骤序号 -> 尚未下发完成的服务器数量
+	early         map[uint64]uint8 // 下发完成前已返回结果的执行记录 -> 执行状态
+	failed        []bool           // 各步骤是否有执行失败
+}
+
+// workflowDispatch 一台待下发步骤任务的服务器
+type workflowDispatch struct {
+	index  int
+	cron   *model.Cron
+	server *model.Server
+	params map[string]string
+}
+
+func NewWorkflowClass() *WorkflowClass {
+	var sortedList []*model.Workflow
+	DB.Find(&sortedList)
+
+	list := make(map[uint64]*model.Workflow, len(sortedList))
+	for _, wf := range sortedList {
+		if wf.Scheduler != "" {
+			var err error
+			if wf.CronJobID, err = CronShared.AddFunc(wf.Scheduler, WorkflowTrigger(wf, model.CronTriggerSchedule)); err != nil {
+				log.Printf("NEZHA>> Failed to register workflow %d: %v", wf.ID, err)
+				NotificationShared.SendNotification(wf.NotificationGroupID, Localizer.Tf("Workflow %s failed to register and will not be executed on schedule", wf.Name), "")
+			}
+		}
+		list[wf.ID] = wf
+	}
+
+	// 面板重启后无法继续跟踪未完成的执行
+	now := time.Now()
+	DB.Model(&model.WorkflowRun{}).Where("status = ?", model.WorkflowStatusRunning).
+		Updates(map[string]any{"status": model.WorkflowStatusFailed, "finished_at": &now})
+
+	return &WorkflowClass{
+		class: class[uint64, *model.Workflow]{
+			list:       list,
+			sortedList: sortedList,
+		},
+		runs: make(map[uint64]*workflowRunState),
+	}
+}
+
+func (c *WorkflowClass) Update(wf *model.Workflow) {
+	c.listMu.Lock()
+	if old := c.list[wf.ID]; old != nil && old.CronJobID != 0 {
+		CronShared.Remove(old.CronJobID)
+	}
+	c.list[wf.ID] = wf
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *WorkflowClass) Delete(idList []uint64) {
+	c.listMu.Lock()
+	for _, id := range idList {
+		if wf := c.list[id]; wf != nil && wf.CronJobID != 0 {
+			CronShared.Remove(wf.CronJobID)
+		}
+		delete(c.list, id)
+	}
+	c.listMu.Unlock()
+
+	c.sortList()
+}
+
+func (c *WorkflowClass) sortList() {
+	c.listMu.RLock()
+	defer c.listMu.RUnlock()
+
+	sortedList := utils.MapValuesToSlice(c.list)
+	slices.SortFunc(sortedList, func(a, b *model.Workflow) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	c.sortedListMu.Lock()
+	defer c.sortedListMu.Unlock()
+	c.sortedList = sortedList
+}
+
+// SendTriggerWorkflows 报警规则触发时执行工作流
+func (c *WorkflowClass) SendTriggerWorkflows(ids []uint64, triggerServer uint64) {
+	for _, id := range ids {
+		if wf, ok := c.Get(id); ok {
+			if _, err := c.Start(wf, model.CronTriggerAlert, triggerServer); err != nil {
+				log.Printf("NEZHA>> Failed to start workflow %d: %v", id, err)
+			}
+		}
+	}
+}
+
+func WorkflowTrigger(wf *model.Workflow, source uint8) func() {
+	return func() {
+		if _, err := WorkflowShared.Start(wf, source, 0); err != nil {
+			log.Printf("NEZHA>> Failed to start workflow %d: %v", wf.ID, err)
+		}
+	}
+}
+
+// Start 开始执行工作流，返回执行记录 ID
+func (c *WorkflowClass) Start(wf *model.Workflow, source uint8, triggerServer uint64) (uint64, error) {
+	now := time.Now()
+	run := &model.WorkflowRun{
+		WorkflowID:    wf.ID,
+		Trigger:       source,
+		TriggerServer: triggerServer,
+		Status:        model.WorkflowStatusRunning,
+		Steps:         make([]model.WorkflowStepRun, len(wf.Steps)),
+	}
+	for i, step := range wf.Steps {
+		run.Steps[i] = model.WorkflowStepRun{StepID: step.ID, Status: model.WorkflowStatusPending}
+	}
+	if err := DB.Create(run).Error; err != nil {
+		return 0, err
+	}
+
+	state := &workflowRunState{
+		workflow:      wf,
+		run:           run,
+		triggerServer: triggerServer,
+		pending:       make(map[uint64]int),
+		dispatching:   make(map[int]int),
+		early:         make(map[uint64]uint8),
+		failed:        make([]bool, len(wf.Steps)),
+	}
+
+	c.runsMu.Lock()
+	c.runs[run.ID] = state
+	finished, dispatches := c.advance(state, now)
+	c.runsMu.Unlock()
+
+	c.afterAdvance(state, finished, dispatches)
+	return run.ID, nil
+}
+
+// finishExecution 记录步骤中一台服务器的执行结果，全部完成后继续执行后续步骤
+func (c *WorkflowClass) finishExecution(runID, executionID uint64, status uint8) {
+	c.runsMu.Lock()
+	state := c.runs[runID]
+	if state == nil {
+		c.runsMu.Unlock()
+		return
+	}
+	index, ok := state.pending[executionID]
+	if !ok {
+		// 下发过程中结果可能先于登记到达，暂存后由 dispatchStep 处理；超时后收到的结果不再改变步骤状态
+		if state.isDispatching() && !state.known(executionID) {
+			state.early[executionID] = status
+		}
+		c.runsMu.Unlock()
+		return
+	}
+	delete(state.pending, executionID)
+	if status != model.CronExecutionSucceeded {
+		state.failed[index] = true
+	}
+
+	finished, dispatches := c.finishStep(state, index, time.Now())
+	c.runsMu.Unlock()
+
+	c.afterAdvance(state, finished, dispatches)
+}
+
+// finishStep 步骤的所有服务器均已返回结果时结束该步骤，然后继续执行后续步骤，调用时需持有 runsMu
+func (c *WorkflowClass) finishStep(state *workflowRunState, index int, now time.Time) (bool, []workflowDispatch) {
+	step := &state.run.Steps[index]
+	if step.Status == model.WorkflowStatusRunning && !state.waiting(index) {
+		step.Status = utils.IfOr[uint8](state.failed[index], model.WorkflowStatusFailed, model.WorkflowStatusSucceeded)
+		step.FinishedAt = &now
+	}
+	return c.advance(state, now)
+}
+
+// afterAdvance 在释放 runsMu 后发送结束通知，或下发新开始的步骤
+func (c *WorkflowClass) afterAdvance(state *workflowRunState, finished bool, dispatches []workflowDispatch) {
+	if finished {
+		notifyWorkflowRun(state.workflow, state.run)
+		return
+	}
+	for _, d := range dispatches {
+		c.dispatchStep(state, d)
+	}
+}
+
+// dispatchStep 向一台服务器下发步骤使用的计划任务并登记执行记录，调用时不能持有 runsMu
+func (c *WorkflowClass) dispatchStep(state *workflowRunState, d workflowDispatch) {
+	execution := CronShared.dispatch(d.cron, d.server, model.CronTriggerWorkflow, state.run.ID, d.params)
+
+	c.runsMu.Lock()
+	state.dispatching[d.index]--
+	if execution.ID == 0 {
+		state.failed[d.index] = true
+	} else {
+		stepRun := &state.run.Steps[d.index]
+		stepRun.ExecutionIDs = append(stepRun.ExecutionIDs, execution.ID)
+		if status, ok := state.early[execution.ID]; ok {
+			delete(state.early, execution.ID)
+			if status != model.CronExecutionSucceeded {
+				state.failed[d.index] = true
+			}
+		} else {
+			state.pending[execution.ID] = d.index
+		}
+	}
+	finished, dispatches := c.finishStep(state, d.index, time.Now())
+	c.runsMu.Unlock()
+
+	c.afterAdvance(state, finished, dispatches)
+}
+
+// advance 开始所有前置步骤均已结束的步骤，并在全部步骤结束后完成本次执行，
+// 返回本次执行是否已结束以及需要下发的任务。调用时需持有 runsMu，下发与通知由调用方在释放锁后进行
+func (c *WorkflowClass) advance(state *workflowRunState, now time.Time) (bool, []workflowDispatch) {
+	wf, run := state.workflow, state.run
+
+	var dispatches []workflowDispatch
+	for progressed := true; progressed; {
+		progressed = false
+		for i, step := range wf.Steps {
+			if run.Steps[i].Status != model.WorkflowStatusPending {
+				continue
+			}
+			ready, satisfied := state.check(step.ID)
+			if !ready {
+				continue
+			}
+			progressed = true
+			if !satisfied {
+				run.Steps[i].Status = model.WorkflowStatusSkipped
+				continue
+			}
+			dispatches = append(dispatches, c.startStep(state, i, now)...)
+		}
+	}
+
+	running := slices.ContainsFunc(run.Steps, func(s model.WorkflowStepRun) bool {
+		return s.Status == model.WorkflowStatusPending || s.Status == model.WorkflowStatusRunning
+	})
+	if !running {
+		run.Status = model.WorkflowStatusSucceeded
+		if slices.ContainsFunc(run.Steps, func(s model.WorkflowStepRun) bool {
+			return s.Status == model.WorkflowStatusFailed
+		}) {
+			run.Status = model.WorkflowStatusFailed
+		}
+		run.FinishedAt = &now
+		delete(c.runs, run.ID)
+	}
+
+	if err := DB.Save(run).Error; err != nil {
+		log.Printf("NEZHA>> Failed to save workflow run: %v", err)
+	}
+	return !running, dispatches
+}
+
+// notifyWorkflowRun 发送工作流执行结束的通知
+func notifyWorkflowRun(wf *model.Workflow, run *model.WorkflowRun) {
+	if run.Status == model.WorkflowStatusFailed {
+		NotificationShared.SendNotification(wf.NotificationGroupID, Localizer.Tf("[Workflow failed] %s: run %d has failed steps", wf.Name, run.ID), "")
+	} else if wf.PushSuccessful {
+		NotificationShared.SendNotification(wf.NotificationGroupID, Localizer.Tf("[Workflow succeeded] %s: run %d completed", wf.Name, run.ID), "")
+	}
+}
+
+// startStep 开始执行步骤，返回需要下发的服务器，调用时需持有 runsMu
+func (c *WorkflowClass) startStep(state *workflowRunState, index int, now time.Time) []workflowDispatch {
+	step := state.workflow.Steps[index]
+	stepRun := &state.run.Steps[index]
+	stepRun.Status = model.WorkflowStatusRunning
+	stepRun.StartedAt = &now
+
+	cr, ok := CronShared.Get(step.CronID)
+	var targets []*model.Server
+	if ok {
+		targets = cronTargets(cr, step.Servers, step.ServerGroups, state.triggerServer)
+	}
+	if len(targets) == 0 {
+		// 任务已被删除或没有可执行的服务器
+		stepRun.Status = model.WorkflowStatusFailed
+		stepRun.FinishedAt = &now
+		state.failed[index] = true
+		return nil
+	}
+
+	dispatches := make([]workflowDispatch, 0, len(targets))
+	for _, s := range targets {
+		dispatches = append(dispatches, workflowDispatch{index: index, cron: cr, server: s, params: step.Parameters})
+	}
+	state.dispatching[index] = len(targets)
+	return dispatches
+}
+
+// waiting 判断步骤是否还有未下发完成或未返回结果的执行
+func (s *workflowRunState) waiting(index int) bool {
+	if s.dispatching[index] > 0 {
+		return true
+	}
+	for _, i := range s.pending {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// isDispatching 判断是否有步骤正在下发
+func (s *workflowRunState) isDispatching() bool {
+	for _, n := range s.dispatching {
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// known 判断执行记录是否已登记到本次执行的步骤中
+func (s *workflowRunState) known(executionID uint64) bool {
+	return slices.ContainsFunc(s.run.Steps, func(step model.WorkflowStepRun) bool {
+		return slices.Contains(step.ExecutionIDs, executionID)
+	})
+}
+
+// check 判断步骤的前置步骤是否均已结束，以及连线条件是否全部满足
+func (s *workflowRunState) check(stepID string) (ready, satisfied bool) {
+	satisfied = true
+	for _, edge := range s.workflow.Edges {
+		if edge.To != stepID {
+			continue
+		}
+		index := slices.IndexFunc(s.workflow.Steps, func(step model.WorkflowStep) bool {
+			return step.ID == edge.From
+		})
+		if index < 0 {
+			continue
+		}
+		switch s.run.Steps[index].Status {
+		case model.WorkflowStatusPending, model.WorkflowStatusRunning:
+			return false, false
+		case model.WorkflowStatusSucceeded:
+			satisfied = satisfied && edge.Condition != model.WorkflowEdgeOnFailure
+		case model.WorkflowStatusFailed:
+			satisfied = satisfied && edge.Condition != model.WorkflowEdgeOnSuccess
+		default:
+			// 前置步骤被跳过时，仅无条件连线可以继续
+			satisfied = satisfied && edge.Condition == model.WorkflowEdgeAlways
+		}
+	}
+	return true, satisfied
+}